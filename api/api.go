@@ -6,11 +6,23 @@ package api
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/deepmap/oapi-codegen/pkg/runtime"
 	"github.com/go-chi/chi/v5"
 )
 
+// Defines values for ExportStatus.
+const (
+	ExportStatusComplete ExportStatus = "complete"
+
+	ExportStatusFailed ExportStatus = "failed"
+
+	ExportStatusQueued ExportStatus = "queued"
+
+	ExportStatusRunning ExportStatus = "running"
+)
+
 // Categories defines model for Categories.
 type Categories []Triplet
 
@@ -20,6 +32,43 @@ type Error struct {
 	Error string `json:"error"`
 }
 
+// Export defines model for Export.
+type Export struct {
+	Created time.Time `json:"created"`
+
+	// URL of the CSV output, once complete
+	Download *string `json:"download,omitempty"`
+
+	// why the export failed
+	Error   *string       `json:"error,omitempty"`
+	Id      string        `json:"id"`
+	Request ExportRequest `json:"request"`
+
+	// size of the CSV output in bytes, once complete
+	Size    *int64       `json:"size,omitempty"`
+	Status  ExportStatus `json:"status"`
+	Updated time.Time    `json:"updated"`
+}
+
+// ExportStatus defines model for Export.Status.
+type ExportStatus string
+
+// ExportRequest defines model for ExportRequest.
+type ExportRequest struct {
+	Bbox        *string   `json:"bbox,omitempty"`
+	Censustable *string   `json:"censustable,omitempty"`
+	Cols        *[]string `json:"cols,omitempty"`
+	DivideBy    *string   `json:"divide_by,omitempty"`
+	Geotype     *[]string `json:"geotype,omitempty"`
+	Location    *string   `json:"location,omitempty"`
+	Polygon     *string   `json:"polygon,omitempty"`
+	Radius      *int      `json:"radius,omitempty"`
+	Rows        *[]string `json:"rows,omitempty"`
+
+	// census year
+	Year int `json:"year"`
+}
+
 // Metadata defines model for Metadata.
 type Metadata struct {
 	Code   *string `json:"code,omitempty"`
@@ -50,6 +99,44 @@ type Triplet struct {
 	Slug *string `json:"slug,omitempty"`
 }
 
+// GetAggregateYearParams defines parameters for GetAggregateYear.
+type GetAggregateYearParams struct {
+	// [ONS codes](https://en.wikipedia.org/wiki/ONS_coding_system) for the geographies making up the area.
+	// Uses the same syntax as the rows parameter for /query, except ALL is not allowed.
+	// All geographies must be the same geotype.
+	Rows *[]string `json:"rows,omitempty"`
+
+	// The census data that you want summed. Uses the same syntax as the cols parameter for /query.
+	// The totals category for each requested category is added automatically.
+	Cols []string `json:"cols"`
+
+	// (OPTIONAL) - the geotype of the areas summed over a polygon or radius. Defaults to OA.
+	// An area is included if its centroid lies within the polygon or circle.
+	Geotype *string `json:"geotype,omitempty"`
+
+	// Radius and location (both are required) select the areas whose centroid is within radius metres
+	// of the long,lat pair location, e.g. location=0.1338,51.4635&radius=1000.
+	Location *string `json:"location,omitempty"`
+
+	// Radius and location (both are required) select the areas whose centroid is within radius metres
+	// of the long,lat pair location, e.g. location=0.1338,51.4635&radius=1000.
+	Radius *int `json:"radius,omitempty"`
+
+	// A sequence of long, lat coordinate pairs representing a closed polygon (NB - 'closed' means the first and last coordinate pair
+	// must be the same), e.g. polygon=0.0844,51.4897,0.1214,51.4910,0.1338,51.4635,0.1017,51.4647,0.0844,51.4897. This will select
+	// the areas whose centroid lies within this polygon.
+	Polygon *string `json:"polygon,omitempty"`
+}
+
+// PostCacheInvalidateParams defines parameters for PostCacheInvalidate.
+type PostCacheInvalidateParams struct {
+	// tag to invalidate, such as year=2011 or table=QS501EW
+	Tag *[]string `json:"tag,omitempty"`
+
+	// request URI prefix to invalidate
+	Prefix *string `json:"prefix,omitempty"`
+}
+
 // GetCkmeansYearParams defines parameters for GetCkmeansYear.
 type GetCkmeansYearParams struct {
 	// The census data category to calculate data breaks for.
@@ -81,8 +168,87 @@ type GetCkmeansYearParams struct {
 	// breaks, instead of raw data (NB if multiple cat are supplied, each cat will be divided by divide_by). Only
 	// single values for divide_by are supported.
 	DivideBy *string `json:"divide_by,omitempty"`
+
+	// (OPTIONAL) - how to choose the data breaks. Defaults to ckmeans.
+	//   - ckmeans: natural breaks, minimising the variance within each class
+	//   - jenks-sample: ckmeans over an evenly spaced sample of 10000 values, for large geotypes such as OA
+	//   - quantile: the same number of areas in each class; suits skewed data, where ckmeans can put
+	//     nearly every area in one class
+	//   - equal-interval: classes of equal width between the min and max
+	//   - standard-deviation: classes one standard deviation wide, centred on the mean
+	//   - head-tail: head/tail breaks, repeatedly splitting the areas above the mean while they are
+	//     under 40% of those split, for heavy tailed data
+	//
+	// Every method returns the upper breakpoint of each class, but some may return fewer than k breaks,
+	// for example quantile breaks when many areas have the same value.
+	Method *GetCkmeansYearParamsMethod `json:"method,omitempty"`
+
+	// (OPTIONAL) - if true, also return <geotype>_counts, <geotype>_means and <geotype>_medians for each
+	// category, holding the number of areas in each class and the mean and median of their values, in the
+	// same order as the breaks. The mean and median of an empty class are 0.
+	Detail *bool `json:"detail,omitempty"`
+
+	// (OPTIONAL) - [ONS codes](https://en.wikipedia.org/wiki/ONS_coding_system) of the geographies to classify,
+	// as for /query/{year}, so breaks are calculated over a region rather than nationally.
+	// rows, bbox, location/radius and polygon may be combined, and select any geography matching one of them.
+	// If none are given, or rows=ALL, every geography of each geotype is classified.
+	Rows *[]string `json:"rows,omitempty"`
+
+	// (OPTIONAL) - two long, lat coordinate pairs representing the opposite corners of a bounding box, as for
+	// /query/{year}. Only geographies within the bounding box are classified.
+	Bbox *string `json:"bbox,omitempty"`
+
+	// (OPTIONAL) - a long,lat pair; with radius, only geographies within radius metres of location are classified,
+	// e.g. location=0.1338,51.4635&radius=1000.
+	Location *string `json:"location,omitempty"`
+
+	// (OPTIONAL) - radius in metres around location, see location.
+	Radius *int `json:"radius,omitempty"`
+
+	// (OPTIONAL) - a closed sequence of long, lat coordinate pairs, as for /query/{year}. Only geographies within
+	// the polygon are classified.
+	Polygon *string `json:"polygon,omitempty"`
+}
+
+// GetCkmeansYearParamsMethod defines parameters for GetCkmeansYear.
+type GetCkmeansYearParamsMethod string
+
+// GetCkmeansYearClassesParams defines parameters for GetCkmeansYearClasses.
+type GetCkmeansYearClassesParams struct {
+	// The census data categories to classify, as for /ckmeans/{year}.
+	Cat *[]string `json:"cat,omitempty"`
+
+	// The types of geography to classify, as for /ckmeans/{year}.
+	Geotype *[]string `json:"geotype,omitempty"`
+
+	// The number of classes.
+	K *int `json:"k,omitempty"`
+
+	// (OPTIONAL) - census data category to use as denominator, as for /ckmeans/{year}.
+	DivideBy *string `json:"divide_by,omitempty"`
+
+	// (OPTIONAL) - how to choose the breaks, as for /ckmeans/{year}. Defaults to ckmeans.
+	Method *GetCkmeansYearClassesParamsMethod `json:"method,omitempty"`
+
+	// (OPTIONAL) - geographies to classify, as for /ckmeans/{year}.
+	Rows *[]string `json:"rows,omitempty"`
+
+	// (OPTIONAL) - bounding box of the geographies to classify, as for /ckmeans/{year}.
+	Bbox *string `json:"bbox,omitempty"`
+
+	// (OPTIONAL) - centre of the circle of geographies to classify, as for /ckmeans/{year}.
+	Location *string `json:"location,omitempty"`
+
+	// (OPTIONAL) - radius in metres of the circle of geographies to classify, as for /ckmeans/{year}.
+	Radius *int `json:"radius,omitempty"`
+
+	// (OPTIONAL) - polygon enclosing the geographies to classify, as for /ckmeans/{year}.
+	Polygon *string `json:"polygon,omitempty"`
 }
 
+// GetCkmeansYearClassesParamsMethod defines parameters for GetCkmeansYearClasses.
+type GetCkmeansYearClassesParamsMethod string
+
 // GetCkmeansratioYearParams defines parameters for GetCkmeansratioYear.
 type GetCkmeansratioYearParams struct {
 	// The census data category to use as numerator (cat1/cat2) when producing the ratio to calculate data breaks for
@@ -100,6 +266,29 @@ type GetCkmeansratioYearParams struct {
 	K *int `json:"k,omitempty"`
 }
 
+// GetCompareParams defines parameters for GetCompare.
+type GetCompareParams struct {
+	// [ONS codes](https://en.wikipedia.org/wiki/ONS_coding_system) for the geographies, as in the to year, that you
+	// want data for. Uses the same syntax as the rows parameter for /query, including ALL.
+	Rows []string `json:"rows"`
+
+	// The census data that you want. Uses the same syntax as the cols parameter for /query.
+	// Categories are matched between years by code.
+	Cols []string `json:"cols"`
+
+	// Geotype filters API results to a specific geography type. Can be single values or comma-separated array.
+	Geotype *[]string `json:"geotype,omitempty"`
+}
+
+// PostExportsJSONBody defines parameters for PostExports.
+type PostExportsJSONBody ExportRequest
+
+// GetExportParams defines parameters for GetExport.
+type GetExportParams struct {
+	// (OPTIONAL) - if true, return the CSV output of a complete export instead of its status
+	Download *bool `json:"download,omitempty"`
+}
+
 // GetGeoParams defines parameters for GetGeo.
 type GetGeoParams struct {
 	// Geography code, eg E09000004
@@ -107,14 +296,37 @@ type GetGeoParams struct {
 
 	// Geography name, eg Bexley
 	Geoname *string `json:"geoname,omitempty"`
+
+	// (OPTIONAL) - language of the area name in the response. With cy, geoname may be the English or Welsh name. Overrides the Accept-Language header. Can be:
+	// - en (English, the default)
+	// - cy (Welsh). Names without a Welsh version are given in English.
+	Lang *GetGeoParamsLang `json:"lang,omitempty"`
+}
+
+// GetGeoParamsLang defines parameters for GetGeo.
+type GetGeoParamsLang string
+
+// GetGeoChildrenParams defines parameters for GetGeoChildren.
+type GetGeoChildrenParams struct {
+	// (OPTIONAL) - the geotype of the areas to list, eg LSOA.
+	// Defaults to the next geotype down from the area's own, eg LSOA for an MSOA.
+	Geotype *string `json:"geotype,omitempty"`
 }
 
 // GetMetadataYearParams defines parameters for GetMetadataYear.
 type GetMetadataYearParams struct {
 	// Use filtertotals=true if you want to have 'totals' categories separated from other categories in the response (see Examples).
 	Filtertotals *bool `json:"filtertotals,omitempty"`
+
+	// (OPTIONAL) - language of topic, table and category names. Overrides the Accept-Language header. Can be:
+	// - en (English, the default)
+	// - cy (Welsh). Names without a Welsh version are given in English.
+	Lang *GetMetadataYearParamsLang `json:"lang,omitempty"`
 }
 
+// GetMetadataYearParamsLang defines parameters for GetMetadataYear.
+type GetMetadataYearParamsLang string
+
 // GetQueryYearParams defines parameters for GetQueryYear.
 type GetQueryYearParams struct {
 	// [ONS codes](https://en.wikipedia.org/wiki/ONS_coding_system) for the geographies that you
@@ -166,8 +378,35 @@ type GetQueryYearParams struct {
 	// When divide_by is given, the returned value for a category will be the value of the category divided by the value of the
 	// divide_by category.
 	DivideBy *string `json:"divide_by,omitempty"`
+
+	// (OPTIONAL) - output format. Overrides the Accept header. Can be:
+	// - csv (text/csv, the default)
+	// - json (application/json), an array of objects, one per geography
+	// - geojson (application/geo+json), a FeatureCollection with each geography's boundary attached
+	// - parquet (application/vnd.apache.parquet)
+	Format *GetQueryYearParamsFormat `json:"format,omitempty"`
+
+	// (OPTIONAL) - language of the geography_name column, which is included by asking for it in cols. Overrides the Accept-Language header. Can be:
+	// - en (English, the default)
+	// - cy (Welsh). Names without a Welsh version are given in English.
+	Lang *GetQueryYearParamsLang `json:"lang,omitempty"`
+
+	// (OPTIONAL) - if true, CSV rows are sent as each geography is read from the database,
+	// rather than after the whole table is built. Use this for bulk downloads such as rows=ALL.
+	// Streamed responses are not subject to the limit on the number of metrics returned,
+	// only to rate limiting.
+	// Only supported for csv output.
+	// If an error occurs part way through a streamed response, the connection is dropped,
+	// so check the number of rows received.
+	Stream *bool `json:"stream,omitempty"`
 }
 
+// GetQueryYearParamsFormat defines parameters for GetQueryYear.
+type GetQueryYearParamsFormat string
+
+// GetQueryYearParamsLang defines parameters for GetQueryYear.
+type GetQueryYearParamsLang string
+
 // GetQueryParams defines parameters for GetQuery.
 type GetQueryParams struct {
 	// [ONS codes](https://en.wikipedia.org/wiki/ONS_coding_system) for the geographies that you
@@ -212,24 +451,103 @@ type GetQueryParams struct {
 	// must be the same), e.g. polygon=0.0844,51.4897,0.1214,51.4910,0.1338,51.4635,0.1017,51.4647,0.0844,51.4897. This will select
 	// all geographies that lie within this polygon. polygon can be used instead of, or in combination with the rows parameter as a
 	// way of selecting geography.
-	Polygon     *string `json:"polygon,omitempty"`
+	Polygon *string `json:"polygon,omitempty"`
+
+	// Near and k (both are required) will select the k geographies whose centroids are nearest to the long,lat pair near,
+	// e.g. near=0.1338,51.4635&k=10. At least one geotype must be given, and only geographies of those geotypes are considered.
+	// Near and k can be used instead of, or in combination with the other parameters as a way of selecting geography,
+	// except rows=ALL.
+	Near *string `json:"near,omitempty"`
+
+	// The number of geographies to select with near, from 1 to 1000.
+	K *int `json:"k,omitempty"`
+
+	// A single [ONS code](https://en.wikipedia.org/wiki/ONS_coding_system). This will select all geographies sharing a boundary
+	// with that geography, e.g. adjacent=E02000001. Use geotype to restrict the results to the same geotype.
+	// Adjacent can be used instead of, or in combination with the other parameters as a way of selecting geography,
+	// except rows=ALL. An unknown code gives a 404.
+	Adjacent    *string `json:"adjacent,omitempty"`
 	Censustable *string `json:"censustable,omitempty"`
 }
 
+// GetSearchParams defines parameters for GetSearch.
+type GetSearchParams struct {
+	// Place name, in English or Welsh, or postcode
+	Q string `json:"q"`
+
+	// (OPTIONAL) - only return geographies of these geotypes, eg geotype=LAD,MSOA
+	Geotype *[]string `json:"geotype,omitempty"`
+
+	// (OPTIONAL) - maximum number of results, 1 to 100. Defaults to 10.
+	Limit *int `json:"limit,omitempty"`
+}
+
+// GetStatsYearParams defines parameters for GetStatsYear.
+type GetStatsYearParams struct {
+	// The census data categories to describe, as for /ckmeans/{year}.
+	// NB - use of ranges (e.g. QS202EW0003...QS202EW0004) is NOT supported.
+	Cat *[]string `json:"cat,omitempty"`
+
+	// The types of geography to describe, as for /ckmeans/{year}.
+	Geotype *[]string `json:"geotype,omitempty"`
+
+	// (OPTIONAL) - census data category to use as denominator, as for /ckmeans/{year}. The statistics are then
+	// of the ratio of each cat to divide_by.
+	DivideBy *string `json:"divide_by,omitempty"`
+
+	// (OPTIONAL) - percentiles to return, from 0 to 100, e.g. percentiles=5&percentiles=95. Defaults to 10, 25, 75 and 90.
+	// Percentiles between two values are interpolated linearly.
+	Percentiles *[]float64 `json:"percentiles,omitempty"`
+
+	// (OPTIONAL) - number of histogram bins, of equal width between the min and max, from 1 to 100. Defaults to 10.
+	// Each bin holds the values from its lower edge up to its upper edge; the last bin includes the max.
+	Bins *int `json:"bins,omitempty"`
+}
+
+// PostExportsJSONRequestBody defines body for PostExports for application/json ContentType.
+type PostExportsJSONRequestBody PostExportsJSONBody
+
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
+	// sum census data over a user-defined area
+	// (GET /aggregate/{year})
+	GetAggregateYear(w http.ResponseWriter, r *http.Request, year int, params GetAggregateYearParams)
+	// remove selected entries from request cache
+	// (POST /cache/invalidate)
+	PostCacheInvalidate(w http.ResponseWriter, r *http.Request, params PostCacheInvalidateParams)
+	// request cache counters and entries
+	// (GET /cache/stats)
+	GetCacheStats(w http.ResponseWriter, r *http.Request)
 	// calculate ckmeans over a given category and geography type
 	// (GET /ckmeans/{year})
 	GetCkmeansYear(w http.ResponseWriter, r *http.Request, year int, params GetCkmeansYearParams)
+	// class of each area, using the breaks from /ckmeans
+	// (GET /ckmeans/{year}/classes)
+	GetCkmeansYearClasses(w http.ResponseWriter, r *http.Request, year int, params GetCkmeansYearClassesParams)
 	// calculate ckmeans for the ratio between two given categories (cat1 / cat2) for a given geography type
 	// (GET /ckmeansratio/{year})
 	GetCkmeansratioYear(w http.ResponseWriter, r *http.Request, year int, params GetCkmeansratioYearParams)
 	// remove all entries from request cache
 	// (GET /clear-cache)
 	GetClearCache(w http.ResponseWriter, r *http.Request)
+	// compare census data between two years
+	// (GET /compare/{from}/{to})
+	GetCompare(w http.ResponseWriter, r *http.Request, from int, to int, params GetCompareParams)
+	// Start a bulk export of census data
+	// (POST /exports)
+	PostExports(w http.ResponseWriter, r *http.Request)
+	// Get the status of an export, or download it
+	// (GET /exports/{id})
+	GetExport(w http.ResponseWriter, r *http.Request, id string, params GetExportParams)
 	// Get geographic info about an area. Queryable with either geocode or geoname (but not both)
 	// (GET /geo/{year})
 	GetGeo(w http.ResponseWriter, r *http.Request, year int, params GetGeoParams)
+	// List the areas of a geotype contained in an area
+	// (GET /geo/{year}/{code}/children)
+	GetGeoChildren(w http.ResponseWriter, r *http.Request, year int, code string, params GetGeoChildrenParams)
+	// List the areas containing an area, largest first
+	// (GET /geo/{year}/{code}/parents)
+	GetGeoParents(w http.ResponseWriter, r *http.Request, year int, code string)
 	// Get Metadata
 	// (GET /metadata/{year})
 	GetMetadataYear(w http.ResponseWriter, r *http.Request, year int, params GetMetadataYearParams)
@@ -242,6 +560,12 @@ type ServerInterface interface {
 	// List geocodes matching search conditions
 	// (GET /query2/{year})
 	GetQuery(w http.ResponseWriter, r *http.Request, year int, params GetQueryParams)
+	// Search for geographies by name or postcode
+	// (GET /search/{year})
+	GetSearch(w http.ResponseWriter, r *http.Request, year int, params GetSearchParams)
+	// descriptive statistics of a census data category
+	// (GET /stats/{year})
+	GetStatsYear(w http.ResponseWriter, r *http.Request, year int, params GetStatsYearParams)
 	// spec
 	// (GET /swagger)
 	GetSwagger(w http.ResponseWriter, r *http.Request)
@@ -261,8 +585,8 @@ type ServerInterfaceWrapper struct {
 
 type MiddlewareFunc func(http.HandlerFunc) http.HandlerFunc
 
-// GetCkmeansYear operation middleware
-func (siw *ServerInterfaceWrapper) GetCkmeansYear(w http.ResponseWriter, r *http.Request) {
+// GetAggregateYear operation middleware
+func (siw *ServerInterfaceWrapper) GetAggregateYear(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	var err error
@@ -277,16 +601,30 @@ func (siw *ServerInterfaceWrapper) GetCkmeansYear(w http.ResponseWriter, r *http
 	}
 
 	// Parameter object where we will unmarshal all parameters from the context
-	var params GetCkmeansYearParams
+	var params GetAggregateYearParams
 
-	// ------------- Optional query parameter "cat" -------------
-	if paramValue := r.URL.Query().Get("cat"); paramValue != "" {
+	// ------------- Optional query parameter "rows" -------------
+	if paramValue := r.URL.Query().Get("rows"); paramValue != "" {
 
 	}
 
-	err = runtime.BindQueryParameter("form", true, false, "cat", r.URL.Query(), &params.Cat)
+	err = runtime.BindQueryParameter("form", true, false, "rows", r.URL.Query(), &params.Rows)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Invalid format for parameter cat: %s", err), http.StatusBadRequest)
+		http.Error(w, fmt.Sprintf("Invalid format for parameter rows: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Required query parameter "cols" -------------
+	if paramValue := r.URL.Query().Get("cols"); paramValue != "" {
+
+	} else {
+		http.Error(w, "Query argument cols is required, but not found", http.StatusBadRequest)
+		return
+	}
+
+	err = runtime.BindQueryParameter("form", true, true, "cols", r.URL.Query(), &params.Cols)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter cols: %s", err), http.StatusBadRequest)
 		return
 	}
 
@@ -301,30 +639,41 @@ func (siw *ServerInterfaceWrapper) GetCkmeansYear(w http.ResponseWriter, r *http
 		return
 	}
 
-	// ------------- Optional query parameter "k" -------------
-	if paramValue := r.URL.Query().Get("k"); paramValue != "" {
+	// ------------- Optional query parameter "location" -------------
+	if paramValue := r.URL.Query().Get("location"); paramValue != "" {
 
 	}
 
-	err = runtime.BindQueryParameter("form", true, false, "k", r.URL.Query(), &params.K)
+	err = runtime.BindQueryParameter("form", true, false, "location", r.URL.Query(), &params.Location)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Invalid format for parameter k: %s", err), http.StatusBadRequest)
+		http.Error(w, fmt.Sprintf("Invalid format for parameter location: %s", err), http.StatusBadRequest)
 		return
 	}
 
-	// ------------- Optional query parameter "divide_by" -------------
-	if paramValue := r.URL.Query().Get("divide_by"); paramValue != "" {
+	// ------------- Optional query parameter "radius" -------------
+	if paramValue := r.URL.Query().Get("radius"); paramValue != "" {
 
 	}
 
-	err = runtime.BindQueryParameter("form", true, false, "divide_by", r.URL.Query(), &params.DivideBy)
+	err = runtime.BindQueryParameter("form", true, false, "radius", r.URL.Query(), &params.Radius)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Invalid format for parameter divide_by: %s", err), http.StatusBadRequest)
+		http.Error(w, fmt.Sprintf("Invalid format for parameter radius: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "polygon" -------------
+	if paramValue := r.URL.Query().Get("polygon"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "polygon", r.URL.Query(), &params.Polygon)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter polygon: %s", err), http.StatusBadRequest)
 		return
 	}
 
 	var handler = func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.GetCkmeansYear(w, r, year, params)
+		siw.Handler.GetAggregateYear(w, r, year, params)
 	}
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -334,8 +683,65 @@ func (siw *ServerInterfaceWrapper) GetCkmeansYear(w http.ResponseWriter, r *http
 	handler(w, r.WithContext(ctx))
 }
 
-// GetCkmeansratioYear operation middleware
-func (siw *ServerInterfaceWrapper) GetCkmeansratioYear(w http.ResponseWriter, r *http.Request) {
+// PostCacheInvalidate operation middleware
+func (siw *ServerInterfaceWrapper) PostCacheInvalidate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PostCacheInvalidateParams
+
+	// ------------- Optional query parameter "tag" -------------
+	if paramValue := r.URL.Query().Get("tag"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "tag", r.URL.Query(), &params.Tag)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter tag: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "prefix" -------------
+	if paramValue := r.URL.Query().Get("prefix"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "prefix", r.URL.Query(), &params.Prefix)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter prefix: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	var handler = func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostCacheInvalidate(w, r, params)
+	}
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler(w, r.WithContext(ctx))
+}
+
+// GetCacheStats operation middleware
+func (siw *ServerInterfaceWrapper) GetCacheStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var handler = func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetCacheStats(w, r)
+	}
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler(w, r.WithContext(ctx))
+}
+
+// GetCkmeansYear operation middleware
+func (siw *ServerInterfaceWrapper) GetCkmeansYear(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	var err error
@@ -350,30 +756,588 @@ func (siw *ServerInterfaceWrapper) GetCkmeansratioYear(w http.ResponseWriter, r
 	}
 
 	// Parameter object where we will unmarshal all parameters from the context
-	var params GetCkmeansratioYearParams
+	var params GetCkmeansYearParams
 
-	// ------------- Optional query parameter "cat1" -------------
-	if paramValue := r.URL.Query().Get("cat1"); paramValue != "" {
+	// ------------- Optional query parameter "cat" -------------
+	if paramValue := r.URL.Query().Get("cat"); paramValue != "" {
 
 	}
 
-	err = runtime.BindQueryParameter("form", true, false, "cat1", r.URL.Query(), &params.Cat1)
+	err = runtime.BindQueryParameter("form", true, false, "cat", r.URL.Query(), &params.Cat)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Invalid format for parameter cat1: %s", err), http.StatusBadRequest)
+		http.Error(w, fmt.Sprintf("Invalid format for parameter cat: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "geotype" -------------
+	if paramValue := r.URL.Query().Get("geotype"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "geotype", r.URL.Query(), &params.Geotype)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter geotype: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "k" -------------
+	if paramValue := r.URL.Query().Get("k"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "k", r.URL.Query(), &params.K)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter k: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "divide_by" -------------
+	if paramValue := r.URL.Query().Get("divide_by"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "divide_by", r.URL.Query(), &params.DivideBy)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter divide_by: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "method" -------------
+	if paramValue := r.URL.Query().Get("method"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "method", r.URL.Query(), &params.Method)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter method: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "detail" -------------
+	if paramValue := r.URL.Query().Get("detail"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "detail", r.URL.Query(), &params.Detail)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter detail: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "rows" -------------
+	if paramValue := r.URL.Query().Get("rows"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "rows", r.URL.Query(), &params.Rows)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter rows: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "bbox" -------------
+	if paramValue := r.URL.Query().Get("bbox"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "bbox", r.URL.Query(), &params.Bbox)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter bbox: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "location" -------------
+	if paramValue := r.URL.Query().Get("location"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "location", r.URL.Query(), &params.Location)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter location: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "radius" -------------
+	if paramValue := r.URL.Query().Get("radius"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "radius", r.URL.Query(), &params.Radius)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter radius: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "polygon" -------------
+	if paramValue := r.URL.Query().Get("polygon"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "polygon", r.URL.Query(), &params.Polygon)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter polygon: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	var handler = func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetCkmeansYear(w, r, year, params)
+	}
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler(w, r.WithContext(ctx))
+}
+
+// GetCkmeansYearClasses operation middleware
+func (siw *ServerInterfaceWrapper) GetCkmeansYearClasses(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "year" -------------
+	var year int
+
+	err = runtime.BindStyledParameter("simple", false, "year", chi.URLParam(r, "year"), &year)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter year: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetCkmeansYearClassesParams
+
+	// ------------- Optional query parameter "cat" -------------
+	if paramValue := r.URL.Query().Get("cat"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "cat", r.URL.Query(), &params.Cat)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter cat: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "geotype" -------------
+	if paramValue := r.URL.Query().Get("geotype"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "geotype", r.URL.Query(), &params.Geotype)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter geotype: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "k" -------------
+	if paramValue := r.URL.Query().Get("k"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "k", r.URL.Query(), &params.K)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter k: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "divide_by" -------------
+	if paramValue := r.URL.Query().Get("divide_by"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "divide_by", r.URL.Query(), &params.DivideBy)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter divide_by: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "method" -------------
+	if paramValue := r.URL.Query().Get("method"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "method", r.URL.Query(), &params.Method)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter method: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "rows" -------------
+	if paramValue := r.URL.Query().Get("rows"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "rows", r.URL.Query(), &params.Rows)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter rows: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "bbox" -------------
+	if paramValue := r.URL.Query().Get("bbox"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "bbox", r.URL.Query(), &params.Bbox)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter bbox: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "location" -------------
+	if paramValue := r.URL.Query().Get("location"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "location", r.URL.Query(), &params.Location)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter location: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "radius" -------------
+	if paramValue := r.URL.Query().Get("radius"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "radius", r.URL.Query(), &params.Radius)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter radius: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "polygon" -------------
+	if paramValue := r.URL.Query().Get("polygon"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "polygon", r.URL.Query(), &params.Polygon)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter polygon: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	var handler = func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetCkmeansYearClasses(w, r, year, params)
+	}
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler(w, r.WithContext(ctx))
+}
+
+// GetCkmeansratioYear operation middleware
+func (siw *ServerInterfaceWrapper) GetCkmeansratioYear(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "year" -------------
+	var year int
+
+	err = runtime.BindStyledParameter("simple", false, "year", chi.URLParam(r, "year"), &year)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter year: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetCkmeansratioYearParams
+
+	// ------------- Optional query parameter "cat1" -------------
+	if paramValue := r.URL.Query().Get("cat1"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "cat1", r.URL.Query(), &params.Cat1)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter cat1: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "cat2" -------------
+	if paramValue := r.URL.Query().Get("cat2"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "cat2", r.URL.Query(), &params.Cat2)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter cat2: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "geotype" -------------
+	if paramValue := r.URL.Query().Get("geotype"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "geotype", r.URL.Query(), &params.Geotype)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter geotype: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "k" -------------
+	if paramValue := r.URL.Query().Get("k"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "k", r.URL.Query(), &params.K)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter k: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	var handler = func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetCkmeansratioYear(w, r, year, params)
+	}
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler(w, r.WithContext(ctx))
+}
+
+// GetClearCache operation middleware
+func (siw *ServerInterfaceWrapper) GetClearCache(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var handler = func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetClearCache(w, r)
+	}
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler(w, r.WithContext(ctx))
+}
+
+// GetCompare operation middleware
+func (siw *ServerInterfaceWrapper) GetCompare(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "from" -------------
+	var from int
+
+	err = runtime.BindStyledParameter("simple", false, "from", chi.URLParam(r, "from"), &from)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter from: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Path parameter "to" -------------
+	var to int
+
+	err = runtime.BindStyledParameter("simple", false, "to", chi.URLParam(r, "to"), &to)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter to: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetCompareParams
+
+	// ------------- Required query parameter "rows" -------------
+	if paramValue := r.URL.Query().Get("rows"); paramValue != "" {
+
+	} else {
+		http.Error(w, "Query argument rows is required, but not found", http.StatusBadRequest)
+		return
+	}
+
+	err = runtime.BindQueryParameter("form", true, true, "rows", r.URL.Query(), &params.Rows)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter rows: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Required query parameter "cols" -------------
+	if paramValue := r.URL.Query().Get("cols"); paramValue != "" {
+
+	} else {
+		http.Error(w, "Query argument cols is required, but not found", http.StatusBadRequest)
+		return
+	}
+
+	err = runtime.BindQueryParameter("form", true, true, "cols", r.URL.Query(), &params.Cols)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter cols: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "geotype" -------------
+	if paramValue := r.URL.Query().Get("geotype"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "geotype", r.URL.Query(), &params.Geotype)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter geotype: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	var handler = func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetCompare(w, r, from, to, params)
+	}
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler(w, r.WithContext(ctx))
+}
+
+// PostExports operation middleware
+func (siw *ServerInterfaceWrapper) PostExports(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var handler = func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostExports(w, r)
+	}
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler(w, r.WithContext(ctx))
+}
+
+// GetExport operation middleware
+func (siw *ServerInterfaceWrapper) GetExport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameter("simple", false, "id", chi.URLParam(r, "id"), &id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter id: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetExportParams
+
+	// ------------- Optional query parameter "download" -------------
+	if paramValue := r.URL.Query().Get("download"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "download", r.URL.Query(), &params.Download)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter download: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	var handler = func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetExport(w, r, id, params)
+	}
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler(w, r.WithContext(ctx))
+}
+
+// GetGeo operation middleware
+func (siw *ServerInterfaceWrapper) GetGeo(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "year" -------------
+	var year int
+
+	err = runtime.BindStyledParameter("simple", false, "year", chi.URLParam(r, "year"), &year)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter year: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetGeoParams
+
+	// ------------- Optional query parameter "geocode" -------------
+	if paramValue := r.URL.Query().Get("geocode"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "geocode", r.URL.Query(), &params.Geocode)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter geocode: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "geoname" -------------
+	if paramValue := r.URL.Query().Get("geoname"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "geoname", r.URL.Query(), &params.Geoname)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter geoname: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "lang" -------------
+	if paramValue := r.URL.Query().Get("lang"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "lang", r.URL.Query(), &params.Lang)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter lang: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	var handler = func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetGeo(w, r, year, params)
+	}
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler(w, r.WithContext(ctx))
+}
+
+// GetGeoChildren operation middleware
+func (siw *ServerInterfaceWrapper) GetGeoChildren(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "year" -------------
+	var year int
+
+	err = runtime.BindStyledParameter("simple", false, "year", chi.URLParam(r, "year"), &year)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter year: %s", err), http.StatusBadRequest)
 		return
 	}
 
-	// ------------- Optional query parameter "cat2" -------------
-	if paramValue := r.URL.Query().Get("cat2"); paramValue != "" {
-
-	}
+	// ------------- Path parameter "code" -------------
+	var code string
 
-	err = runtime.BindQueryParameter("form", true, false, "cat2", r.URL.Query(), &params.Cat2)
+	err = runtime.BindStyledParameter("simple", false, "code", chi.URLParam(r, "code"), &code)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Invalid format for parameter cat2: %s", err), http.StatusBadRequest)
+		http.Error(w, fmt.Sprintf("Invalid format for parameter code: %s", err), http.StatusBadRequest)
 		return
 	}
 
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetGeoChildrenParams
+
 	// ------------- Optional query parameter "geotype" -------------
 	if paramValue := r.URL.Query().Get("geotype"); paramValue != "" {
 
@@ -385,34 +1349,8 @@ func (siw *ServerInterfaceWrapper) GetCkmeansratioYear(w http.ResponseWriter, r
 		return
 	}
 
-	// ------------- Optional query parameter "k" -------------
-	if paramValue := r.URL.Query().Get("k"); paramValue != "" {
-
-	}
-
-	err = runtime.BindQueryParameter("form", true, false, "k", r.URL.Query(), &params.K)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Invalid format for parameter k: %s", err), http.StatusBadRequest)
-		return
-	}
-
-	var handler = func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.GetCkmeansratioYear(w, r, year, params)
-	}
-
-	for _, middleware := range siw.HandlerMiddlewares {
-		handler = middleware(handler)
-	}
-
-	handler(w, r.WithContext(ctx))
-}
-
-// GetClearCache operation middleware
-func (siw *ServerInterfaceWrapper) GetClearCache(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-
 	var handler = func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.GetClearCache(w, r)
+		siw.Handler.GetGeoChildren(w, r, year, code, params)
 	}
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -422,8 +1360,8 @@ func (siw *ServerInterfaceWrapper) GetClearCache(w http.ResponseWriter, r *http.
 	handler(w, r.WithContext(ctx))
 }
 
-// GetGeo operation middleware
-func (siw *ServerInterfaceWrapper) GetGeo(w http.ResponseWriter, r *http.Request) {
+// GetGeoParents operation middleware
+func (siw *ServerInterfaceWrapper) GetGeoParents(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	var err error
@@ -437,33 +1375,17 @@ func (siw *ServerInterfaceWrapper) GetGeo(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	// Parameter object where we will unmarshal all parameters from the context
-	var params GetGeoParams
-
-	// ------------- Optional query parameter "geocode" -------------
-	if paramValue := r.URL.Query().Get("geocode"); paramValue != "" {
-
-	}
-
-	err = runtime.BindQueryParameter("form", true, false, "geocode", r.URL.Query(), &params.Geocode)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Invalid format for parameter geocode: %s", err), http.StatusBadRequest)
-		return
-	}
-
-	// ------------- Optional query parameter "geoname" -------------
-	if paramValue := r.URL.Query().Get("geoname"); paramValue != "" {
-
-	}
+	// ------------- Path parameter "code" -------------
+	var code string
 
-	err = runtime.BindQueryParameter("form", true, false, "geoname", r.URL.Query(), &params.Geoname)
+	err = runtime.BindStyledParameter("simple", false, "code", chi.URLParam(r, "code"), &code)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Invalid format for parameter geoname: %s", err), http.StatusBadRequest)
+		http.Error(w, fmt.Sprintf("Invalid format for parameter code: %s", err), http.StatusBadRequest)
 		return
 	}
 
 	var handler = func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.GetGeo(w, r, year, params)
+		siw.Handler.GetGeoParents(w, r, year, code)
 	}
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -502,6 +1424,17 @@ func (siw *ServerInterfaceWrapper) GetMetadataYear(w http.ResponseWriter, r *htt
 		return
 	}
 
+	// ------------- Optional query parameter "lang" -------------
+	if paramValue := r.URL.Query().Get("lang"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "lang", r.URL.Query(), &params.Lang)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter lang: %s", err), http.StatusBadRequest)
+		return
+	}
+
 	var handler = func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.GetMetadataYear(w, r, year, params)
 	}
@@ -656,6 +1589,39 @@ func (siw *ServerInterfaceWrapper) GetQueryYear(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	// ------------- Optional query parameter "format" -------------
+	if paramValue := r.URL.Query().Get("format"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "format", r.URL.Query(), &params.Format)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter format: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "lang" -------------
+	if paramValue := r.URL.Query().Get("lang"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "lang", r.URL.Query(), &params.Lang)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter lang: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "stream" -------------
+	if paramValue := r.URL.Query().Get("stream"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "stream", r.URL.Query(), &params.Stream)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter stream: %s", err), http.StatusBadRequest)
+		return
+	}
+
 	var handler = func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.GetQueryYear(w, r, year, params)
 	}
@@ -762,6 +1728,39 @@ func (siw *ServerInterfaceWrapper) GetQuery(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	// ------------- Optional query parameter "near" -------------
+	if paramValue := r.URL.Query().Get("near"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "near", r.URL.Query(), &params.Near)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter near: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "k" -------------
+	if paramValue := r.URL.Query().Get("k"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "k", r.URL.Query(), &params.K)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter k: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "adjacent" -------------
+	if paramValue := r.URL.Query().Get("adjacent"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "adjacent", r.URL.Query(), &params.Adjacent)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter adjacent: %s", err), http.StatusBadRequest)
+		return
+	}
+
 	// ------------- Optional query parameter "censustable" -------------
 	if paramValue := r.URL.Query().Get("censustable"); paramValue != "" {
 
@@ -784,6 +1783,155 @@ func (siw *ServerInterfaceWrapper) GetQuery(w http.ResponseWriter, r *http.Reque
 	handler(w, r.WithContext(ctx))
 }
 
+// GetSearch operation middleware
+func (siw *ServerInterfaceWrapper) GetSearch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "year" -------------
+	var year int
+
+	err = runtime.BindStyledParameter("simple", false, "year", chi.URLParam(r, "year"), &year)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter year: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetSearchParams
+
+	// ------------- Required query parameter "q" -------------
+	if paramValue := r.URL.Query().Get("q"); paramValue != "" {
+
+	} else {
+		http.Error(w, "Query argument q is required, but not found", http.StatusBadRequest)
+		return
+	}
+
+	err = runtime.BindQueryParameter("form", true, true, "q", r.URL.Query(), &params.Q)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter q: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "geotype" -------------
+	if paramValue := r.URL.Query().Get("geotype"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "geotype", r.URL.Query(), &params.Geotype)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter geotype: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+	if paramValue := r.URL.Query().Get("limit"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter limit: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	var handler = func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetSearch(w, r, year, params)
+	}
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler(w, r.WithContext(ctx))
+}
+
+// GetStatsYear operation middleware
+func (siw *ServerInterfaceWrapper) GetStatsYear(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "year" -------------
+	var year int
+
+	err = runtime.BindStyledParameter("simple", false, "year", chi.URLParam(r, "year"), &year)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter year: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetStatsYearParams
+
+	// ------------- Optional query parameter "cat" -------------
+	if paramValue := r.URL.Query().Get("cat"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "cat", r.URL.Query(), &params.Cat)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter cat: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "geotype" -------------
+	if paramValue := r.URL.Query().Get("geotype"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "geotype", r.URL.Query(), &params.Geotype)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter geotype: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "divide_by" -------------
+	if paramValue := r.URL.Query().Get("divide_by"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "divide_by", r.URL.Query(), &params.DivideBy)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter divide_by: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "percentiles" -------------
+	if paramValue := r.URL.Query().Get("percentiles"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "percentiles", r.URL.Query(), &params.Percentiles)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter percentiles: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// ------------- Optional query parameter "bins" -------------
+	if paramValue := r.URL.Query().Get("bins"); paramValue != "" {
+
+	}
+
+	err = runtime.BindQueryParameter("form", true, false, "bins", r.URL.Query(), &params.Bins)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid format for parameter bins: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	var handler = func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetStatsYear(w, r, year, params)
+	}
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler(w, r.WithContext(ctx))
+}
+
 // GetSwagger operation middleware
 func (siw *ServerInterfaceWrapper) GetSwagger(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -886,18 +2034,45 @@ func HandlerWithOptions(si ServerInterface, options ChiServerOptions) http.Handl
 		HandlerMiddlewares: options.Middlewares,
 	}
 
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/aggregate/{year}", wrapper.GetAggregateYear)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/cache/invalidate", wrapper.PostCacheInvalidate)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/cache/stats", wrapper.GetCacheStats)
+	})
 	r.Group(func(r chi.Router) {
 		r.Get(options.BaseURL+"/ckmeans/{year}", wrapper.GetCkmeansYear)
 	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/ckmeans/{year}/classes", wrapper.GetCkmeansYearClasses)
+	})
 	r.Group(func(r chi.Router) {
 		r.Get(options.BaseURL+"/ckmeansratio/{year}", wrapper.GetCkmeansratioYear)
 	})
 	r.Group(func(r chi.Router) {
 		r.Get(options.BaseURL+"/clear-cache", wrapper.GetClearCache)
 	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/compare/{from}/{to}", wrapper.GetCompare)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/exports", wrapper.PostExports)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/exports/{id}", wrapper.GetExport)
+	})
 	r.Group(func(r chi.Router) {
 		r.Get(options.BaseURL+"/geo/{year}", wrapper.GetGeo)
 	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/geo/{year}/{code}/children", wrapper.GetGeoChildren)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/geo/{year}/{code}/parents", wrapper.GetGeoParents)
+	})
 	r.Group(func(r chi.Router) {
 		r.Get(options.BaseURL+"/metadata/{year}", wrapper.GetMetadataYear)
 	})
@@ -910,6 +2085,12 @@ func HandlerWithOptions(si ServerInterface, options ChiServerOptions) http.Handl
 	r.Group(func(r chi.Router) {
 		r.Get(options.BaseURL+"/query2/{year}", wrapper.GetQuery)
 	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/search/{year}", wrapper.GetSearch)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/stats/{year}", wrapper.GetStatsYear)
+	})
 	r.Group(func(r chi.Router) {
 		r.Get(options.BaseURL+"/swagger", wrapper.GetSwagger)
 	})
@@ -9,68 +9,98 @@
 // a cache key before they are permitted to access the cache.
 //
 // General usage:
-// 1. application calls New to set up the cache manager
-// 2. on each request:
-//	a. generate a cache key based on the request
-//	b. allocate an Entry for this cache key
-//	c. lock the Entry for the duration of cache operations
-//	d. unlock the Entry after cache operations are complete, but before writing to client
-//	3. release the cache key entry
+//  1. application calls New to set up the cache manager
+//  2. on each request:
+//     a. generate a cache key based on the request
+//     b. allocate an Entry for this cache key
+//     c. lock the Entry for the duration of cache operations, giving up if the request is done
+//     d. unlock the Entry after cache operations are complete, but before writing to client
+//  3. release the cache key entry
 //
+// The cache itself is a chain of one or more gocache stores, such as memory, Redis or a
+// local directory.
+// Entry locking is always done within the process; a Locker can be added to also lock
+// keys across every process sharing a store.
 package cache
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 
-	"github.com/allegro/bigcache/v3"
+	"github.com/ONSdigital/dp-geodata-api/pkg/tracing"
+	"github.com/ONSdigital/log.go/v2/log"
 	"github.com/eko/gocache/v2/cache"
 	"github.com/eko/gocache/v2/store"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // A Manager manages the underlying cache and the dynamic set of Entries.
 type Manager struct {
-	cache      *cache.Cache      // underlying cache
-	sync.Mutex                   // protexts operations on locks and references below
-	entries    map[string]*Entry // cache access manager for each key; map index is the key
-	references map[string]int    // reference counts for each key; map index is the key
+	cache      cache.CacheInterface         // underlying cache
+	tiers      []cache.SetterCacheInterface // the stores making up cache, fastest first
+	locker     Locker                       // optional lock shared with other processes
+	sync.Mutex                              // protexts operations on locks and references below
+	entries    map[string]*Entry            // cache access manager for each key; map index is the key
+	references map[string]int               // reference counts for each key; map index is the key
+	index      *index                       // what this process has stored, for stats and invalidation
 }
 
 // An Entry manages cache access and locking for a single cache key.
 type Entry struct {
-	key        string   // the cache key
-	manager    *Manager // our "parent" manager
-	sync.Mutex          // mutex to serialise cache operations related to this key
+	key     string        // the cache key
+	manager *Manager      // our "parent" manager
+	sem     chan struct{} // holds a token while cache operations related to this key are serialised
+	unlock  func()        // releases the Locker lock, if any
 }
 
-// New sets up a new cache and lock manager.
+// New sets up a new cache and lock manager with a single in-memory store.
 func New(ttl time.Duration, megabytes int) (*Manager, error) {
-	// configure bigcache
-	config := bigcache.DefaultConfig(ttl)
-	config.HardMaxCacheSize = megabytes
-
-	// create bigcache client
-	bigcacheClient, err := bigcache.NewBigCache(config)
+	memory, err := NewMemoryStore(ttl, megabytes)
 	if err != nil {
 		return nil, err
 	}
+	return NewChain(ttl, nil, memory), nil
+}
 
-	// use bigcache client as a gocache store
-	bigcacheStore := store.NewBigcache(bigcacheClient, nil)
+// NewChain sets up a new cache and lock manager over stores.
+// Stores are consulted in order, and a value found in a later store is copied back
+// to the earlier ones, so the usual arrangement is a fast local store first, backed
+// by a larger shared or persistent one.
+// Values are written to every store.
+//
+// If locker is not nil, Entry locks are also taken in locker, so processes sharing
+// a store don't all generate the same content.
+//
+// ttl should match the stores' ttl; it is how long entries are kept in the index
+// used by Stats and Invalidate.
+func NewChain(ttl time.Duration, locker Locker, stores ...store.StoreInterface) *Manager {
+	var tiers []cache.SetterCacheInterface
+	for _, s := range stores {
+		tiers = append(tiers, cache.New(s))
+	}
 
-	// create single stage cache using bigcache
-	cacheManager := cache.New(bigcacheStore)
+	var underlying cache.CacheInterface
+	if len(tiers) == 1 {
+		underlying = tiers[0]
+	} else {
+		underlying = cache.NewChain(tiers...)
+	}
 
 	return &Manager{
-		cache:      cacheManager,
+		cache:      underlying,
+		tiers:      tiers,
+		locker:     locker,
 		entries:    map[string]*Entry{},
 		references: map[string]int{},
-	}, nil
+		index:      newIndex(ttl),
+	}
 }
 
 // Clear removes all entries from the cache.
 func (cm *Manager) Clear(ctx context.Context) error {
+	cm.index.clear()
 	return cm.cache.Clear(ctx)
 }
 
@@ -89,6 +119,7 @@ func (cm *Manager) AllocateEntry(key string) *Entry {
 		entry = &Entry{
 			key:     key,
 			manager: cm,
+			sem:     make(chan struct{}, 1),
 		}
 		cm.entries[key] = entry
 		cm.references[key] = 0
@@ -115,16 +146,95 @@ func (entry *Entry) Free() {
 	}
 }
 
+// Lock serialises cache operations on key.
+// Within the process it waits for any other holder of this Entry.
+// With a Locker it then also waits, within the Locker's limits, for other processes.
+// If the Locker fails, the Entry is still locked within the process.
+//
+// Lock gives up when ctx is done, so requests which are abandoned or time out while
+// another request builds the value stop waiting. The Entry is not locked if Lock
+// returns an error, so Unlock must not be called.
+//
+func (entry *Entry) Lock(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "cache.Lock")
+	defer func() { tracing.End(span, err) }()
+
+	select {
+	case entry.sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if entry.manager.locker == nil {
+		return nil
+	}
+	unlock, err := entry.manager.locker.Lock(ctx, entry.key)
+	if err != nil {
+		if ctx.Err() != nil {
+			<-entry.sem
+			return ctx.Err()
+		}
+		log.Warn(ctx, "cannot take shared cache lock", log.Data{"message": err.Error(), "key": entry.key})
+		return nil
+	}
+	entry.unlock = unlock
+	return nil
+}
+
+// Unlock releases the locks taken by Lock.
+func (entry *Entry) Unlock() {
+	if entry.unlock != nil {
+		entry.unlock()
+		entry.unlock = nil
+	}
+	<-entry.sem
+}
+
 // Get retrieves a value from the cache for key.
-func (entry *Entry) Get(ctx context.Context) ([]byte, error) {
+func (entry *Entry) Get(ctx context.Context) (value *Value, err error) {
+	ctx, span := tracing.Start(ctx, "cache.Get")
+	defer func() {
+		span.SetAttributes(attribute.Bool("cache.hit", err == nil))
+		span.End()
+	}()
+
 	v, err := entry.manager.cache.Get(ctx, entry.key)
 	if err != nil {
+		entry.manager.index.miss(entry.key)
+		return nil, err
+	}
+	b, err := toBytes(v)
+	if err != nil {
+		entry.manager.index.miss(entry.key)
+		return nil, err
+	}
+	value, err = decodeValue(b)
+	if err != nil {
+		entry.manager.index.miss(entry.key)
 		return nil, err
 	}
-	return v.([]byte), nil
+	entry.manager.index.hit()
+	return value, nil
 }
 
 // Set saves a new value in the cache for key.
-func (entry *Entry) Set(ctx context.Context, value []byte) error {
-	return entry.manager.cache.Set(ctx, entry.key, value, nil)
+// tags label the value for Manager.Invalidate, eg "year=2011".
+func (entry *Entry) Set(ctx context.Context, value *Value, tags ...string) (err error) {
+	ctx, span := tracing.Start(ctx, "cache.Set", attribute.Int("cache.size", len(value.Body)))
+	defer func() { tracing.End(span, err) }()
+
+	cm := entry.manager
+	encoded := value.encode()
+	for _, tier := range cm.tiers {
+		// shared and persistent stores keep their own record of tags, so tagged values
+		// can be invalidated by any process sharing them, or after a restart
+		var options *store.Options
+		if _, ok := tier.GetCodec().GetStore().(keyedStore); ok && len(tags) > 0 {
+			options = &store.Options{Tags: tags}
+		}
+		if err := tier.Set(ctx, entry.key, encoded, options); err != nil {
+			return fmt.Errorf("cannot set item in cache store %s: %w", tier.GetCodec().GetStore().GetType(), err)
+		}
+	}
+	cm.index.set(entry.key, len(value.Body), tags)
+	return nil
 }
@@ -1,6 +1,8 @@
 package cache
 
 import (
+	"context"
+	"net/http"
 	"testing"
 	"time"
 
@@ -67,3 +69,33 @@ func Test_AllocateFree(t *testing.T) {
 	assert.Equal(t, len(cm.entries), 0, "must be no entries allocated")
 	assert.Equal(t, len(cm.references), 0, "must be no references allocated")
 }
+
+func Test_LockGivesUp(t *testing.T) {
+	cm, err := New(5*time.Minute, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	holder := cm.AllocateEntry("key")
+	defer holder.Free()
+	assert.NoError(t, holder.Lock(context.Background()), "first lock is free")
+
+	// a waiter whose request is done stops waiting
+	waiter := cm.AllocateEntry("key")
+	defer waiter.Free()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, waiter.Lock(ctx), context.DeadlineExceeded, "waiter gives up at its deadline")
+
+	// and once the holder is done, the next waiter gets the lock
+	holder.Unlock()
+	assert.NoError(t, waiter.Lock(context.Background()), "lock is free again")
+	waiter.Unlock()
+}
+
+func Test_CacheKey(t *testing.T) {
+	req := &http.Request{RequestURI: "/query/2011?rows=E01000001"}
+
+	assert.Equal(t, "/query/2011?rows=E01000001", CacheKey(req), "no variants is just the URI")
+	assert.NotEqual(t, CacheKey(req, "text/csv"), CacheKey(req, "application/json"), "variants must not collide")
+}
@@ -0,0 +1,310 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/eko/gocache/v2/store"
+)
+
+// DiskType is the gocache store type of a DiskStore.
+const DiskType = "disk"
+
+const diskSuffix = ".cache"
+
+const (
+	diskSweepEvery = time.Hour // most time between sweeps of expired files
+	diskTempAge    = time.Hour // age after which a temporary file is taken to be abandoned
+)
+
+// A DiskStore is a gocache store keeping each value in its own file in a directory,
+// so the cache survives restarts.
+//
+// File names are a hash of the key, and a file's modification time is when it was set.
+// Expired files are ignored, and replaced when the key is next set; RunSweeper removes
+// those that are never set again.
+// Each file starts with a diskHeader line giving the key and tags, so values can be
+// invalidated by tag or key prefix after a restart, or by another process sharing
+// the directory.
+//
+type DiskStore struct {
+	dir string
+	ttl time.Duration
+}
+
+// A diskHeader is the first line of each file, as JSON.
+type diskHeader struct {
+	Key  string   `json:"key"`
+	Tags []string `json:"tags,omitempty"`
+}
+
+// NewDiskStore sets up a DiskStore in dir, creating dir if necessary.
+// Entries expire after ttl.
+func NewDiskStore(dir string, ttl time.Duration) (*DiskStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &DiskStore{dir: dir, ttl: ttl}, nil
+}
+
+// path returns the file name for key.
+func (s *DiskStore) path(key interface{}) string {
+	sum := sha256.Sum256([]byte(fmt.Sprint(key)))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+diskSuffix)
+}
+
+// Get returns the value stored for key.
+func (s *DiskStore) Get(ctx context.Context, key interface{}) (interface{}, error) {
+	v, _, err := s.GetWithTTL(ctx, key)
+	return v, err
+}
+
+// GetWithTTL returns the value stored for key and how long it has left.
+func (s *DiskStore) GetWithTTL(ctx context.Context, key interface{}) (interface{}, time.Duration, error) {
+	fn := s.path(key)
+	fi, err := os.Stat(fn)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, 0, fmt.Errorf("%w: %v", sentinel.ErrNotFound, key)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var ttl time.Duration
+	if s.ttl > 0 {
+		ttl = time.Until(fi.ModTime().Add(s.ttl))
+		if ttl <= 0 {
+			return nil, 0, fmt.Errorf("%w: %v expired", sentinel.ErrNotFound, key)
+		}
+	}
+
+	b, err := os.ReadFile(fn)
+	if err != nil {
+		return nil, 0, err
+	}
+	// files from before headers were added, or for a colliding key, are misses
+	i := bytes.IndexByte(b, '\n')
+	if i < 0 {
+		return nil, 0, fmt.Errorf("%w: %v has no header", sentinel.ErrNotFound, key)
+	}
+	var h diskHeader
+	if err := json.Unmarshal(b[:i], &h); err != nil || h.Key != fmt.Sprint(key) {
+		return nil, 0, fmt.Errorf("%w: %v has the wrong header", sentinel.ErrNotFound, key)
+	}
+	return b[i+1:], ttl, nil
+}
+
+// readHeader returns the header of the file fn.
+func readHeader(fn string) (*diskHeader, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	line, err := bufio.NewReader(f).ReadBytes('\n')
+	if err != nil {
+		return nil, err
+	}
+	var h diskHeader
+	if err := json.Unmarshal(line, &h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// Set stores value, which must be []byte or string, for key, labelled with the
+// options' tags.
+// The file is written under a temporary name and renamed into place, so readers never
+// see a partial value.
+// Expiration in options is ignored; all entries use the store's ttl.
+func (s *DiskStore) Set(ctx context.Context, key interface{}, value interface{}, options *store.Options) error {
+	b, err := toBytes(value)
+	if err != nil {
+		return err
+	}
+	h := diskHeader{Key: fmt.Sprint(key)}
+	if options != nil {
+		h.Tags = options.Tags
+	}
+	header, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(s.dir, "set-*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(append(header, '\n'), b...)); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), s.path(key))
+}
+
+// Delete removes the value for key.
+func (s *DiskStore) Delete(ctx context.Context, key interface{}) error {
+	err := os.Remove(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Invalidate removes the values labelled with any of the options' tags.
+func (s *DiskStore) Invalidate(ctx context.Context, options store.InvalidateOptions) error {
+	want := map[string]bool{}
+	for _, tag := range options.Tags {
+		want[tag] = true
+	}
+	return s.remove(func(h *diskHeader) bool {
+		for _, tag := range h.Tags {
+			if want[tag] {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// InvalidatePrefix removes the values whose keys start with prefix.
+func (s *DiskStore) InvalidatePrefix(ctx context.Context, prefix string) error {
+	return s.remove(func(h *diskHeader) bool {
+		return strings.HasPrefix(h.Key, prefix)
+	})
+}
+
+// remove removes the values whose headers match.
+// Every file has to be read, so this is slow on a large cache.
+func (s *DiskStore) remove(match func(*diskHeader) bool) error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), diskSuffix) {
+			continue
+		}
+		fn := filepath.Join(s.dir, entry.Name())
+		h, err := readHeader(fn)
+		if err != nil || !match(h) {
+			// unreadable files can't be matched, but are misses anyway, and will be swept
+			continue
+		}
+		if err := os.Remove(fn); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Clear removes every value from the store.
+// Files in the directory which don't belong to the store are left alone.
+func (s *DiskStore) Clear(ctx context.Context) error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), diskSuffix) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, entry.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sweep removes expired entries, and temporary files abandoned by interrupted Sets, and
+// returns how many files it removed.
+// Files in the directory which don't belong to the store are left alone.
+func (s *DiskStore) Sweep(ctx context.Context) (int, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	var n int
+	for _, entry := range entries {
+		var maxAge time.Duration
+		switch {
+		case strings.HasSuffix(entry.Name(), diskSuffix):
+			maxAge = s.ttl
+		case strings.HasPrefix(entry.Name(), "set-") && strings.HasSuffix(entry.Name(), ".tmp"):
+			maxAge = diskTempAge
+		default:
+			continue
+		}
+		if maxAge <= 0 {
+			continue
+		}
+
+		fi, err := entry.Info()
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return n, err
+		}
+		if now.Sub(fi.ModTime()) < maxAge {
+			continue
+		}
+		err = os.Remove(filepath.Join(s.dir, entry.Name()))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// RunSweeper calls Sweep every hour, or every ttl if that is shorter, until ctx is
+// done, so entries which are never asked for again don't fill the disk.
+func (s *DiskStore) RunSweeper(ctx context.Context) {
+	every := diskSweepEvery
+	if s.ttl > 0 && s.ttl < every {
+		every = s.ttl
+	}
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		n, err := s.Sweep(ctx)
+		if err != nil {
+			log.Error(ctx, "sweeping disk cache", err, log.Data{"dir": s.dir})
+		} else if n > 0 {
+			log.Info(ctx, "removed expired disk cache files", log.Data{"dir": s.dir, "removed": n})
+		}
+	}
+}
+
+// GetType returns DiskType.
+func (s *DiskStore) GetType() string {
+	return DiskType
+}
@@ -1,11 +1,18 @@
 package cache
 
-import "net/http"
+import (
+	"net/http"
+	"strings"
+)
 
-// cacheKey builds a cache key from an incoming HTTP request struct.
-// Right now it only looks at RequestURI, but it should probably
-// also look at certain headers to do with language, content-type,
-// encoding, etc.
-func CacheKey(req *http.Request) string {
-	return req.RequestURI
+// CacheKey builds a cache key from an incoming HTTP request struct.
+// It looks at RequestURI, plus any variants the handler has already negotiated
+// from request headers, such as the response content-type, language and
+// content coding.
+// Responses that differ by variant are cached separately.
+func CacheKey(req *http.Request, variants ...string) string {
+	if len(variants) == 0 {
+		return req.RequestURI
+	}
+	return req.RequestURI + " " + strings.Join(variants, " ")
 }
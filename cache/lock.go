@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// A Locker locks cache keys across processes.
+// Lock waits until key is free, and returns a function to free it again.
+type Locker interface {
+	Lock(ctx context.Context, key string) (unlock func(), err error)
+}
+
+// ErrLockTimeout is returned by RedisLocker.Lock when key stays locked for too long.
+var ErrLockTimeout = errors.New("timed out waiting for cache lock")
+
+// unlockScript deletes a lock only if we still hold it, so a lock which expired and
+// was taken by another process is not released by mistake.
+var unlockScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0
+`)
+
+// A RedisLocker is a Locker keeping locks on a Redis server.
+//
+// Locks expire after Expiry, in case the holder dies without unlocking, so Expiry
+// should be longer than the slowest response takes to generate.
+// Lock gives up after Wait, and the caller is expected to carry on without the lock:
+// the worst case is then a duplicate query, rather than a stuck request.
+//
+type RedisLocker struct {
+	client *redis.Client
+	Expiry time.Duration
+	Wait   time.Duration
+	Poll   time.Duration // how often to retry a held lock
+}
+
+// NewRedisLocker returns a RedisLocker using client.
+func NewRedisLocker(client *redis.Client) *RedisLocker {
+	return &RedisLocker{
+		client: client,
+		Expiry: 2 * time.Minute,
+		Wait:   time.Minute,
+		Poll:   50 * time.Millisecond,
+	}
+}
+
+// Lock takes the lock for key, waiting up to Wait for another holder to release it.
+func (l *RedisLocker) Lock(ctx context.Context, key string) (func(), error) {
+	lockKey := "lock " + key
+
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(b[:])
+
+	ctx, cancel := context.WithTimeout(ctx, l.Wait)
+	defer cancel()
+
+	for {
+		ok, err := l.client.SetNX(ctx, lockKey, token, l.Expiry).Result()
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			return nil, err
+		}
+		if ok {
+			unlock := func() {
+				unlockScript.Run(context.Background(), l.client, []string{lockKey}, token)
+			}
+			return unlock, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ErrLockTimeout
+		case <-time.After(l.Poll):
+		}
+	}
+}
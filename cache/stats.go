@@ -0,0 +1,251 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/dp-geodata-api/pkg/metrics"
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+	"github.com/allegro/bigcache/v3"
+	"github.com/eko/gocache/v2/store"
+	"github.com/go-redis/redis/v8"
+)
+
+// Stats describes the cache as seen by this process.
+//
+// Counters run from process start.
+// Keys, Entries and Bytes only cover values stored by this process; other processes
+// sharing a store have their own.
+//
+type Stats struct {
+	Hits          int64        `json:"hits"`
+	Misses        int64        `json:"misses"`
+	Sets          int64        `json:"sets"`
+	Evictions     int64        `json:"evictions"`     // values stored here which had expired or been pushed out when next requested
+	Invalidations int64        `json:"invalidations"` // values removed by Invalidate
+	Entries       int          `json:"entries"`
+	Bytes         int64        `json:"bytes"`
+	Stores        []StoreStats `json:"stores"`
+	Keys          []KeyStats   `json:"keys"`
+}
+
+// StoreStats counts lookups in a single store of the chain.
+type StoreStats struct {
+	Type   string `json:"type"`
+	Hits   int    `json:"hits"`
+	Misses int    `json:"misses"`
+}
+
+// KeyStats describes a single cached value.
+type KeyStats struct {
+	Key    string    `json:"key"`
+	Size   int       `json:"size"`
+	Tags   []string  `json:"tags,omitempty"`
+	Stored time.Time `json:"stored"`
+}
+
+// index records what this process has stored, so it can count evictions, report sizes
+// and find keys by tag or prefix.
+// Entries are forgotten after ttl, when the stores will have expired them too.
+type index struct {
+	sync.Mutex
+	ttl           time.Duration
+	keys          map[string]KeyStats
+	hits          int64
+	misses        int64
+	sets          int64
+	evictions     int64
+	invalidations int64
+}
+
+func newIndex(ttl time.Duration) *index {
+	return &index{
+		ttl:  ttl,
+		keys: map[string]KeyStats{},
+	}
+}
+
+func (x *index) hit() {
+	metrics.CacheHit()
+	x.Lock()
+	defer x.Unlock()
+	x.hits++
+}
+
+// miss counts a lookup for key which found nothing.
+// If we stored key and it has not yet reached its ttl, it must have been evicted.
+func (x *index) miss(key string) {
+	metrics.CacheMiss()
+	x.Lock()
+	defer x.Unlock()
+	x.misses++
+	if ks, ok := x.keys[key]; ok {
+		if x.ttl == 0 || time.Since(ks.Stored) < x.ttl {
+			x.evictions++
+		}
+		delete(x.keys, key)
+	}
+}
+
+func (x *index) set(key string, size int, tags []string) {
+	x.Lock()
+	defer x.Unlock()
+	x.sets++
+	x.keys[key] = KeyStats{
+		Key:    key,
+		Size:   size,
+		Tags:   tags,
+		Stored: time.Now(),
+	}
+}
+
+func (x *index) clear() {
+	x.Lock()
+	defer x.Unlock()
+	x.keys = map[string]KeyStats{}
+}
+
+// expire forgets keys older than ttl.
+// Must be called with x locked.
+func (x *index) expire() {
+	if x.ttl == 0 {
+		return
+	}
+	for key, ks := range x.keys {
+		if time.Since(ks.Stored) >= x.ttl {
+			delete(x.keys, key)
+		}
+	}
+}
+
+// match removes and returns the keys with any of tags, or starting with prefix.
+func (x *index) match(tags []string, prefix string) []string {
+	x.Lock()
+	defer x.Unlock()
+	x.expire()
+
+	want := map[string]bool{}
+	for _, tag := range tags {
+		want[tag] = true
+	}
+
+	var keys []string
+	for key, ks := range x.keys {
+		matched := prefix != "" && strings.HasPrefix(key, prefix)
+		for _, tag := range ks.Tags {
+			matched = matched || want[tag]
+		}
+		if matched {
+			keys = append(keys, key)
+			delete(x.keys, key)
+		}
+	}
+	x.invalidations += int64(len(keys))
+	sort.Strings(keys)
+	return keys
+}
+
+// Stats returns the cache counters, and the size and tags of each value stored by this
+// process, ordered by key.
+func (cm *Manager) Stats() Stats {
+	x := cm.index
+	x.Lock()
+	defer x.Unlock()
+	x.expire()
+
+	stats := Stats{
+		Hits:          x.hits,
+		Misses:        x.misses,
+		Sets:          x.sets,
+		Evictions:     x.evictions,
+		Invalidations: x.invalidations,
+		Entries:       len(x.keys),
+		Stores:        []StoreStats{},
+		Keys:          []KeyStats{},
+	}
+	for _, ks := range x.keys {
+		stats.Bytes += int64(ks.Size)
+		stats.Keys = append(stats.Keys, ks)
+	}
+	sort.Slice(stats.Keys, func(i, j int) bool {
+		return stats.Keys[i].Key < stats.Keys[j].Key
+	})
+
+	for _, tier := range cm.tiers {
+		codec := tier.GetCodec()
+		stats.Stores = append(stats.Stores, StoreStats{
+			Type:   codec.GetStore().GetType(),
+			Hits:   codec.GetStats().Hits,
+			Misses: codec.GetStats().Miss,
+		})
+	}
+	return stats
+}
+
+// A keyedStore keeps its own record of the key and tags of each value, so values can
+// be invalidated whichever process stored them, and after a restart.
+// Invalidate takes tags, and InvalidatePrefix a key prefix.
+type keyedStore interface {
+	store.StoreInterface
+	InvalidatePrefix(ctx context.Context, prefix string) error
+}
+
+// Invalidate removes values labelled with any of tags, or whose keys start with prefix,
+// from every store, and returns the keys removed by this process.
+//
+// Keyed stores, Redis and disk, are searched for matching values too, so values stored
+// by other processes or before a restart are also removed from them; those keys are
+// not returned.
+// The memory store only holds values stored by this process, so other processes
+// may go on serving their own copies from memory until they expire.
+//
+// Errors do not stop Invalidate, so as much as possible is removed; the first is
+// returned.
+//
+func (cm *Manager) Invalidate(ctx context.Context, tags []string, prefix string) ([]string, error) {
+	var first error
+	fail := func(err error) {
+		if first == nil {
+			first = err
+		}
+	}
+
+	keys := cm.index.match(tags, prefix)
+	for _, tier := range cm.tiers {
+		s := tier.GetCodec().GetStore()
+		for _, key := range keys {
+			if err := s.Delete(ctx, key); err != nil && !isNotFound(err) {
+				fail(fmt.Errorf("cache store %s: %w", s.GetType(), err))
+			}
+		}
+
+		ks, ok := s.(keyedStore)
+		if !ok {
+			continue
+		}
+		if len(tags) > 0 {
+			if err := ks.Invalidate(ctx, store.InvalidateOptions{Tags: tags}); err != nil {
+				fail(fmt.Errorf("cache store %s: %w", s.GetType(), err))
+			}
+		}
+		if prefix != "" {
+			if err := ks.InvalidatePrefix(ctx, prefix); err != nil {
+				fail(fmt.Errorf("cache store %s: %w", s.GetType(), err))
+			}
+		}
+	}
+	return keys, first
+}
+
+// isNotFound is true if err is a store saying it has no value for a key, which is
+// what Invalidate wants anyway.
+func isNotFound(err error) bool {
+	return errors.Is(err, bigcache.ErrEntryNotFound) ||
+		errors.Is(err, sentinel.ErrNotFound) ||
+		errors.Is(err, redis.Nil)
+}
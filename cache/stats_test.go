@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func set(t *testing.T, cm *Manager, key, value string, tags ...string) {
+	t.Helper()
+	entry := cm.AllocateEntry(key)
+	defer entry.Free()
+	if err := entry.Set(context.Background(), NewValue([]byte(value), time.Time{}), tags...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func get(cm *Manager, key string) error {
+	entry := cm.AllocateEntry(key)
+	defer entry.Free()
+	_, err := entry.Get(context.Background())
+	return err
+}
+
+func Test_Stats(t *testing.T) {
+	cm, err := New(time.Hour, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set(t, cm, "/b", "bb", "year=2011")
+	set(t, cm, "/a", "a")
+	assert.NoError(t, get(cm, "/a"))
+	assert.Error(t, get(cm, "/missing"))
+
+	stats := cm.Stats()
+	assert.EqualValues(t, 1, stats.Hits)
+	assert.EqualValues(t, 1, stats.Misses)
+	assert.EqualValues(t, 2, stats.Sets)
+	assert.EqualValues(t, 0, stats.Evictions)
+	assert.Equal(t, 2, stats.Entries)
+	assert.EqualValues(t, 3, stats.Bytes)
+	if assert.Len(t, stats.Keys, 2) {
+		assert.Equal(t, "/a", stats.Keys[0].Key, "keys are sorted")
+		assert.Equal(t, []string{"year=2011"}, stats.Keys[1].Tags)
+	}
+	if assert.Len(t, stats.Stores, 1) {
+		assert.Equal(t, "bigcache", stats.Stores[0].Type)
+	}
+
+	// a value missing before its ttl has been evicted
+	if err := cm.cache.Delete(context.Background(), "/a"); err != nil {
+		t.Fatal(err)
+	}
+	assert.Error(t, get(cm, "/a"))
+	stats = cm.Stats()
+	assert.EqualValues(t, 1, stats.Evictions)
+	assert.Equal(t, 1, stats.Entries)
+}
+
+func Test_Invalidate(t *testing.T) {
+	ctx := context.Background()
+	cm, err := New(time.Hour, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set(t, cm, "/query/2011?cols=QS101EW0001", "1", "year=2011", "table=QS101EW")
+	set(t, cm, "/query/2011?cols=QS501EW0001", "2", "year=2011", "table=QS501EW")
+	set(t, cm, "/query/2021?cols=QS501EW0001", "3", "year=2021", "table=QS501EW")
+	set(t, cm, "/metadata/2021", "4", "year=2021")
+
+	keys, err := cm.Invalidate(ctx, []string{"table=QS501EW"}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/query/2011?cols=QS501EW0001", "/query/2021?cols=QS501EW0001"}, keys)
+	assert.Error(t, get(cm, "/query/2021?cols=QS501EW0001"))
+	assert.NoError(t, get(cm, "/query/2011?cols=QS101EW0001"))
+
+	keys, err = cm.Invalidate(ctx, nil, "/metadata/")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/metadata/2021"}, keys)
+	assert.Error(t, get(cm, "/metadata/2021"))
+
+	stats := cm.Stats()
+	assert.EqualValues(t, 3, stats.Invalidations)
+	assert.EqualValues(t, 0, stats.Evictions, "invalidated values are not evictions")
+	assert.Equal(t, 1, stats.Entries)
+}
+
+func Test_Invalidate_Redis(t *testing.T) {
+	ctx := context.Background()
+	_, client := newRedis(t)
+
+	// two replicas sharing redis
+	cm1 := NewChain(time.Hour, nil, NewRedisStore(client, time.Hour))
+	cm2 := NewChain(time.Hour, nil, NewRedisStore(client, time.Hour))
+
+	set(t, cm1, "/query/2011", "1", "year=2011")
+
+	_, err := cm2.Invalidate(ctx, []string{"year=2011"}, "")
+	assert.NoError(t, err)
+	assert.Error(t, get(cm1, "/query/2011"), "tags are shared through redis")
+}
+
+func Test_Invalidate_Evicted(t *testing.T) {
+	ctx := context.Background()
+	cm, err := New(time.Hour, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set(t, cm, "/query/2011?cols=QS101EW0001", "1", "year=2011")
+	set(t, cm, "/query/2011?cols=QS501EW0001", "2", "year=2011")
+
+	// the first value has been pushed out of memory
+	if err := cm.cache.Delete(ctx, "/query/2011?cols=QS101EW0001"); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := cm.Invalidate(ctx, []string{"year=2011"}, "")
+	assert.NoError(t, err, "missing values are not an error")
+	assert.Len(t, keys, 2)
+	assert.Error(t, get(cm, "/query/2011?cols=QS501EW0001"), "values after a missing one are still removed")
+}
+
+func Test_Invalidate_Keyed(t *testing.T) {
+	ctx := context.Background()
+	_, client := newRedis(t)
+	dir := t.TempDir()
+
+	newManager := func() *Manager {
+		disk, err := NewDiskStore(dir, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return NewChain(time.Hour, nil, disk, NewRedisStore(client, time.Hour))
+	}
+
+	cm1 := newManager()
+	set(t, cm1, "/query/2011?cols=QS101EW0001", "1", "year=2011")
+	set(t, cm1, "/query/2011?cols=*", "2", "year=2011")
+	set(t, cm1, "/metadata/2011", "3", "year=2011")
+	set(t, cm1, "/metadata/2021", "4", "year=2021")
+
+	// another replica, or this one after a restart, has none of these in its index
+	cm2 := newManager()
+
+	keys, err := cm2.Invalidate(ctx, nil, "/query/2011?cols=*")
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+	assert.Error(t, get(cm1, "/query/2011?cols=*"), "prefix is removed from every keyed store")
+	assert.NoError(t, get(cm1, "/query/2011?cols=QS101EW0001"), "prefix is not a pattern")
+
+	_, err = cm2.Invalidate(ctx, []string{"year=2011"}, "")
+	assert.NoError(t, err)
+	assert.Error(t, get(cm1, "/query/2011?cols=QS101EW0001"), "tags are removed from every keyed store")
+	assert.Error(t, get(cm1, "/metadata/2011"))
+	assert.NoError(t, get(cm1, "/metadata/2021"))
+}
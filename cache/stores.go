@@ -0,0 +1,188 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/eko/gocache/v2/store"
+	"github.com/go-redis/redis/v8"
+)
+
+// NewMemoryStore returns a store holding up to megabytes of content in this process.
+// Entries expire after ttl.
+func NewMemoryStore(ttl time.Duration, megabytes int) (store.StoreInterface, error) {
+	// configure bigcache
+	config := bigcache.DefaultConfig(ttl)
+	config.HardMaxCacheSize = megabytes
+
+	// create bigcache client
+	bigcacheClient, err := bigcache.NewBigCache(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// use bigcache client as a gocache store
+	return store.NewBigcache(bigcacheClient, nil), nil
+}
+
+// NewRedisStore returns a store on the Redis server client is connected to.
+// Entries expire after ttl.
+//
+// Entries and their tags are kept under the redisNamespace prefix, and clearing the
+// store only deletes keys with that prefix, so the server can be shared with the
+// RedisLocker, or with anything else.
+//
+func NewRedisStore(client *redis.Client, ttl time.Duration) store.StoreInterface {
+	return redisStore{
+		client: client,
+		ttl:    ttl,
+	}
+}
+
+// redisNamespace prefixes every key a redisStore uses.
+const redisNamespace = "geodata:"
+
+// redisScanCount is how many keys to ask for in each SCAN while clearing the store.
+const redisScanCount = 1000
+
+// redisStore is like the gocache Redis store, but keeps its keys in redisNamespace.
+// Values always come back as the []byte they were stored as, rather than as
+// strings, since other stores in a chain, such as bigcache, only accept []byte.
+type redisStore struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// entryKey and tagKey return the Redis keys holding a cached value, and the set of
+// keys labelled with a tag.
+func entryKey(key interface{}) string {
+	return redisNamespace + "entry:" + fmt.Sprint(key)
+}
+
+func tagKey(tag string) string {
+	return redisNamespace + "tag:" + tag
+}
+
+func (s redisStore) Get(ctx context.Context, key interface{}) (interface{}, error) {
+	v, err := s.client.Get(ctx, entryKey(key)).Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (s redisStore) GetWithTTL(ctx context.Context, key interface{}) (interface{}, time.Duration, error) {
+	v, err := s.client.Get(ctx, entryKey(key)).Bytes()
+	if err != nil {
+		return nil, 0, err
+	}
+	ttl, err := s.client.TTL(ctx, entryKey(key)).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+	return v, ttl, nil
+}
+
+// Set stores value under key, expiring after the store's ttl unless options give
+// another expiration.
+// Each of the options' tags records key, until the last value with that tag expires.
+func (s redisStore) Set(ctx context.Context, key interface{}, value interface{}, options *store.Options) error {
+	ttl := s.ttl
+	var tags []string
+	if options != nil {
+		if options.Expiration != 0 {
+			ttl = options.Expiration
+		}
+		tags = options.Tags
+	}
+
+	b, err := toBytes(value)
+	if err != nil {
+		return err
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.Set(ctx, entryKey(key), b, ttl)
+	for _, tag := range tags {
+		pipe.SAdd(ctx, tagKey(tag), fmt.Sprint(key))
+		pipe.Expire(ctx, tagKey(tag), ttl)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (s redisStore) Delete(ctx context.Context, key interface{}) error {
+	return s.client.Del(ctx, entryKey(key)).Err()
+}
+
+// Invalidate deletes the values with any of the options' tags, whichever process
+// stored them.
+func (s redisStore) Invalidate(ctx context.Context, options store.InvalidateOptions) error {
+	for _, tag := range options.Tags {
+		keys, err := s.client.SMembers(ctx, tagKey(tag)).Result()
+		if err != nil {
+			return err
+		}
+		del := []string{tagKey(tag)}
+		for _, key := range keys {
+			del = append(del, entryKey(key))
+		}
+		if err := s.client.Del(ctx, del...).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InvalidatePrefix deletes the values whose keys start with prefix, whichever process
+// stored them.
+func (s redisStore) InvalidatePrefix(ctx context.Context, prefix string) error {
+	return s.deleteMatching(ctx, globEscaper.Replace(entryKey(prefix))+"*")
+}
+
+// Clear deletes every key in redisNamespace, leaving anything else on the server,
+// such as RedisLocker locks, alone.
+func (s redisStore) Clear(ctx context.Context) error {
+	return s.deleteMatching(ctx, redisNamespace+"*")
+}
+
+// globEscaper escapes the characters which are special in Redis key patterns.
+var globEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+
+// deleteMatching deletes the keys matching the Redis key pattern.
+func (s redisStore) deleteMatching(ctx context.Context, pattern string) error {
+	var cursor uint64
+	for {
+		keys, next, err := s.client.Scan(ctx, cursor, pattern, redisScanCount).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if err := s.client.Del(ctx, keys...).Err(); err != nil {
+				return err
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+func (s redisStore) GetType() string {
+	return store.RedisType
+}
+
+// toBytes converts a cached value back to []byte.
+func toBytes(v interface{}) ([]byte, error) {
+	switch b := v.(type) {
+	case []byte:
+		return b, nil
+	case string:
+		return []byte(b), nil
+	}
+	return nil, fmt.Errorf("unexpected cached value type %T", v)
+}
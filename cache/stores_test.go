@@ -0,0 +1,250 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/eko/gocache/v2/store"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return mr, client
+}
+
+func Test_DiskStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	s, err := NewDiskStore(dir, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.Get(ctx, "key")
+	assert.Error(t, err, "missing key is an error")
+
+	assert.NoError(t, s.Set(ctx, "key", []byte("value"), nil))
+	v, ttl, err := s.GetWithTTL(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value"), v)
+	assert.True(t, ttl > 59*time.Minute && ttl <= time.Hour, "ttl counts down from store ttl")
+
+	// a second store on the same directory sees the same entries, as after a restart
+	s2, err := NewDiskStore(dir, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err = s2.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value"), v)
+
+	// entries older than ttl are ignored
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(s.path("key"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Get(ctx, "key")
+	assert.Error(t, err, "expired key is an error")
+
+	// Clear leaves other files alone
+	other := filepath.Join(dir, "other")
+	if err := os.WriteFile(other, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, s.Set(ctx, "key", "string value", nil))
+	assert.NoError(t, s.Clear(ctx))
+	_, err = s.Get(ctx, "key")
+	assert.Error(t, err, "cleared key is an error")
+	_, err = os.Stat(other)
+	assert.NoError(t, err, "other files survive Clear")
+}
+
+func Test_DiskStoreHeader(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewDiskStore(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a file from before headers is a miss
+	if err := os.WriteFile(s.path("old"), []byte("value"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Get(ctx, "old")
+	assert.Error(t, err, "file without a header is a miss")
+
+	assert.NoError(t, s.Set(ctx, "/a", []byte("a"), &store.Options{Tags: []string{"year=2011"}}))
+	assert.NoError(t, s.Set(ctx, "/b", []byte("b\nb"), nil))
+	v, err := s.Get(ctx, "/b")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("b\nb"), v, "value is returned without the header")
+
+	assert.NoError(t, s.Invalidate(ctx, store.InvalidateOptions{Tags: []string{"year=2011"}}))
+	_, err = s.Get(ctx, "/a")
+	assert.Error(t, err, "tagged value is invalidated")
+	assert.NoError(t, s.InvalidatePrefix(ctx, "/b"))
+	_, err = s.Get(ctx, "/b")
+	assert.Error(t, err, "prefixed value is invalidated")
+}
+
+func Test_DiskStoreSweep(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	s, err := NewDiskStore(dir, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	for _, key := range []string{"fresh", "expired"} {
+		assert.NoError(t, s.Set(ctx, key, "value", nil))
+	}
+	abandoned := filepath.Join(dir, "set-123.tmp")
+	other := filepath.Join(dir, "other")
+	for _, fn := range []string{abandoned, other} {
+		if err := os.WriteFile(fn, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, fn := range []string{s.path("expired"), abandoned, other} {
+		if err := os.Chtimes(fn, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := s.Sweep(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n, "expired entry and abandoned temporary file removed")
+
+	_, err = s.Get(ctx, "fresh")
+	assert.NoError(t, err, "fresh entry survives Sweep")
+	for fn, want := range map[string]bool{s.path("expired"): false, abandoned: false, other: true} {
+		_, err := os.Stat(fn)
+		assert.Equal(t, want, err == nil, "%s exists", filepath.Base(fn))
+	}
+}
+
+func Test_RedisStoreReturnsBytes(t *testing.T) {
+	ctx := context.Background()
+	mr, client := newRedis(t)
+
+	s := NewRedisStore(client, time.Hour)
+	assert.NoError(t, s.Set(ctx, "key", []byte("value"), nil))
+
+	v, err := s.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value"), v)
+	assert.Equal(t, time.Hour, mr.TTL(entryKey("key")), "entries expire after store ttl")
+}
+
+func Test_RedisStoreClear(t *testing.T) {
+	ctx := context.Background()
+	mr, client := newRedis(t)
+
+	s := NewRedisStore(client, time.Hour)
+	assert.NoError(t, s.Set(ctx, "key", []byte("value"), &store.Options{Tags: []string{"year=2011"}}))
+	mr.Set("lock key", "token")
+	mr.Set("other", "value")
+
+	assert.NoError(t, s.Clear(ctx))
+	_, err := s.Get(ctx, "key")
+	assert.Error(t, err, "entry is cleared")
+	assert.False(t, mr.Exists(tagKey("year=2011")), "tag is cleared")
+	assert.True(t, mr.Exists("lock key"), "locks are left alone")
+	assert.True(t, mr.Exists("other"), "other keys are left alone")
+}
+
+func Test_ChainFallsThrough(t *testing.T) {
+	ctx := context.Background()
+	_, client := newRedis(t)
+
+	memory, err := NewMemoryStore(time.Hour, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared := NewRedisStore(client, time.Hour)
+
+	// another replica has already cached the value in redis
+	if err := shared.Set(ctx, "key", NewValue([]byte("value"), time.Time{}).encode(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	cm := NewChain(time.Hour, nil, memory, shared)
+	entry := cm.AllocateEntry("key")
+	defer entry.Free()
+
+	v, err := entry.Get(ctx)
+	assert.NoError(t, err, "L1 miss falls through to L2")
+	assert.Equal(t, []byte("value"), v.Body)
+
+	// the L2 hit is copied back to L1 in the background
+	assert.Eventually(t, func() bool {
+		_, err := memory.Get(ctx, "key")
+		return err == nil
+	}, time.Second, 10*time.Millisecond, "L2 hit is copied to L1")
+
+	// Set writes through to every store
+	entry2 := cm.AllocateEntry("key2")
+	defer entry2.Free()
+	assert.NoError(t, entry2.Set(ctx, NewValue([]byte("value2"), time.Time{})))
+	v2, err := shared.Get(ctx, "key2")
+	assert.NoError(t, err)
+	assert.Equal(t, NewValue([]byte("value2"), time.Time{}).encode(), v2)
+}
+
+func Test_RedisLocker(t *testing.T) {
+	ctx := context.Background()
+	mr, client := newRedis(t)
+
+	l := NewRedisLocker(client)
+	l.Wait = 100 * time.Millisecond
+	l.Poll = 10 * time.Millisecond
+
+	unlock, err := l.Lock(ctx, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, mr.Exists("lock key"), "lock is held in redis")
+
+	_, err = l.Lock(ctx, "key")
+	assert.ErrorIs(t, err, ErrLockTimeout, "second lock waits and gives up")
+
+	unlock()
+	assert.False(t, mr.Exists("lock key"), "unlock releases the lock")
+
+	unlock2, err := l.Lock(ctx, "key")
+	assert.NoError(t, err, "lock can be taken again after unlock")
+
+	// an expired lock taken over by someone else is not released by its old holder
+	mr.Set("lock key", "someone else")
+	unlock2()
+	assert.True(t, mr.Exists("lock key"), "only the holder's own lock is released")
+}
+
+func Test_EntryLockUsesLocker(t *testing.T) {
+	mr, client := newRedis(t)
+
+	memory, err := NewMemoryStore(time.Hour, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cm := NewChain(time.Hour, NewRedisLocker(client), memory, NewRedisStore(client, time.Hour))
+
+	entry := cm.AllocateEntry("key")
+	defer entry.Free()
+
+	assert.NoError(t, entry.Lock(context.Background()))
+	assert.True(t, mr.Exists("lock key"), "Entry lock is shared through redis")
+	entry.Unlock()
+	assert.False(t, mr.Exists("lock key"), "Entry unlock releases the shared lock")
+}
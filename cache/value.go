@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// A Value is a cached response body, along with the validators sent with it.
+type Value struct {
+	Body         []byte
+	ETag         string    // strong entity tag, including quotes
+	LastModified time.Time // zero if unknown
+}
+
+// NewValue returns a Value holding body, with an ETag computed from body.
+func NewValue(body []byte, modified time.Time) *Value {
+	return &Value{
+		Body:         body,
+		ETag:         ETag(body),
+		LastModified: modified,
+	}
+}
+
+// ETag returns a strong entity tag for body.
+func ETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// valueMagic starts every encoded Value, so values written in an older format, or by
+// something else sharing the store, are treated as misses.
+var valueMagic = []byte("geodata-value-1\n")
+
+var errBadValue = errors.New("cached value has unknown format")
+
+// encode serialises v for the stores as
+//
+//	magic
+//	etag\n
+//	last modified unix nanoseconds, or empty\n
+//	body
+//
+func (v *Value) encode() []byte {
+	var buf bytes.Buffer
+	buf.Grow(len(valueMagic) + len(v.ETag) + 22 + len(v.Body))
+	buf.Write(valueMagic)
+	buf.WriteString(v.ETag)
+	buf.WriteByte('\n')
+	if !v.LastModified.IsZero() {
+		buf.WriteString(strconv.FormatInt(v.LastModified.UnixNano(), 10))
+	}
+	buf.WriteByte('\n')
+	buf.Write(v.Body)
+	return buf.Bytes()
+}
+
+// decodeValue is the reverse of encode.
+func decodeValue(b []byte) (*Value, error) {
+	if !bytes.HasPrefix(b, valueMagic) {
+		return nil, errBadValue
+	}
+	b = b[len(valueMagic):]
+
+	fields := make([][]byte, 2)
+	for i := range fields {
+		n := bytes.IndexByte(b, '\n')
+		if n < 0 {
+			return nil, errBadValue
+		}
+		fields[i], b = b[:n], b[n+1:]
+	}
+
+	v := &Value{
+		Body: b,
+		ETag: string(fields[0]),
+	}
+	if len(fields[1]) > 0 {
+		nanos, err := strconv.ParseInt(string(fields[1]), 10, 64)
+		if err != nil {
+			return nil, errBadValue
+		}
+		v.LastModified = time.Unix(0, nanos).UTC()
+	}
+	return v, nil
+}
@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Value(t *testing.T) {
+	modified := time.Date(2022, 3, 4, 5, 6, 7, 8, time.UTC)
+
+	var tests = map[string]*Value{
+		"with modified":    NewValue([]byte("a,b\n1,2\n"), modified),
+		"no modified":      NewValue([]byte("a,b\n1,2\n"), time.Time{}),
+		"empty body":       NewValue(nil, modified),
+		"newlines in body": NewValue([]byte("\n\n\n"), modified),
+	}
+
+	for desc, v := range tests {
+		got, err := decodeValue(v.encode())
+		if !assert.NoError(t, err, desc) {
+			continue
+		}
+		assert.Equal(t, v.ETag, got.ETag, desc)
+		assert.Equal(t, string(v.Body), string(got.Body), desc)
+		assert.True(t, v.LastModified.Equal(got.LastModified), desc)
+	}
+
+	_, err := decodeValue([]byte("a,b\n1,2\n"))
+	assert.ErrorIs(t, err, errBadValue, "unencoded values are rejected")
+}
+
+func Test_ETag(t *testing.T) {
+	a := ETag([]byte("a"))
+	assert.Equal(t, a, ETag([]byte("a")))
+	assert.NotEqual(t, a, ETag([]byte("b")))
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, a)
+}
@@ -10,8 +10,10 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/ONSdigital/dp-geodata-api/pkg/tracing"
 	"github.com/ONSdigital/dp-healthcheck/healthcheck"
 	"github.com/shurcooL/graphql"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const URL = "https://ftb-api-ext.ons.sensiblecode.io/graphql"
@@ -28,8 +30,10 @@ type AuthTripper struct {
 	Pass string
 }
 
+// RoundTrip adds basic auth and the W3C trace context to req, and sends it.
 func (at AuthTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 	req.SetBasicAuth(at.User, at.Pass)
+	tracing.Inject(req.Context(), req.Header)
 	return http.DefaultTransport.RoundTrip(req)
 }
 
@@ -282,7 +286,12 @@ func (cant *Client) QueryMetaData(ctx context.Context, ds string, nomis bool) (s
 }
 
 func (cant *Client) SendQueryVars(ctx context.Context, query interface{}, vars map[string]interface{}) error {
-	return cant.client.Query(ctx, query, vars)
+	ctx, span := tracing.Start(ctx, "cantabular.SendQueryVars",
+		attribute.String("cantabular.query", fmt.Sprintf("%T", query)),
+	)
+	err := cant.client.Query(ctx, query, vars)
+	tracing.End(span, err)
+	return err
 }
 
 // ParseResp is used for the command line investigate API commands
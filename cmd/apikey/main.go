@@ -0,0 +1,79 @@
+// apikey generates a new API key.
+//
+// It prints the key, to hand to the client, and its entry for API_KEYS_FILE.
+// With -db it inserts the key into the api_key table instead, using the PG* environment
+// variables.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dp-geodata-api/model"
+	"github.com/ONSdigital/dp-geodata-api/pkg/apikey"
+	"github.com/ONSdigital/dp-geodata-api/pkg/database"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func main() {
+	name := flag.String("name", "", "name of the client the key is for (required)")
+	scopes := flag.String("scopes", "", "comma separated scopes, eg query,ckmeans or admin (required)")
+	expires := flag.String("expires", "", "expiry date, YYYY-MM-DD (default never)")
+	useDB := flag.Bool("db", false, "insert the key into the api_key table")
+	flag.Parse()
+
+	if *name == "" || *scopes == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	secret, err := apikey.Generate()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	key := apikey.Key{
+		Name:    *name,
+		Hash:    apikey.Hash(secret),
+		Scopes:  apikey.ParseScopes(*scopes),
+		Enabled: true,
+	}
+	if *expires != "" {
+		t, err := time.Parse("2006-01-02", *expires)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		key.Expires = &t
+	}
+
+	if *useDB {
+		gdb, err := gorm.Open(postgres.Open(database.GetDSN()), &gorm.Config{})
+		if err != nil {
+			log.Fatalln(err)
+		}
+		row := model.APIKey{
+			Name:      key.Name,
+			KeyHash:   key.Hash,
+			Scopes:    strings.Join(key.Scopes, ","),
+			Enabled:   key.Enabled,
+			ExpiresAt: key.Expires,
+		}
+		if err := gdb.Create(&row).Error; err != nil {
+			log.Fatalln(err)
+		}
+		fmt.Printf("key: %s\n", secret)
+		return
+	}
+
+	entry, err := json.MarshalIndent(key, "", "  ")
+	if err != nil {
+		log.Fatalln(err)
+	}
+	fmt.Printf("key: %s\n", secret)
+	fmt.Printf("API_KEYS_FILE entry:\n%s\n", entry)
+}
@@ -16,6 +16,8 @@ import (
 
 	"github.com/ONSdigital/dp-geodata-api/pkg/database"
 	"github.com/ONSdigital/dp-geodata-api/pkg/geodata"
+	"github.com/ONSdigital/dp-geodata-api/pkg/lang"
+	"github.com/ONSdigital/dp-geodata-api/pkg/table"
 	dplog "github.com/ONSdigital/log.go/v2/log"
 	_ "github.com/jackc/pgx/v4/stdlib"
 )
@@ -211,12 +213,14 @@ func (g *generator) gentiles(ctx context.Context) error {
 						[]string{"geography_code", cat},
 						"",
 						prefix+totalsuffix,
+						lang.English,
+						table.FormatCSV,
 					)
 					if err != nil {
 						log.Fatal(err)
 					}
 
-					if err = os.WriteFile(fn+".tmp", []byte(body), 0644); err != nil {
+					if err = os.WriteFile(fn+".tmp", body, 0644); err != nil {
 						log.Fatal(err)
 					}
 					if err = os.Rename(fn+".tmp", fn); err != nil {
@@ -264,7 +268,7 @@ func (g *generator) genckmeans(ctx context.Context) error {
 					g.printstatus(fn, int(n))
 				}
 
-				breaks, err := g.app.CKmeans(ctx, 2011, []string{cat}, []string{geotype}, 5, prefix+totalsuffix)
+				breaks, err := g.app.CKmeans(ctx, 2011, []string{cat}, []string{geotype}, 5, prefix+totalsuffix, "", false, geodata.AreaArgs{})
 				if err != nil {
 					log.Fatal(err)
 				}
@@ -311,7 +315,7 @@ func (g *generator) gengeos(ctx context.Context) error {
 				g.printstatus(fn, int(n))
 			}
 
-			resp, err := g.app.Geo(ctx, 2011, geocode, "")
+			resp, err := g.app.Geo(ctx, 2011, geocode, "", lang.English)
 			if err != nil {
 				log.Fatal(err)
 			}
@@ -8,11 +8,14 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 
 	"github.com/ONSdigital/dp-geodata-api/cantabular"
 	"github.com/ONSdigital/dp-geodata-api/metadata"
 	"github.com/ONSdigital/dp-geodata-api/pkg/database"
 	geodata "github.com/ONSdigital/dp-geodata-api/pkg/geodata"
+	"github.com/ONSdigital/dp-geodata-api/pkg/lang"
+	"github.com/ONSdigital/dp-geodata-api/pkg/table"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -20,7 +23,7 @@ import (
 func main() {
 	maxmetrics := flag.Int("maxmetrics", 0, "max number of rows to accept from db query (default 0 means no limit)")
 	flag.Usage = func() {
-		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [command-options] query|ckmeans|ckmeansratio|metadata [subcommand-options]\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [command-options] query|ckmeans|ckmeansratio|stats|metadata [subcommand-options]\n", filepath.Base(os.Args[0]))
 		flag.PrintDefaults()
 	}
 	flag.Parse()
@@ -55,6 +58,8 @@ func main() {
 		query(ctx, app, flag.Args()[1:])
 	case "ckmeans":
 		ckmeans(ctx, app, flag.Args()[1:])
+	case "stats":
+		stats(ctx, app, flag.Args()[1:])
 	case "metadata":
 		mdquery(ctx, md, flag.Args()[1:])
 	default:
@@ -75,12 +80,19 @@ func query(ctx context.Context, app *geodata.Geodata, argv []string) {
 	polygon := flagset.String("polygon", "", "polygon x1,y1,...,x1,y1 (closed linestring)")
 	censustable := flagset.String("censustable", "", "censustable QS802EW 'nomis table' / grouping of census data categories")
 	divideby := flagset.String("divideby", "", "category to divide by")
+	format := flagset.String("format", "csv", "output format (csv, json, geojson or parquet)")
+	language := flagset.String("lang", lang.English, "language of geography_name (en or cy)")
 	flagset.Var(&geotypes, "geotype", "geography types (LSOA, LAD, etc)")
 	flagset.Var(&rows, "rows", "row or row range")
 	flagset.Var(&cols, "cols", "column name(s) to return")
 	flagset.Parse(argv)
 
-	body, err := app.Query(ctx, *year, *bbox, *location, *radius, *polygon, geotypes, rows, cols, *censustable, *divideby)
+	f, err := table.ParseFormat(*format)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	body, err := app.Query(ctx, *year, *bbox, *location, *radius, *polygon, geotypes, rows, cols, *censustable, *divideby, *language, f)
 	if err != nil {
 		log.Fatalln(err)
 	}
@@ -89,7 +101,7 @@ func query(ctx context.Context, app *geodata.Geodata, argv []string) {
 }
 
 func ckmeans(ctx context.Context, app *geodata.Geodata, argv []string) {
-	var cat, geotype multiFlag
+	var cat, geotype, rows multiFlag
 
 	flagset := flag.NewFlagSet("ckmeans", flag.ExitOnError)
 
@@ -98,9 +110,23 @@ func ckmeans(ctx context.Context, app *geodata.Geodata, argv []string) {
 	flagset.Var(&geotype, "geotype", "geography types (LSOA, LAD, etc)")
 	k := flagset.Int("k", 5, "number of clusters/bins")
 	divide_by := flagset.String("divide_by", "", "category code to divide all other categories by (optional)")
+	method := flagset.String("method", "ckmeans", "classification method: ckmeans, jenks-sample, quantile, equal-interval, standard-deviation or head-tail")
+	detail := flagset.Bool("detail", false, "include class counts, means and medians")
+	bbox := flagset.String("bbox", "", "only classify areas in bounding box lon1,lat1,lon2,lat2 (optional)")
+	location := flagset.String("location", "", "central point for radius (optional)")
+	radius := flagset.Int("radius", 0, "only classify areas within radius meters of location (optional)")
+	polygon := flagset.String("polygon", "", "only classify areas in polygon x1,y1,...,x1,y1 (optional)")
+	flagset.Var(&rows, "rows", "only classify row or row range (optional)")
 	flagset.Parse(argv)
 
-	breaks, err := app.CKmeans(ctx, *year, cat, geotype, *k, *divide_by)
+	area := geodata.AreaArgs{
+		Geos:     rows,
+		BBox:     *bbox,
+		Location: *location,
+		Radius:   *radius,
+		Polygon:  *polygon,
+	}
+	breaks, err := app.CKmeans(ctx, *year, cat, geotype, *k, *divide_by, *method, *detail, area)
 	if err != nil {
 		log.Fatalln(err)
 	}
@@ -111,14 +137,54 @@ func ckmeans(ctx context.Context, app *geodata.Geodata, argv []string) {
 	fmt.Print(string(append(buf, "\n"...)))
 }
 
+func stats(ctx context.Context, app *geodata.Geodata, argv []string) {
+	var cat, geotype multiFlag
+	var percentiles []float64
+
+	flagset := flag.NewFlagSet("stats", flag.ExitOnError)
+
+	year := flagset.Int("year", 2011, "census year")
+	flagset.Var(&cat, "cat", "category code(s) to describe")
+	flagset.Var(&geotype, "geotype", "geography types (LSOA, LAD, etc)")
+	divide_by := flagset.String("divide_by", "", "category code to divide all other categories by (optional)")
+	flagset.Func("percentile", "percentile(s) to return, 0 to 100 (optional)", func(s string) error {
+		p, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		percentiles = append(percentiles, p)
+		return nil
+	})
+	bins := flagset.Int("bins", 0, "number of histogram bins (optional)")
+	flagset.Parse(argv)
+
+	result, err := app.Stats(ctx, geodata.StatsArgs{
+		Year:        *year,
+		Cats:        cat,
+		Geotypes:    geotype,
+		DivideBy:    *divide_by,
+		Percentiles: percentiles,
+		Bins:        *bins,
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+	buf, err := json.MarshalIndent(result, "", "    ")
+	if err != nil {
+		log.Fatalln(err)
+	}
+	fmt.Print(string(append(buf, "\n"...)))
+}
+
 func mdquery(ctx context.Context, md *metadata.Metadata, argv []string) {
 	flagset := flag.NewFlagSet("metadata", flag.ExitOnError)
 
 	year := flagset.Int("year", 2011, "census year")
 	filtertotals := flagset.Bool("filtertotals", false, "include totals")
+	language := flagset.String("lang", lang.English, "language of names (en or cy)")
 	flagset.Parse(argv)
 
-	result, err := md.Get(ctx, *year, *filtertotals)
+	result, err := md.Get(ctx, *year, *filtertotals, *language)
 	if err != nil {
 		log.Fatalln(err)
 	}
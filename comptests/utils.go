@@ -23,6 +23,7 @@ func ClearDB(db *database.Database) error {
 		"nomis_desc",
 		"nomis_topic",
 		"geo_type",
+		"geo_breaks",
 		"data_ver",
 	}
 	for _, table := range tables {
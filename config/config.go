@@ -8,22 +8,45 @@ import (
 
 // Config represents service configuration for dp-geodata-api
 type Config struct {
-	BindAddr                   string        `envconfig:"BIND_ADDR"`
-	BaseURL                    string        `envconfig:"BASEURL"`
-	GracefulShutdownTimeout    time.Duration `envconfig:"GRACEFUL_SHUTDOWN_TIMEOUT"`
-	HealthCheckInterval        time.Duration `envconfig:"HEALTHCHECK_INTERVAL"`
-	HealthCheckCriticalTimeout time.Duration `envconfig:"HEALTHCHECK_CRITICAL_TIMEOUT"`
-	EnableDatabase             bool          `envconfig:"ENABLE_DATABASE"`
-	MaxMetrics                 int           `envconfig:"MAX_METRICS"`
-	WriteTimeout               time.Duration `envconfig:"WRITE_TIMEOUT"`
-	APIToken                   string        `envconfig:"API_TOKEN"                     json:"-"`
-	EnableHeaderAuth           bool          `envconfig:"ENABLE_HEADER_AUTH"`
-	CacheSize                  int           `envconfig:"CACHE_SIZE"`
-	CacheTTL                   time.Duration `envconfig:"CACHE_TTL"`
-	EnableCantabular           bool          `envconfig:"ENABLE_CANTABULAR"`
-	CantabularURL              string        `envconfig:"CANT_URL"`
-	CantabularUser             string        `envconfig:"CANT_USER"`
-	DoCors                     bool          `envconfig:"DO_CORS"`
+	BindAddr                   string                   `envconfig:"BIND_ADDR"`
+	BaseURL                    string                   `envconfig:"BASEURL"`
+	GracefulShutdownTimeout    time.Duration            `envconfig:"GRACEFUL_SHUTDOWN_TIMEOUT"`
+	HealthCheckInterval        time.Duration            `envconfig:"HEALTHCHECK_INTERVAL"`
+	HealthCheckCriticalTimeout time.Duration            `envconfig:"HEALTHCHECK_CRITICAL_TIMEOUT"`
+	EnableDatabase             bool                     `envconfig:"ENABLE_DATABASE"`
+	MaxMetrics                 int                      `envconfig:"MAX_METRICS"`
+	WriteTimeout               time.Duration            `envconfig:"WRITE_TIMEOUT"`
+	APIToken                   string                   `envconfig:"API_TOKEN"                     json:"-"`
+	EnableHeaderAuth           bool                     `envconfig:"ENABLE_HEADER_AUTH"`
+	APIKeysFile                string                   `envconfig:"API_KEYS_FILE"`
+	APIKeysDB                  bool                     `envconfig:"API_KEYS_DB"`
+	CacheSize                  int                      `envconfig:"CACHE_SIZE"`
+	CacheTTL                   time.Duration            `envconfig:"CACHE_TTL"`
+	CacheStores                []string                 `envconfig:"CACHE_STORES"`
+	CacheDir                   string                   `envconfig:"CACHE_DIR"`
+	RedisAddr                  string                   `envconfig:"REDIS_ADDR"`
+	RedisPassword              string                   `envconfig:"REDIS_PASSWORD"                json:"-"`
+	RedisLock                  bool                     `envconfig:"REDIS_LOCK"`
+	CacheMaxAge                time.Duration            `envconfig:"CACHE_MAX_AGE"`
+	CacheMaxAges               map[string]time.Duration `envconfig:"CACHE_MAX_AGES"`
+	StreamCacheLimit           int                      `envconfig:"STREAM_CACHE_LIMIT"`
+	StreamTimeout              time.Duration            `envconfig:"STREAM_TIMEOUT"`
+	QueryTimeout               time.Duration            `envconfig:"QUERY_TIMEOUT"`
+	QueryTimeouts              map[string]time.Duration `envconfig:"QUERY_TIMEOUTS"`
+	ExportDir                  string                   `envconfig:"EXPORT_DIR"`
+	ExportWorkers              int                      `envconfig:"EXPORT_WORKERS"`
+	ExportTTL                  time.Duration            `envconfig:"EXPORT_TTL"`
+	WarmFile                   string                   `envconfig:"WARM_FILE"`
+	WarmWorkers                int                      `envconfig:"WARM_WORKERS"`
+	RateLimitRate              float64                  `envconfig:"RATE_LIMIT_RATE"`
+	RateLimitBurst             int                      `envconfig:"RATE_LIMIT_BURST"`
+	TrustedProxies             int                      `envconfig:"TRUSTED_PROXIES"`
+	TracingExporter            string                   `envconfig:"TRACING_EXPORTER"`
+	TracingEndpoint            string                   `envconfig:"TRACING_ENDPOINT"`
+	EnableCantabular           bool                     `envconfig:"ENABLE_CANTABULAR"`
+	CantabularURL              string                   `envconfig:"CANT_URL"`
+	CantabularUser             string                   `envconfig:"CANT_USER"`
+	DoCors                     bool                     `envconfig:"DO_CORS"`
 }
 
 var cfg *Config
@@ -44,8 +67,20 @@ func Get() (*Config, error) {
 		WriteTimeout:               30 * time.Second, // http WriteTimeout
 		APIToken:                   "",
 		EnableHeaderAuth:           false,
-		CacheSize:                  200,            // memory cache size in MB
-		CacheTTL:                   12 * time.Hour, // cache entry TTL
+		CacheSize:                  200,                // memory cache size in MB
+		CacheTTL:                   12 * time.Hour,     // cache entry TTL
+		CacheStores:                []string{"memory"}, // cache stores, fastest first
+		StreamCacheLimit:           10,                 // largest streamed response to cache, in MB
+		StreamTimeout:              10 * time.Minute,   // replaces WriteTimeout for streamed responses
+		QueryTimeout:               25 * time.Second,   // less than WriteTimeout, so timeouts get a 504
+		ExportWorkers:              2,                  // concurrent export jobs
+		ExportTTL:                  7 * 24 * time.Hour, // how long finished exports are kept
+		WarmWorkers:                4,                  // concurrent cache warming requests
+		RateLimitBurst:             1000000,            // largest query cost budget per client, in metrics
+		TrustedProxies:             -1,                 // unset; must be given when rate limiting
+		// ExportDir defaults to empty, which disables exports
+		// RateLimitRate defaults to 0, which disables rate limiting
+		// TracingExporter defaults to empty, which disables tracing
 		// Cantabular defaults to disabled, so no defaults
 	}
 
@@ -31,6 +31,15 @@ func TestConfig(t *testing.T) {
 					WriteTimeout:               30 * time.Second,
 					CacheSize:                  200,
 					CacheTTL:                   12 * time.Hour,
+					CacheStores:                []string{"memory"},
+					StreamCacheLimit:           10,
+					StreamTimeout:              10 * time.Minute,
+					QueryTimeout:               25 * time.Second,
+					ExportWorkers:              2,
+					ExportTTL:                  7 * 24 * time.Hour,
+					WarmWorkers:                4,
+					RateLimitBurst:             1000000,
+					TrustedProxies:             -1,
 				})
 			})
 
@@ -42,4 +51,52 @@ func TestConfig(t *testing.T) {
 			})
 		})
 	})
+
+	Convey("Given per-endpoint cache max-ages in the environment", t, func() {
+		os.Clearenv()
+		os.Setenv("CACHE_MAX_AGE", "5m")
+		os.Setenv("CACHE_MAX_AGES", "metadata:24h,query:1h")
+		cfg = nil
+
+		Convey("Then they are parsed as durations", func() {
+			configuration, err = Get()
+			So(err, ShouldBeNil)
+			So(configuration.CacheMaxAge, ShouldEqual, 5*time.Minute)
+			So(configuration.CacheMaxAges, ShouldResemble, map[string]time.Duration{
+				"metadata": 24 * time.Hour,
+				"query":    time.Hour,
+			})
+		})
+	})
+
+	Convey("Given per-endpoint query timeouts in the environment", t, func() {
+		os.Clearenv()
+		os.Setenv("QUERY_TIMEOUT", "10s")
+		os.Setenv("QUERY_TIMEOUTS", "ckmeans:20s,search:2s")
+		cfg = nil
+
+		Convey("Then they are parsed as durations", func() {
+			configuration, err = Get()
+			So(err, ShouldBeNil)
+			So(configuration.QueryTimeout, ShouldEqual, 10*time.Second)
+			So(configuration.QueryTimeouts, ShouldResemble, map[string]time.Duration{
+				"ckmeans": 20 * time.Second,
+				"search":  2 * time.Second,
+			})
+		})
+	})
+
+	Convey("Given an OTLP tracing exporter in the environment", t, func() {
+		os.Clearenv()
+		os.Setenv("TRACING_EXPORTER", "otlp")
+		os.Setenv("TRACING_ENDPOINT", "http://localhost:4318")
+		cfg = nil
+
+		Convey("Then the exporter and endpoint are set", func() {
+			configuration, err = Get()
+			So(err, ShouldBeNil)
+			So(configuration.TracingExporter, ShouldEqual, "otlp")
+			So(configuration.TracingEndpoint, ShouldEqual, "http://localhost:4318")
+		})
+	})
 }
@@ -12,7 +12,7 @@ import (
 	"github.com/ONSdigital/dp-geodata-api/data-tiles/geos"
 	"github.com/ONSdigital/dp-geodata-api/data-tiles/grid"
 	"github.com/ONSdigital/dp-geodata-api/data-tiles/types"
-	"github.com/jtrim-ons/ckmeans/pkg/ckmeans"
+	"github.com/ONSdigital/dp-geodata-api/pkg/classify"
 )
 
 func main() {
@@ -22,8 +22,14 @@ func main() {
 	metdir := flag.String("M", "data/processed/metrics", "directory holding metrics files for each category")
 	outdir := flag.String("O", "data/output/breaks", "output directory")
 	calcRatios := flag.Bool("R", false, "calculate ratios")
+	methodName := flag.String("m", "ckmeans", "classification method: ckmeans, jenks-sample, quantile, equal-interval, standard-deviation or head-tail")
 	flag.Parse()
 
+	method, err := classify.ParseMethod(*methodName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	quads, err := grid.Load(*gridfile)
 	if err != nil {
 		log.Fatal(err)
@@ -61,7 +67,7 @@ func main() {
 		log.Fatal(err)
 	}
 
-	if err := genbreaks(wanttypes, geotypes, catlist, metrics, *calcRatios, *outdir); err != nil {
+	if err := genbreaks(wanttypes, geotypes, catlist, metrics, *calcRatios, method, *outdir); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -105,12 +111,13 @@ func genbreaks(
 	cats []types.Category,
 	metrics map[types.Category]map[types.Geocode]types.Value,
 	calcMetrics bool,
+	method classify.Method,
 	dir string,
 ) error {
 	if calcMetrics {
-		return genbreaksWithRatios(wanttypes, geotypes, cats, metrics, dir)
+		return genbreaksWithRatios(wanttypes, geotypes, cats, metrics, method, dir)
 	} else {
-		return genbreaksWithoutRatios(wanttypes, geotypes, cats, metrics, dir)
+		return genbreaksWithoutRatios(wanttypes, geotypes, cats, metrics, method, dir)
 	}
 }
 
@@ -119,6 +126,7 @@ func genbreaksWithRatios(
 	geotypes map[types.Geocode]types.Geotype,
 	cats []types.Category,
 	metrics map[types.Category]map[types.Geocode]types.Value,
+	method classify.Method,
 	dir string,
 ) error {
 	for _, thiscat := range cats {
@@ -166,7 +174,7 @@ func genbreaksWithRatios(
 		}
 
 		for geotype, values := range ratios {
-			breaks, err := getBreaks(values, 5)
+			breaks, err := classify.Breaks(method, values, 5)
 			if err != nil {
 				return fmt.Errorf(
 					"%s %s (%d values): %w",
@@ -177,7 +185,7 @@ func genbreaksWithRatios(
 				)
 			}
 
-			minmax := getMinMax(values)
+			minmax := classify.MinMax(values)
 
 			result := stats{
 				thiscat: map[string][]float64{
@@ -199,6 +207,7 @@ func genbreaksWithoutRatios(
 	geotypes map[types.Geocode]types.Geotype,
 	cats []types.Category,
 	metrics map[types.Category]map[types.Geocode]types.Value,
+	method classify.Method,
 	dir string,
 ) error {
 	for _, thiscat := range cats {
@@ -221,7 +230,7 @@ func genbreaksWithoutRatios(
 		}
 
 		for geotype, values := range ratios {
-			breaks, err := getBreaks(values, 5)
+			breaks, err := classify.Breaks(method, values, 5)
 			if err != nil {
 				return fmt.Errorf(
 					"%s %s (%d values): %w",
@@ -232,7 +241,7 @@ func genbreaksWithoutRatios(
 				)
 			}
 
-			minmax := getMinMax(values)
+			minmax := classify.MinMax(values)
 
 			result := stats{
 				thiscat: map[string][]float64{
@@ -249,35 +258,6 @@ func genbreaksWithoutRatios(
 	return nil
 }
 
-// getBreaks gets k ckmeans clusters from metrics and returns the upper breakpoints for each cluster.
-func getBreaks(metrics []float64, k int) ([]float64, error) {
-	clusters, err := ckmeans.Ckmeans(metrics, k)
-	if err != nil {
-		return nil, err
-	}
-
-	var breaks []float64
-	for _, cluster := range clusters {
-		bp := cluster[len(cluster)-1]
-		breaks = append(breaks, bp)
-	}
-	return breaks, nil
-}
-
-func getMinMax(values []float64) []float64 {
-	max := values[0]
-	min := values[0]
-	for _, v := range values {
-		if v > max {
-			max = v
-		}
-		if v < min {
-			min = v
-		}
-	}
-	return []float64{min, max}
-}
-
 func saveStats(dir string, geotype types.Geotype, cat types.Category, result stats) error {
 	d := filepath.Join(dir, geotype.Pathname())
 	if err := os.MkdirAll(d, 0755); err != nil {
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dp-geodata-api/model"
+	"github.com/ONSdigital/dp-geodata-api/pkg/database"
+	"github.com/ONSdigital/dp-geodata-api/pkg/geodata"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+const batchSize = 10000
+
+// populates geo_breaks with precomputed /ckmeans breaks
+func main() {
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	year := flag.Int("year", 2011, "census year")
+	geotypeList := flag.String("geotypes", strings.Join(model.GetGeoTypeValues(), ","), "comma separated geotypes")
+	kList := flag.String("k", "5", "comma separated numbers of classes")
+	methodList := flag.String("methods", "ckmeans", "comma separated classification methods")
+	flag.Parse()
+
+	geotypes := split(*geotypeList)
+	ks, err := parseInts(*kList)
+	if err != nil {
+		log.Fatal(err)
+	}
+	methods := split(*methodList)
+
+	dsn := database.GetDSN()
+	db, err := database.Open("pgx", dsn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	app, err := geodata.New(db, nil, 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// compute everything before touching geo_breaks, so requests keep using the old
+	// breaks until the new ones are ready
+	t0 := time.Now()
+	var rows []model.GeoBreaks
+	err = app.ComputeBreaks(context.Background(), *year, geotypes, ks, methods, func(row model.GeoBreaks) error {
+		rows = append(rows, row)
+		if len(rows)%1000 == 0 {
+			log.Printf("%d breaks", len(rows))
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	gdb, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = gdb.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where(
+			"data_ver_id IN (SELECT id FROM data_ver WHERE census_year = ?) AND geotype IN ? AND k IN ? AND method IN ?",
+			*year,
+			geotypes,
+			ks,
+			methods,
+		).Delete(&model.GeoBreaks{}).Error
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(rows, batchSize).Error
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%d rows in %d min(s)\n", len(rows), int(time.Since(t0).Minutes()))
+}
+
+// split splits a comma separated list, ignoring empty items.
+func split(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseInts parses a comma separated list of positive integers.
+func parseInts(list string) ([]int, error) {
+	var ints []int
+	for _, item := range split(list) {
+		n, err := strconv.Atoi(item)
+		if err != nil {
+			return nil, err
+		}
+		if n < 1 {
+			return nil, fmt.Errorf("%d: must be at least 1", n)
+		}
+		ints = append(ints, n)
+	}
+	return ints, nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	got := split(" LAD,,MSOA ,")
+	want := []string{"LAD", "MSOA"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestParseInts(t *testing.T) {
+	var tests = map[string]struct {
+		list    string
+		want    []int
+		wantErr bool
+	}{
+		"one":      {list: "5", want: []int{5}},
+		"several":  {list: "3,5,7", want: []int{3, 5, 7}},
+		"empty":    {list: "", want: nil},
+		"not int":  {list: "5,x", wantErr: true},
+		"too few":  {list: "0", wantErr: true},
+		"negative": {list: "-2", wantErr: true},
+	}
+
+	for name, test := range tests {
+		got, err := parseInts(test.list)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: got error %v, want error %t", name, err, test.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/ONSdigital/dp-geodata-api/model"
+	"github.com/ONSdigital/dp-geodata-api/pkg/database"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+const batchSize = 10000
+
+// populates geo_hierarchy, linking each area to the larger areas containing it
+func main() {
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	lookup := flag.String("lookup", "", "CSV file of geocodes, one row per area, smallest geotype first")
+	flag.Parse()
+
+	db, err := gorm.Open(postgres.Open(database.GetDSN()), &gorm.Config{})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	t0 := time.Now()
+	if err := db.Exec("DELETE FROM geo_hierarchy").Error; err != nil {
+		log.Fatal(err)
+	}
+	if *lookup != "" {
+		err = loadLookup(db, *lookup)
+	} else {
+		err = bestFit(db)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%d min(s)\n", int(time.Since(t0).Minutes()))
+}
+
+// loadLookup populates geo_hierarchy from a lookup file such as
+// PCD_OA_LSOA_MSOA_LAD_MAY20_UK_LU.csv cut down to its oa11cd, lsoa11cd, msoa11cd and
+// ladcd columns.
+// The first line is a header and is ignored.
+func loadLookup(db *gorm.DB, fname string) error {
+	f, err := os.Open(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	pairs, err := readPairs(f)
+	if err != nil {
+		return err
+	}
+
+	var geos []model.Geo
+	if err := db.Select("id", "code").Where("valid").Find(&geos).Error; err != nil {
+		return err
+	}
+	ids := map[string]int32{}
+	for _, geo := range geos {
+		ids[geo.Code] = geo.ID
+	}
+
+	var rows []model.GeoHierarchy
+	for _, pair := range pairs {
+		child, ok := ids[pair[0]]
+		if !ok {
+			log.Printf("not found: %s", pair[0])
+			continue
+		}
+		parent, ok := ids[pair[1]]
+		if !ok {
+			log.Printf("not found: %s", pair[1])
+			continue
+		}
+		rows = append(rows, model.GeoHierarchy{ChildID: child, ParentID: parent})
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	fmt.Printf("%d rows\n", len(rows))
+	return db.CreateInBatches(rows, batchSize).Error
+}
+
+// readPairs reads a lookup CSV and returns every distinct child, parent pair of geocodes.
+// Each row lists the geocodes of one area and the areas containing it, smallest first,
+// so every geocode in a row is the parent of every geocode to its left.
+func readPairs(r io.Reader) ([][2]string, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	seen := map[[2]string]bool{}
+	var pairs [][2]string
+	for line := 0; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == 0 {
+			continue // skip header line
+		}
+		for i := 0; i < len(record); i++ {
+			for j := i + 1; j < len(record); j++ {
+				if record[i] == "" || record[j] == "" {
+					continue
+				}
+				pair := [2]string{record[i], record[j]}
+				if !seen[pair] {
+					seen[pair] = true
+					pairs = append(pairs, pair)
+				}
+			}
+		}
+	}
+	return pairs, nil
+}
+
+// bestFit populates geo_hierarchy using the best-fit method: an area belongs to a larger
+// area if its centroid lies within the larger area's boundary.
+// Every pair of geotypes is linked, so an OA is linked directly to its LSOA, MSOA and LAD.
+func bestFit(db *gorm.DB) error {
+	geotypes := model.GetGeoTypeValues() // largest first
+	for i, parent := range geotypes {
+		for _, child := range geotypes[i+1:] {
+			result := db.Exec(bestFitSQL, child, parent)
+			if result.Error != nil {
+				return result.Error
+			}
+			fmt.Printf("%s -> %s: %d rows\n", child, parent, result.RowsAffected)
+		}
+	}
+	return nil
+}
+
+const bestFitSQL = `
+INSERT INTO geo_hierarchy (child_id, parent_id)
+SELECT
+	child.id,
+	parent.id
+FROM
+	geo AS child,
+	geo_type AS child_type,
+	geo AS parent,
+	geo_type AS parent_type
+WHERE child.valid
+AND child_type.id = child.type_id
+AND child_type.name = ?
+AND parent.valid
+AND parent_type.id = parent.type_id
+AND parent_type.name = ?
+AND ST_Covers(parent.wkb_geometry, child.wkb_long_lat_geom)
+`
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadPairs(t *testing.T) {
+	var tests = map[string]struct {
+		csv  string
+		want [][2]string
+	}{
+		"header only": {
+			csv:  "oa11cd,lsoa11cd\n",
+			want: nil,
+		},
+		"child parent": {
+			csv: "child,parent\nE00000001,E01000001\n",
+			want: [][2]string{
+				{"E00000001", "E01000001"},
+			},
+		},
+		"chain": {
+			csv: "oa11cd,lsoa11cd,msoa11cd\nE00000001,E01000001,E02000001\n",
+			want: [][2]string{
+				{"E00000001", "E01000001"},
+				{"E00000001", "E02000001"},
+				{"E01000001", "E02000001"},
+			},
+		},
+		"duplicates and blanks": {
+			csv: "oa11cd,lsoa11cd,msoa11cd\nE00000001,E01000001,\nE00000002,E01000001,E02000001\n",
+			want: [][2]string{
+				{"E00000001", "E01000001"},
+				{"E00000002", "E01000001"},
+				{"E00000002", "E02000001"},
+				{"E01000001", "E02000001"},
+			},
+		},
+	}
+
+	for desc, test := range tests {
+		got, err := readPairs(strings.NewReader(test.csv))
+		if err != nil {
+			t.Errorf("%s: %s", desc, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", desc, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/ONSdigital/dp-geodata-api/model"
+	"github.com/ONSdigital/dp-geodata-api/pkg/database"
+	"github.com/spkg/bom"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+const batchSize = 10000
+
+// populates geo_vintage_lookup from a best-fit lookup between two census years' geographies
+func main() {
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	fname := flag.String("r", "", "input best-fit lookup csv file")
+	fromYear := flag.Int("from", 2011, "census year of the geocodes in the from column")
+	toYear := flag.Int("to", 2021, "census year of the geocodes in the to column")
+	fromCol := flag.String("fromcol", "FromCode", "name of the from column")
+	toCol := flag.String("tocol", "ToCode", "name of the to column")
+	flag.Parse()
+
+	if *fname == "" {
+		log.Fatal("-r is required")
+	}
+
+	f, err := os.Open(*fname)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	recodes, err := readRecodes(f, *fromCol, *toCol)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := gorm.Open(postgres.Open(database.GetDSN()), &gorm.Config{})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("from_year = ? AND to_year = ?", *fromYear, *toYear).Delete(&model.GeoVintageLookup{}).Error
+		if err != nil {
+			return err
+		}
+
+		var rows []model.GeoVintageLookup
+		for from, to := range recodes {
+			rows = append(rows, model.GeoVintageLookup{
+				FromYear: int32(*fromYear),
+				FromCode: from,
+				ToYear:   int32(*toYear),
+				ToCode:   to,
+			})
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(rows, batchSize).Error
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%d rows\n", len(recodes))
+}
+
+// readRecodes reads the from and to columns of a lookup CSV into a map of from code to
+// to code.
+// Rows where the code is unchanged are left out, since unmapped codes are assumed not to
+// have changed.
+func readRecodes(r io.Reader, fromCol, toCol string) (map[string]string, error) {
+	// ONS lookup files may contain a UTF-8 BOM
+	cr := csv.NewReader(bom.NewReader(r))
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	from, to := -1, -1
+	for i, name := range header {
+		switch name {
+		case fromCol:
+			from = i
+		case toCol:
+			to = i
+		}
+	}
+	if from == -1 || to == -1 {
+		return nil, fmt.Errorf("header must include %s and %s: %v", fromCol, toCol, header)
+	}
+
+	recodes := map[string]string{}
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if record[from] == "" || record[to] == "" || record[from] == record[to] {
+			continue
+		}
+		if prev, ok := recodes[record[from]]; ok && prev != record[to] {
+			return nil, fmt.Errorf("%s maps to both %s and %s; best-fit lookups must be many to one", record[from], prev, record[to])
+		}
+		recodes[record[from]] = record[to]
+	}
+	return recodes, nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadRecodes(t *testing.T) {
+	var tests = map[string]struct {
+		csv     string
+		fromCol string
+		toCol   string
+		want    map[string]string
+		wantErr bool
+	}{
+		"recode-lads format": {
+			csv:     "FromCode,ToCode\nE06000057,E06000048\nE07000240,E07000100\n",
+			fromCol: "FromCode",
+			toCol:   "ToCode",
+			want: map[string]string{
+				"E06000057": "E06000048",
+				"E07000240": "E07000100",
+			},
+		},
+		"BOM and other columns": {
+			csv:     "\ufeffOA11CD,OA21CD,CHNGIND\nE00000001,E00000001,U\nE00000002,E00000003,M\nE00000004,E00000003,M\n",
+			fromCol: "OA11CD",
+			toCol:   "OA21CD",
+			want: map[string]string{
+				"E00000002": "E00000003",
+				"E00000004": "E00000003",
+			},
+		},
+		"missing column": {
+			csv:     "FromCode,Other\nE06000057,E06000048\n",
+			fromCol: "FromCode",
+			toCol:   "ToCode",
+			wantErr: true,
+		},
+		"one to many": {
+			csv:     "FromCode,ToCode\nE00000001,E00000002\nE00000001,E00000003\n",
+			fromCol: "FromCode",
+			toCol:   "ToCode",
+			wantErr: true,
+		},
+	}
+
+	for desc, test := range tests {
+		got, err := readRecodes(strings.NewReader(test.csv), test.fromCol, test.toCol)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: got error %v, want error %t", desc, err, test.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", desc, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ONSdigital/dp-geodata-api/api"
+	"github.com/ONSdigital/dp-geodata-api/pkg/geodata"
+)
+
+func (svr *Server) GetAggregateYear(w http.ResponseWriter, r *http.Request, year int, params api.GetAggregateYearParams) {
+	if !svr.assertAuthorized(w, r, scopeAggregate) || !svr.assertDatabaseEnabled(w, r) {
+		return
+	}
+
+	generate := func() ([]byte, error) {
+		args := geodata.AggregateArgs{
+			Year: year,
+			Cols: params.Cols,
+		}
+		if params.Rows != nil {
+			args.Geos = *params.Rows
+		}
+		if params.Geotype != nil {
+			args.Geotype = *params.Geotype
+		}
+		if params.Location != nil {
+			args.Location = *params.Location
+		}
+		if params.Radius != nil {
+			args.Radius = *params.Radius
+		}
+		if params.Polygon != nil {
+			args.Polygon = *params.Polygon
+		}
+
+		// charged for the metrics summed, like a query over the same areas
+		err := svr.chargeEstimate(r, func(ctx context.Context) (int, error) {
+			costArgs := geodata.CensusQuerySQLArgs{
+				Year:     args.Year,
+				Geos:     args.Geos,
+				Location: args.Location,
+				Radius:   args.Radius,
+				Polygon:  args.Polygon,
+				Cols:     args.Cols,
+			}
+			if args.Geotype != "" {
+				costArgs.Geotypes = []string{args.Geotype}
+			}
+			return svr.querygeodata.Cost(ctx, costArgs)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		ctx := r.Context()
+		return svr.querygeodata.Aggregate(ctx, args)
+	}
+
+	svr.respond(w, r, mimeCSV, generate)
+}
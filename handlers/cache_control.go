@@ -3,80 +3,230 @@ package handlers
 import (
 	"errors"
 	"net/http"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ONSdigital/dp-geodata-api/cache"
+	"github.com/ONSdigital/dp-geodata-api/pkg/tracing"
 	"github.com/ONSdigital/dp-geodata-api/sentinel"
 	"github.com/ONSdigital/log.go/v2/log"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
-	mimeCSV  = "text/csv"
-	mimeJSON = "application/json"
+	mimeCSV     = "text/csv"
+	mimeJSON    = "application/json"
+	mimeGeoJSON = "application/geo+json"
+	mimeParquet = "application/vnd.apache.parquet"
 )
 
 type generateFunc func() ([]byte, error)
 
 // respond returns cached data if it is available, or generates and caches new data.
-func (svr *Server) respond(w http.ResponseWriter, r *http.Request, contentType string, generate generateFunc) {
+// The cache key is the request URI and contentType, plus any variants, which
+// distinguish responses to the same URI that depend on other request headers.
+//
+// Responses carry an ETag computed from the body, Last-Modified from the data versions
+// of the census years in the path, and Cache-Control from the configured max-age for
+// the endpoint.
+// Conditional requests matching these validators get 304 Not Modified.
+//
+// Responses are compressed with gzip or brotli if the client accepts them.
+// Compressed responses are cached under their own keys, made from the cached
+// uncompressed response, so each is only generated and compressed once.
+//
+func (svr *Server) respond(w http.ResponseWriter, r *http.Request, contentType string, generate generateFunc, variants ...string) {
 
 	// add CORS header if application configured to do so
 	if svr.doCors {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 	}
 
+	encoding := negotiateEncoding(w, r)
+	ctx, span := tracing.Start(r.Context(), "respond",
+		attribute.String("http.response.content_type", contentType),
+		attribute.String("http.response.content_encoding", encoding),
+	)
+	r = r.WithContext(ctx)
+
+	key := cache.CacheKey(r, append([]string{contentType}, variants...)...)
+	buildPlain := func() (*cache.Value, error) {
+		body, err := generate()
+		if err != nil {
+			return nil, err
+		}
+		return cache.NewValue(body, svr.lastModified(r)), nil
+	}
+
+	var value *cache.Value
 	var err error
-	var body []byte
+	if encoding == encodingIdentity {
+		value, err = svr.fetch(r, key, buildPlain)
+	} else {
+		encodedKey := cache.CacheKey(r, append(append([]string{contentType}, variants...), encoding)...)
+		value, err = svr.fetch(r, encodedKey, func() (*cache.Value, error) {
+			plain, err := svr.fetch(r, key, buildPlain)
+			if err != nil {
+				return nil, err
+			}
+			return compressValue(plain, encoding)
+		})
+	}
 
-	key := cache.CacheKey(r)
+	tracing.End(span, err)
+	if err == nil {
+		svr.writeValue(w, r, contentType, encoding, value)
+		return
+	}
+
+	setRetryAfter(w, err)
+	sendError(ctx, w, errorCode(err), err.Error())
+}
+
+// fetch returns the cached value for key, or builds, caches and returns a new one.
+// The key is locked throughout, so concurrent requests wait for the first to build
+// the value rather than all building it.
+func (svr *Server) fetch(r *http.Request, key string, build func() (*cache.Value, error)) (*cache.Value, error) {
+	ctx := r.Context()
 
 	// allocate a serialiser for this cache key
 	ser := svr.cm.AllocateEntry(key)
 	defer ser.Free()
 
-	ctx := r.Context()
-
-	func() {
-		// lock cache key before doing any cache operations
-		ser.Lock()
-		defer ser.Unlock()
+	// lock cache key before doing any cache operations
+	if err := ser.Lock(ctx); err != nil {
+		return nil, lockError(err)
+	}
+	defer ser.Unlock()
 
-		if !noCache(r) {
-			body, err = ser.Get(ctx)
-			if err == nil {
-				return
-			}
+	if !noCache(r) {
+		value, err := ser.Get(ctx)
+		if err == nil {
+			return value, nil
 		}
+	}
 
-		body, err = generate()
-		if err != nil {
-			return
+	value, err := build()
+	if err != nil {
+		return nil, err
+	}
+
+	// if there is a problem saving response in cache, log it, but still send to client
+	if err := ser.Set(ctx, value, cacheTags(r)...); err != nil {
+		log.Warn(ctx, "cannot cache", log.Data{"message": err.Error(), "uri": key, "size": len(value.Body)})
+	}
+	return value, nil
+}
+
+// writeValue sends a cached value to the client, along with its validators, or just
+// the validators if the client already has it.
+// encoding is the value's content coding.
+func (svr *Server) writeValue(w http.ResponseWriter, r *http.Request, contentType, encoding string, value *cache.Value) {
+	svr.setValidators(w, r, value.ETag, value.LastModified)
+	if notModified(r, value) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Add("Content-Type", contentType)
+	if encoding != encodingIdentity {
+		w.Header().Set("Content-Encoding", encoding)
+	}
+	w.Write(value.Body)
+}
+
+// setValidators sets the ETag, Last-Modified and Cache-Control headers.
+// etag and modified are left out if empty.
+func (svr *Server) setValidators(w http.ResponseWriter, r *http.Request, etag string, modified time.Time) {
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	if !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+	w.Header().Set("Cache-Control", svr.cacheControl(r))
+}
+
+// cacheControl returns the Cache-Control header for responses to r.
+// The max-age for each endpoint, such as "query" or "metadata", can be set
+// separately from the default.
+// A max-age of zero becomes no-cache, so clients revalidate every time, which
+// costs little now that they can be told their copy is still good.
+func (svr *Server) cacheControl(r *http.Request) string {
+	maxAge := svr.maxAge
+	if age, ok := svr.maxAges[endpoint(r)]; ok {
+		maxAge = age
+	}
+	if maxAge <= 0 {
+		return "no-cache"
+	}
+	return "max-age=" + strconv.Itoa(int(maxAge.Seconds()))
+}
+
+// lastModified returns when the data behind the response to r last changed, using
+// the census years in the path.
+// The time is zero if there are no years, the database is not enabled, or it cannot
+// be found.
+func (svr *Server) lastModified(r *http.Request) time.Time {
+	years := pathYears(r)
+	if len(years) == 0 || svr.querygeodata == nil {
+		return time.Time{}
+	}
+	modified, err := svr.querygeodata.LastModified(r.Context(), years...)
+	if err != nil {
+		log.Warn(r.Context(), "cannot find last modified time", log.Data{"message": err.Error(), "years": years})
+		return time.Time{}
+	}
+	return modified
+}
+
+// notModified is true if the conditional headers in r show the client already has value.
+// If-None-Match takes precedence over If-Modified-Since, as in RFC 7232.
+func notModified(r *http.Request, value *cache.Value) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, etag := range strings.Split(inm, ",") {
+			etag = strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+			if etag == "*" || etag == value.ETag {
+				return true
+			}
 		}
+		return false
+	}
 
-		// if there is a problem saving response in cache, log it, but still send to client
-		err = ser.Set(ctx, body)
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !value.LastModified.IsZero() {
+		t, err := http.ParseTime(ims)
 		if err != nil {
-			log.Warn(ctx, "cannot cache", log.Data{"message": err.Error(), "uri": key, "size": len(body)})
-			err = nil
+			return false
 		}
-	}()
-
-	if err == nil {
-		w.Header().Add("Content-Type", contentType)
-		w.Write(body)
-		return
+		// Last-Modified only has a resolution of seconds
+		return !value.LastModified.Truncate(time.Second).After(t)
 	}
+	return false
+}
 
+// errorCode maps sentinel errors to HTTP status codes.
+// Every error sent to a client goes through here, so this is where they are counted.
+func errorCode(err error) int {
 	code := http.StatusInternalServerError
 	switch {
 	case errors.Is(err, sentinel.ErrMissingParams), errors.Is(err, sentinel.ErrInvalidParams):
 		code = http.StatusBadRequest
 	case errors.Is(err, sentinel.ErrTooManyMetrics):
 		code = http.StatusForbidden
-	case errors.Is(err, sentinel.ErrNotSupported):
+	case errors.Is(err, sentinel.ErrNotSupported), errors.Is(err, sentinel.ErrNotFound):
 		code = http.StatusNotFound
+	case errors.Is(err, sentinel.ErrNotAcceptable):
+		code = http.StatusNotAcceptable
+	case errors.Is(err, sentinel.ErrNotReady):
+		code = http.StatusConflict
+	case errors.Is(err, sentinel.ErrRateLimited):
+		code = http.StatusTooManyRequests
+	case errors.Is(err, sentinel.ErrQueryTimeout):
+		code = http.StatusGatewayTimeout
 	}
-	sendError(ctx, w, code, err.Error())
+	countError(err, code)
+	return code
 }
 
 // noCache is true if a Cache-Control header contains "no-cache"
@@ -92,3 +242,56 @@ func noCache(req *http.Request) bool {
 	}
 	return false
 }
+
+// tableCode matches a census table code, or a category code, capturing the table code.
+var tableCode = regexp.MustCompile(`^([A-Z]+[0-9]+[A-Z]+)[0-9]*$`)
+
+// tagParams are the query parameters which may name tables or categories.
+var tagParams = []string{"cols", "censustable", "cat", "cat1", "cat2", "divide_by"}
+
+// cacheTags returns the tags for a cached response to r, so it can be invalidated
+// when the data behind it changes.
+// Responses are tagged year=YYYY for the census years in the path, and table=CODE for
+// each table named in the query parameters, directly or through category codes.
+func cacheTags(r *http.Request) []string {
+	var tags []string
+	seen := map[string]bool{}
+	add := func(tag string) {
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+
+	for _, year := range pathYears(r) {
+		add("year=" + strconv.Itoa(year))
+	}
+
+	query := r.URL.Query()
+	for _, param := range tagParams {
+		for _, value := range query[param] {
+			for _, item := range strings.Split(value, ",") {
+				for _, code := range strings.Split(item, "...") {
+					if m := tableCode.FindStringSubmatch(strings.TrimSpace(code)); m != nil {
+						add("table=" + m[1])
+					}
+				}
+			}
+		}
+	}
+	return tags
+}
+
+// pathYears returns the census years in the path of r, such as 2011 in /query/2011.
+func pathYears(r *http.Request) []int {
+	var years []int
+	for _, segment := range strings.Split(r.URL.Path, "/") {
+		if len(segment) != 4 {
+			continue
+		}
+		if year, err := strconv.Atoi(segment); err == nil && year >= 1000 {
+			years = append(years, year)
+		}
+	}
+	return years
+}
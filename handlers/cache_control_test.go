@@ -1,8 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"net/http/httptest"
+	"reflect"
 	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-geodata-api/cache"
 )
 
 func Test_noCache(t *testing.T) {
@@ -50,3 +56,188 @@ func Test_noCache(t *testing.T) {
 		}
 	}
 }
+
+func Test_cacheTags(t *testing.T) {
+	var tests = map[string]struct {
+		uri  string
+		want []string
+	}{
+		"no tags": {
+			"/health",
+			nil,
+		},
+		"year only": {
+			"/metadata/2021",
+			[]string{"year=2021"},
+		},
+		"cols and ranges": {
+			"/query/2011?rows=E01000001&cols=geography_code,QS101EW0001...QS101EW0003,QS501EW0002",
+			[]string{"year=2011", "table=QS101EW", "table=QS501EW"},
+		},
+		"repeated params and divide_by": {
+			"/query/2011?cols=QS101EW0002&cols=KS102EW0001&divide_by=QS101EW0001",
+			[]string{"year=2011", "table=QS101EW", "table=KS102EW"},
+		},
+		"censustable and cats": {
+			"/ckmeans/2011?cat=QS501EW0002&divide_by=QS501EW0001&k=5",
+			[]string{"year=2011", "table=QS501EW"},
+		},
+		"compare years": {
+			"/compare/2011/2021?cols=QS101EW0001",
+			[]string{"year=2011", "year=2021", "table=QS101EW"},
+		},
+	}
+
+	for name, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.uri, nil)
+		got := cacheTags(req)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: %v, want %v", name, got, test.want)
+		}
+	}
+}
+
+func Test_respond_Conditional(t *testing.T) {
+	cm, err := cache.New(time.Minute, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svr := &Server{cm: cm, maxAge: time.Minute, maxAges: map[string]time.Duration{"metadata": 0}}
+	generate := func() ([]byte, error) {
+		return []byte("a,b\n1,2\n"), nil
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/query/2011?cols=QS101EW0001", nil)
+	rec := httptest.NewRecorder()
+	svr.respond(rec, req, mimeCSV, generate)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d, want %d", rec.Code, http.StatusOK)
+	}
+	etag := rec.Header().Get("ETag")
+	if etag != cache.ETag([]byte("a,b\n1,2\n")) {
+		t.Errorf("ETag %q, want hash of body", etag)
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != "max-age=60" {
+		t.Errorf("Cache-Control %q, want %q", cc, "max-age=60")
+	}
+
+	var tests = map[string]struct {
+		header   string
+		value    string
+		wantCode int
+	}{
+		"matching etag": {
+			"If-None-Match",
+			etag,
+			http.StatusNotModified,
+		},
+		"matching etag in list": {
+			"If-None-Match",
+			`"other", ` + etag,
+			http.StatusNotModified,
+		},
+		"weak etag": {
+			"If-None-Match",
+			"W/" + etag,
+			http.StatusNotModified,
+		},
+		"star": {
+			"If-None-Match",
+			"*",
+			http.StatusNotModified,
+		},
+		"different etag": {
+			"If-None-Match",
+			`"other"`,
+			http.StatusOK,
+		},
+		"no last modified": {
+			"If-Modified-Since",
+			time.Now().UTC().Format(http.TimeFormat),
+			http.StatusOK,
+		},
+	}
+
+	for name, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/query/2011?cols=QS101EW0001", nil)
+		req.Header.Set(test.header, test.value)
+		rec := httptest.NewRecorder()
+		svr.respond(rec, req, mimeCSV, func() ([]byte, error) {
+			return nil, errors.New("not cached")
+		})
+		if rec.Code != test.wantCode {
+			t.Errorf("%s: status %d, want %d", name, rec.Code, test.wantCode)
+		}
+		if rec.Header().Get("ETag") != etag {
+			t.Errorf("%s: ETag %q, want %q", name, rec.Header().Get("ETag"), etag)
+		}
+		if test.wantCode == http.StatusNotModified && rec.Body.Len() != 0 {
+			t.Errorf("%s: 304 has body %q", name, rec.Body.String())
+		}
+	}
+}
+
+func Test_notModified(t *testing.T) {
+	modified := time.Date(2022, 3, 4, 5, 6, 7, 500, time.UTC)
+	value := cache.NewValue([]byte("body"), modified)
+
+	var tests = map[string]struct {
+		headers map[string]string
+		want    bool
+	}{
+		"no conditions": {
+			nil,
+			false,
+		},
+		"modified since": {
+			map[string]string{"If-Modified-Since": modified.Add(-time.Hour).Format(http.TimeFormat)},
+			false,
+		},
+		"not modified since": {
+			map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)},
+			true,
+		},
+		"bad date": {
+			map[string]string{"If-Modified-Since": "yesterday"},
+			false,
+		},
+		"If-None-Match wins": {
+			map[string]string{
+				"If-None-Match":     `"other"`,
+				"If-Modified-Since": modified.Format(http.TimeFormat),
+			},
+			false,
+		},
+	}
+
+	for name, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/query/2011", nil)
+		for k, v := range test.headers {
+			req.Header.Set(k, v)
+		}
+		if got := notModified(req, value); got != test.want {
+			t.Errorf("%s: %t, want %t", name, got, test.want)
+		}
+	}
+}
+
+func Test_cacheControl(t *testing.T) {
+	svr := &Server{
+		maxAge: time.Hour,
+		maxAges: map[string]time.Duration{
+			"metadata": 24 * time.Hour,
+			"geo":      0,
+		},
+	}
+
+	for uri, want := range map[string]string{
+		"/query/2011":    "max-age=3600",
+		"/metadata/2011": "max-age=86400",
+		"/geo/2011":      "no-cache",
+	} {
+		req := httptest.NewRequest(http.MethodGet, uri, nil)
+		if got := svr.cacheControl(req); got != want {
+			t.Errorf("%s: %q, want %q", uri, got, want)
+		}
+	}
+}
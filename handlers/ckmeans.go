@@ -1,53 +1,133 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 
 	"github.com/ONSdigital/dp-geodata-api/api"
+	"github.com/ONSdigital/dp-geodata-api/pkg/geodata"
 	"github.com/ONSdigital/dp-geodata-api/sentinel"
 )
 
 func (svr *Server) GetCkmeansYear(w http.ResponseWriter, r *http.Request, year int, params api.GetCkmeansYearParams) {
-	if !svr.assertAuthorized(w, r) || !svr.assertDatabaseEnabled(w, r) {
+	if !svr.assertAuthorized(w, r, scopeCkmeans) || !svr.assertDatabaseEnabled(w, r) {
 		return
 	}
 
 	generate := func() ([]byte, error) {
-		var cat, geotype []string
-		var divideBy string
-		var k int
-		if params.Cat != nil {
-			cat = *params.Cat
+		args, err := ckmeansArgs(params.Cat, params.Geotype, params.K, params.DivideBy, (*string)(params.Method))
+		if err != nil {
+			return nil, err
 		}
-		if params.Geotype != nil {
-			geotype = *params.Geotype
+		var detail bool
+		if params.Detail != nil {
+			detail = *params.Detail
 		}
-		if params.K != nil {
-			k = *params.K
+
+		ctx := r.Context()
+		area := ckmeansArea(params.Rows, params.Bbox, params.Location, params.Radius, params.Polygon)
+		err = svr.chargeEstimate(r, func(ctx context.Context) (int, error) {
+			return svr.querygeodata.CKmeansCost(ctx, year, args.cat, args.geotype, args.k, args.divideBy, args.method, area)
+		})
+		if err != nil {
+			return nil, err
 		}
-		if params.DivideBy != nil {
-			divideBy = *params.DivideBy
+		breaks, err := svr.querygeodata.CKmeans(ctx, year, args.cat, args.geotype, args.k, args.divideBy, args.method, detail, area)
+		if err != nil {
+			return nil, err
 		}
-		if cat == nil || geotype == nil || k == 0 {
-			return nil, fmt.Errorf("%w: cat, geotype and k required", sentinel.ErrMissingParams)
+		return toJSON(breaks)
+	}
+
+	svr.respond(w, r, mimeJSON, generate)
+}
+
+func (svr *Server) GetCkmeansYearClasses(w http.ResponseWriter, r *http.Request, year int, params api.GetCkmeansYearClassesParams) {
+	if !svr.assertAuthorized(w, r, scopeCkmeans) || !svr.assertDatabaseEnabled(w, r) {
+		return
+	}
+
+	generate := func() ([]byte, error) {
+		args, err := ckmeansArgs(params.Cat, params.Geotype, params.K, params.DivideBy, (*string)(params.Method))
+		if err != nil {
+			return nil, err
 		}
 
 		ctx := r.Context()
-		breaks, err := svr.querygeodata.CKmeans(ctx, year, cat, geotype, k, divideBy)
+		area := ckmeansArea(params.Rows, params.Bbox, params.Location, params.Radius, params.Polygon)
+		err = svr.chargeEstimate(r, func(ctx context.Context) (int, error) {
+			return svr.querygeodata.CKmeansClassesCost(ctx, year, args.cat, args.geotype, args.k, args.divideBy, args.method, area)
+		})
 		if err != nil {
 			return nil, err
 		}
-		return toJSON(breaks)
+		return svr.querygeodata.CKmeansClasses(ctx, year, args.cat, args.geotype, args.k, args.divideBy, args.method, area)
 	}
 
-	svr.respond(w, r, mimeJSON, generate)
+	svr.respond(w, r, mimeCSV, generate)
+}
+
+// ckmeansQuery holds the query parameters shared by /ckmeans and /ckmeans/classes.
+type ckmeansQuery struct {
+	cat      []string
+	geotype  []string
+	k        int
+	divideBy string
+	method   string
+}
+
+// ckmeansArgs checks the required ckmeans parameters are present, and fills in the
+// optional ones.
+func ckmeansArgs(cat, geotype *[]string, k *int, divideBy, method *string) (ckmeansQuery, error) {
+	var args ckmeansQuery
+	if cat != nil {
+		args.cat = *cat
+	}
+	if geotype != nil {
+		args.geotype = *geotype
+	}
+	if k != nil {
+		args.k = *k
+	}
+	if divideBy != nil {
+		args.divideBy = *divideBy
+	}
+	if method != nil {
+		args.method = *method
+	}
+	if args.cat == nil || args.geotype == nil || args.k == 0 {
+		return args, fmt.Errorf("%w: cat, geotype and k required", sentinel.ErrMissingParams)
+	}
+	return args, nil
+}
+
+// ckmeansArea fills in the optional spatial selectors shared by /ckmeans and
+// /ckmeans/classes.
+func ckmeansArea(rows *[]string, bbox, location *string, radius *int, polygon *string) geodata.AreaArgs {
+	var area geodata.AreaArgs
+	if rows != nil {
+		area.Geos = *rows
+	}
+	if bbox != nil {
+		area.BBox = *bbox
+	}
+	if location != nil {
+		area.Location = *location
+	}
+	if radius != nil {
+		area.Radius = *radius
+	}
+	if polygon != nil {
+		area.Polygon = *polygon
+	}
+	return area
 }
 
 // !!!! DEPRECATED CKMEANSRATIO TO BE REMOVED WHEN FRONT END REMOVES DEPENDENCY ON IT !!!!
 //
 func (svr *Server) GetCkmeansratioYear(w http.ResponseWriter, r *http.Request, year int, params api.GetCkmeansratioYearParams) {
-	if !svr.assertAuthorized(w, r) || !svr.assertDatabaseEnabled(w, r) {
+	if !svr.assertAuthorized(w, r, scopeCkmeans) || !svr.assertDatabaseEnabled(w, r) {
 		return
 	}
 
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ONSdigital/dp-geodata-api/api"
+	"github.com/ONSdigital/dp-geodata-api/pkg/geodata"
+)
+
+func (svr *Server) GetCompare(w http.ResponseWriter, r *http.Request, from int, to int, params api.GetCompareParams) {
+	if !svr.assertAuthorized(w, r, scopeCompare) || !svr.assertDatabaseEnabled(w, r) {
+		return
+	}
+
+	generate := func() ([]byte, error) {
+		args := geodata.CompareArgs{
+			From: from,
+			To:   to,
+			Geos: params.Rows,
+			Cols: params.Cols,
+		}
+		if params.Geotype != nil {
+			args.Geotypes = *params.Geotype
+		}
+
+		// each row holds one metric from each year, so it costs the same as a query of
+		// the To year
+		costArgs := geodata.CensusQuerySQLArgs{
+			Year:     to,
+			Geos:     args.Geos,
+			Geotypes: args.Geotypes,
+			Cols:     args.Cols,
+		}
+		if err := svr.charge(r, costArgs, svr.querygeodata.MaxMetrics()); err != nil {
+			return nil, err
+		}
+
+		return svr.querygeodata.Compare(r.Context(), args)
+	}
+
+	svr.respond(w, r, mimeCSV, generate)
+}
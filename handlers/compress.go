@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/ONSdigital/dp-geodata-api/cache"
+	"github.com/andybalholm/brotli"
+)
+
+// content codings we can produce
+const (
+	encodingIdentity = ""
+	encodingBrotli   = "br"
+	encodingGzip     = "gzip"
+)
+
+// encodings are the content codings we can produce, most preferred first.
+var encodings = []string{encodingBrotli, encodingGzip}
+
+// An encoder compresses what is written to it.
+// Flush writes out everything compressed so far, at some cost in compression.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// newEncoder returns a writer which compresses to w using encoding.
+// The writer must be closed to flush the compressed stream.
+func newEncoder(w io.Writer, encoding string) (encoder, error) {
+	switch encoding {
+	case encodingBrotli:
+		return brotli.NewWriter(w), nil
+	case encodingGzip:
+		return gzip.NewWriter(w), nil
+	}
+	return nil, fmt.Errorf("unsupported content coding %q", encoding)
+}
+
+// compressValue returns a copy of value with its body compressed using encoding.
+// The copy has its own ETag, since it is a different representation.
+func compressValue(value *cache.Value, encoding string) (*cache.Value, error) {
+	var buf bytes.Buffer
+	enc, err := newEncoder(&buf, encoding)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := enc.Write(value.Body); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return cache.NewValue(buf.Bytes(), value.LastModified), nil
+}
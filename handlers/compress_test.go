@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-geodata-api/cache"
+	"github.com/andybalholm/brotli"
+)
+
+func decode(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+	var r io.Reader
+	switch encoding {
+	case encodingBrotli:
+		r = brotli.NewReader(bytes.NewReader(body))
+	case encodingGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = zr
+	default:
+		return string(body)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func Test_respond_Compressed(t *testing.T) {
+	cm, err := cache.New(time.Minute, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svr := &Server{cm: cm}
+
+	body := strings.Repeat("a,b\n1,2\n", 100)
+	var generated int
+	generate := func() ([]byte, error) {
+		generated++
+		return []byte(body), nil
+	}
+
+	etags := map[string]bool{}
+	for _, encoding := range []string{encodingGzip, encodingBrotli, encodingIdentity, encodingGzip} {
+		req := httptest.NewRequest(http.MethodGet, "/query/2011", nil)
+		if encoding != encodingIdentity {
+			req.Header.Set("Accept-Encoding", encoding)
+		}
+		rec := httptest.NewRecorder()
+		svr.respond(rec, req, mimeCSV, generate)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%q: status %d", encoding, rec.Code)
+		}
+		if ce := rec.Header().Get("Content-Encoding"); ce != encoding {
+			t.Errorf("%q: Content-Encoding %q", encoding, ce)
+		}
+		if got := decode(t, encoding, rec.Body.Bytes()); got != body {
+			t.Errorf("%q: body does not round trip", encoding)
+		}
+		etags[rec.Header().Get("ETag")] = true
+	}
+
+	if generated != 1 {
+		t.Errorf("generated %d times, want 1", generated)
+	}
+	if len(etags) != 3 {
+		t.Errorf("got %d distinct ETags, want one per encoding", len(etags))
+	}
+}
+
+func Test_respondStream_Flush(t *testing.T) {
+	svr := newStreamServer(t, 10000)
+	row := "a,b\n1,2\n"
+
+	for _, encoding := range []string{encodingIdentity, encodingGzip, encodingBrotli} {
+		req := httptest.NewRequest(http.MethodGet, "/query/2011?stream=true", nil)
+		req.Header.Set("Accept-Encoding", encoding)
+		req.Header.Set("Cache-Control", "no-cache")
+
+		rec := httptest.NewRecorder()
+		svr.respondStream(rec, req, mimeCSV, func(w io.Writer) error {
+			io.WriteString(w, row)
+			if err := w.(interface{ Flush() error }).Flush(); err != nil {
+				return err
+			}
+			if !rec.Flushed {
+				t.Errorf("%q: response not flushed", encoding)
+			}
+			// everything written so far must have reached the client
+			if got := decodePartial(t, encoding, rec.Body.Bytes()); got != row {
+				t.Errorf("%q: flushed %q, want %q", encoding, got, row)
+			}
+			_, err := io.WriteString(w, row)
+			return err
+		})
+		if got := decode(t, encoding, rec.Body.Bytes()); got != row+row {
+			t.Errorf("%q: body %q, want %q", encoding, got, row+row)
+		}
+	}
+}
+
+// decodePartial is like decode, but body may be the start of an unfinished stream.
+func decodePartial(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+	var r io.Reader
+	switch encoding {
+	case encodingBrotli:
+		r = brotli.NewReader(bytes.NewReader(body))
+	case encodingGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = zr
+	default:
+		return string(body)
+	}
+	var out bytes.Buffer
+	io.Copy(&out, r) // ends with io.ErrUnexpectedEOF
+	return out.String()
+}
+
+func Test_respondStream_Compressed(t *testing.T) {
+	svr := newStreamServer(t, 10000)
+	body := strings.Repeat("a,b\n1,2\n", 100)
+
+	for _, encoding := range []string{encodingGzip, encodingBrotli} {
+		req := httptest.NewRequest(http.MethodGet, "/query/2011?stream=true", nil)
+		req.Header.Set("Accept-Encoding", encoding)
+
+		rec := httptest.NewRecorder()
+		svr.respondStream(rec, req, mimeCSV, func(w io.Writer) error {
+			_, err := io.WriteString(w, body)
+			return err
+		})
+		if ce := rec.Header().Get("Content-Encoding"); ce != encoding {
+			t.Errorf("%q: Content-Encoding %q", encoding, ce)
+		}
+		if got := decode(t, encoding, rec.Body.Bytes()); got != body {
+			t.Errorf("%q: body does not round trip", encoding)
+		}
+
+		// the compressed copy is cached
+		rec = httptest.NewRecorder()
+		svr.respondStream(rec, req, mimeCSV, func(io.Writer) error {
+			return errors.New("not cached")
+		})
+		if rec.Code != http.StatusOK {
+			t.Errorf("%q: second request not cached", encoding)
+			continue
+		}
+		if got := decode(t, encoding, rec.Body.Bytes()); got != body {
+			t.Errorf("%q: cached body does not round trip", encoding)
+		}
+	}
+}
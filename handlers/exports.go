@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/ONSdigital/dp-geodata-api/api"
+	"github.com/ONSdigital/dp-geodata-api/pkg/exports"
+	"github.com/ONSdigital/dp-geodata-api/pkg/geodata"
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// maxExportRequest is the largest export request body we will read.
+// Requests list geocodes and categories, so this is generous.
+const maxExportRequest = 1 << 20
+
+func (svr *Server) PostExports(w http.ResponseWriter, r *http.Request) {
+	if !svr.assertAuthorized(w, r, scopeExports) || !svr.assertDatabaseEnabled(w, r) || !svr.assertExportsEnabled(w, r) {
+		return
+	}
+
+	ctx := r.Context()
+
+	var req api.ExportRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxExportRequest))
+	dec.DisallowUnknownFields() // so a misspelt parameter isn't quietly ignored
+	if err := dec.Decode(&req); err != nil {
+		sendError(ctx, w, http.StatusBadRequest, fmt.Sprintf("%s: %s", sentinel.ErrInvalidParams, err))
+		return
+	}
+	args := exportArgs(req)
+
+	// catch bad parameters now, rather than in a failed job
+	if _, _, _, err := geodata.CensusQuerySQL(ctx, args); err != nil {
+		sendError(ctx, w, errorCode(err), err.Error())
+		return
+	}
+
+	// exports are not subject to MAX_METRICS
+	if err := svr.charge(r, args, 0); err != nil {
+		setRetryAfter(w, err)
+		sendError(ctx, w, errorCode(err), err.Error())
+		return
+	}
+
+	job, err := svr.exports.Submit(ctx, args)
+	if err != nil {
+		sendError(ctx, w, errorCode(err), err.Error())
+		return
+	}
+
+	body, err := toJSON(svr.exportResponse(job))
+	if err != nil {
+		sendError(ctx, w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if svr.doCors {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	}
+	w.Header().Set("Location", svr.exportURL(job.ID))
+	w.Header().Set("Content-Type", mimeJSON)
+	w.WriteHeader(http.StatusAccepted)
+	w.Write(body)
+}
+
+func (svr *Server) GetExport(w http.ResponseWriter, r *http.Request, id string, params api.GetExportParams) {
+	if !svr.assertAuthorized(w, r, scopeExports) || !svr.assertExportsEnabled(w, r) {
+		return
+	}
+
+	ctx := r.Context()
+
+	if svr.doCors {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	}
+
+	if params.Download == nil || !*params.Download {
+		job, err := svr.exports.Get(ctx, id)
+		if err != nil {
+			sendError(ctx, w, errorCode(err), err.Error())
+			return
+		}
+		body, err := toJSON(svr.exportResponse(job))
+		if err != nil {
+			sendError(ctx, w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", mimeJSON)
+		w.Write(body)
+		return
+	}
+
+	job, rc, err := svr.exports.Open(ctx, id)
+	if err != nil {
+		sendError(ctx, w, errorCode(err), err.Error())
+		return
+	}
+	defer rc.Close()
+
+	w.Header().Set("Content-Type", mimeCSV)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "export-"+job.ID+".csv"))
+	if _, err := io.Copy(w, rc); err != nil {
+		log.Error(ctx, "export download", err, log.Data{"id": id})
+	}
+}
+
+// assertExportsEnabled sends an error to the client if exports are not enabled.
+// Returns true if exports are enabled.
+func (svr *Server) assertExportsEnabled(w http.ResponseWriter, req *http.Request) bool {
+	if svr.exports != nil {
+		return true
+	}
+	sendError(req.Context(), w, http.StatusNotImplemented, "exports not enabled")
+	return false
+}
+
+func (svr *Server) exportURL(id string) string {
+	return svr.baseURL + "/exports/" + id
+}
+
+// exportArgs converts an export request body to census query arguments.
+func exportArgs(req api.ExportRequest) geodata.CensusQuerySQLArgs {
+	args := geodata.CensusQuerySQLArgs{
+		Year: req.Year,
+	}
+	if req.Rows != nil {
+		args.Geos = *req.Rows
+	}
+	if req.Cols != nil {
+		args.Cols = *req.Cols
+	}
+	if req.Bbox != nil {
+		args.BBox = *req.Bbox
+	}
+	if req.Geotype != nil {
+		args.Geotypes = *req.Geotype
+	}
+	if req.Location != nil {
+		args.Location = *req.Location
+	}
+	if req.Radius != nil {
+		args.Radius = *req.Radius
+	}
+	if req.Polygon != nil {
+		args.Polygon = *req.Polygon
+	}
+	if req.Censustable != nil {
+		args.Censustable = *req.Censustable
+	}
+	if req.DivideBy != nil {
+		args.DivideBy = *req.DivideBy
+	}
+	return args
+}
+
+// exportResponse converts a job to the response sent to clients.
+func (svr *Server) exportResponse(job *exports.Job) api.Export {
+	resp := api.Export{
+		Id:      job.ID,
+		Status:  api.ExportStatus(job.Status),
+		Created: job.Created,
+		Updated: job.Updated,
+		Request: api.ExportRequest{
+			Year: job.Args.Year,
+		},
+	}
+
+	req := &resp.Request
+	if job.Args.Geos != nil {
+		req.Rows = &job.Args.Geos
+	}
+	if job.Args.Cols != nil {
+		req.Cols = &job.Args.Cols
+	}
+	if job.Args.BBox != "" {
+		req.Bbox = &job.Args.BBox
+	}
+	if job.Args.Geotypes != nil {
+		req.Geotype = &job.Args.Geotypes
+	}
+	if job.Args.Location != "" {
+		req.Location = &job.Args.Location
+	}
+	if job.Args.Radius != 0 {
+		req.Radius = &job.Args.Radius
+	}
+	if job.Args.Polygon != "" {
+		req.Polygon = &job.Args.Polygon
+	}
+	if job.Args.Censustable != "" {
+		req.Censustable = &job.Args.Censustable
+	}
+	if job.Args.DivideBy != "" {
+		req.DivideBy = &job.Args.DivideBy
+	}
+
+	if job.Error != "" {
+		resp.Error = &job.Error
+	}
+	if job.Status == exports.StatusComplete {
+		download := svr.exportURL(job.ID) + "?download=true"
+		resp.Download = &download
+		resp.Size = &job.Size
+	}
+	return resp
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-geodata-api/api"
+	"github.com/ONSdigital/dp-geodata-api/pkg/exports"
+	"github.com/ONSdigital/dp-geodata-api/pkg/geodata"
+)
+
+func newExportServer(t *testing.T, query exports.QueryFunc) *Server {
+	store, err := exports.NewFSStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex := exports.New(store, query, 1, 0)
+	if err := ex.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(ex.Close)
+
+	return &Server{
+		baseURL:      "http://localhost/v1/geodata",
+		querygeodata: &geodata.Geodata{},
+		exports:      ex,
+	}
+}
+
+func Test_PostExports(t *testing.T) {
+	release := make(chan struct{})
+	svr := newExportServer(t, func(ctx context.Context, w io.Writer, args geodata.CensusQuerySQLArgs) error {
+		<-release
+		_, err := io.WriteString(w, "geography_code\nE01000001\n")
+		return err
+	})
+
+	// bad parameters are rejected up front
+	rec := httptest.NewRecorder()
+	svr.PostExports(rec, httptest.NewRequest("POST", "/exports", strings.NewReader(`{"year": 2011}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("missing conditions: status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	rec = httptest.NewRecorder()
+	svr.PostExports(rec, httptest.NewRequest("POST", "/exports", strings.NewReader(`{"year": 2011, "row": ["ALL"], "censustable": "QS101EW"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown field: status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	rec = httptest.NewRecorder()
+	huge := `{"year": 2011, "geotype": ["` + strings.Repeat("x", maxExportRequest) + `"]}`
+	svr.PostExports(rec, httptest.NewRequest("POST", "/exports", strings.NewReader(huge)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("oversized body: status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	rec = httptest.NewRecorder()
+	body := `{"year": 2011, "rows": ["ALL"], "censustable": "QS101EW"}`
+	svr.PostExports(rec, httptest.NewRequest("POST", "/exports", strings.NewReader(body)))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status %d, want %d: %s", rec.Code, http.StatusAccepted, rec.Body.String())
+	}
+	var job api.Export
+	if err := json.Unmarshal(rec.Body.Bytes(), &job); err != nil {
+		t.Fatal(err)
+	}
+	if loc := rec.Header().Get("Location"); loc != "http://localhost/v1/geodata/exports/"+job.Id {
+		t.Errorf("Location %q", loc)
+	}
+	if job.Request.Censustable == nil || *job.Request.Censustable != "QS101EW" {
+		t.Errorf("request not echoed: %+v", job.Request)
+	}
+
+	// download before complete
+	download := true
+	rec = httptest.NewRecorder()
+	svr.GetExport(rec, httptest.NewRequest("GET", "/exports/"+job.Id+"?download=true", nil), job.Id, api.GetExportParams{Download: &download})
+	if rec.Code != http.StatusConflict {
+		t.Errorf("early download: status %d, want %d", rec.Code, http.StatusConflict)
+	}
+
+	close(release)
+	for i := 0; i < 200 && job.Status != api.ExportStatusComplete; i++ {
+		time.Sleep(10 * time.Millisecond)
+		rec = httptest.NewRecorder()
+		svr.GetExport(rec, httptest.NewRequest("GET", "/exports/"+job.Id, nil), job.Id, api.GetExportParams{})
+		json.Unmarshal(rec.Body.Bytes(), &job)
+	}
+	if job.Status != api.ExportStatusComplete || job.Download == nil {
+		t.Fatalf("export not complete: %+v", job)
+	}
+
+	rec = httptest.NewRecorder()
+	svr.GetExport(rec, httptest.NewRequest("GET", *job.Download, nil), job.Id, api.GetExportParams{Download: &download})
+	if rec.Code != http.StatusOK || rec.Body.String() != "geography_code\nE01000001\n" {
+		t.Errorf("download: status %d, body %q", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != mimeCSV {
+		t.Errorf("download: Content-Type %q", ct)
+	}
+}
+
+func Test_GetExport_NotFound(t *testing.T) {
+	svr := newExportServer(t, nil)
+	rec := httptest.NewRecorder()
+	svr.GetExport(rec, httptest.NewRequest("GET", "/exports/nope", nil), "nope", api.GetExportParams{})
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
@@ -7,7 +7,7 @@ import (
 )
 
 func (svr *Server) GetGeo(w http.ResponseWriter, r *http.Request, year int, params api.GetGeoParams) {
-	if !svr.assertAuthorized(w, r) || !svr.assertDatabaseEnabled(w, r) {
+	if !svr.assertAuthorized(w, r, scopeGeo) || !svr.assertDatabaseEnabled(w, r) {
 		return
 	}
 
@@ -24,8 +24,19 @@ func (svr *Server) GetGeo(w http.ResponseWriter, r *http.Request, year int, para
 		return
 	}
 
+	var langParam *string
+	if params.Lang != nil {
+		s := string(*params.Lang)
+		langParam = &s
+	}
+	language, err := negotiateLang(w, r, langParam)
+	if err != nil {
+		sendError(r.Context(), w, errorCode(err), err.Error())
+		return
+	}
+
 	generate := func() ([]byte, error) {
-		resp, err := svr.querygeodata.Geo(r.Context(), year, geocode, geoname)
+		resp, err := svr.querygeodata.Geo(r.Context(), year, geocode, geoname, language)
 		if err != nil {
 			return nil, err
 		}
@@ -36,5 +47,42 @@ func (svr *Server) GetGeo(w http.ResponseWriter, r *http.Request, year int, para
 		return []byte(buf), err
 	}
 
+	svr.respond(w, r, mimeJSON, generate, language)
+}
+
+func (svr *Server) GetGeoParents(w http.ResponseWriter, r *http.Request, year int, code string) {
+	if !svr.assertAuthorized(w, r, scopeGeo) || !svr.assertDatabaseEnabled(w, r) {
+		return
+	}
+
+	generate := func() ([]byte, error) {
+		resp, err := svr.querygeodata.Parents(r.Context(), year, code)
+		if err != nil {
+			return nil, err
+		}
+		return toJSON(resp)
+	}
+
+	svr.respond(w, r, mimeJSON, generate)
+}
+
+func (svr *Server) GetGeoChildren(w http.ResponseWriter, r *http.Request, year int, code string, params api.GetGeoChildrenParams) {
+	if !svr.assertAuthorized(w, r, scopeGeo) || !svr.assertDatabaseEnabled(w, r) {
+		return
+	}
+
+	var geotype string
+	if params.Geotype != nil {
+		geotype = *params.Geotype
+	}
+
+	generate := func() ([]byte, error) {
+		resp, err := svr.querygeodata.Children(r.Context(), year, code, geotype)
+		if err != nil {
+			return nil, err
+		}
+		return toJSON(resp)
+	}
+
 	svr.respond(w, r, mimeJSON, generate)
 }
@@ -14,7 +14,7 @@ import (
 )
 
 func (svr *Server) GetQuery(w http.ResponseWriter, r *http.Request, year int, params api.GetQueryParams) {
-	if !svr.assertAuthorized(w, r) || !svr.assertDatabaseEnabled(w, r) {
+	if !svr.assertAuthorized(w, r, scopeQuery) || !svr.assertDatabaseEnabled(w, r) {
 		return
 	}
 
@@ -25,6 +25,9 @@ func (svr *Server) GetQuery(w http.ResponseWriter, r *http.Request, year int, pa
 		var location string
 		var radius int
 		var polygon string
+		var near string
+		var k int
+		var adjacent string
 		if params.Rows != nil {
 			rows = *params.Rows
 		}
@@ -43,8 +46,17 @@ func (svr *Server) GetQuery(w http.ResponseWriter, r *http.Request, year int, pa
 		if params.Polygon != nil {
 			polygon = *params.Polygon
 		}
+		if params.Near != nil {
+			near = *params.Near
+		}
+		if params.K != nil {
+			k = *params.K
+		}
+		if params.Adjacent != nil {
+			adjacent = *params.Adjacent
+		}
 
-		geocodes, err := svr.querygeodata.Query2(r.Context(), year, bbox, location, radius, polygon, geotype, rows)
+		geocodes, err := svr.querygeodata.Query2(r.Context(), year, bbox, location, radius, polygon, near, k, adjacent, geotype, rows)
 		if err != nil {
 			return nil, err
 		}
@@ -58,6 +70,18 @@ func (svr *Server) GetQuery(w http.ResponseWriter, r *http.Request, year int, pa
 			censustable = *params.Censustable
 		}
 
+		// charged once the areas are known, since near, k and adjacent cannot be
+		// estimated beforehand
+		costArgs := geodata.CensusQuerySQLArgs{
+			Year:        year,
+			Geos:        geocodes,
+			Cols:        cols,
+			Censustable: censustable,
+		}
+		if err := svr.charge(r, costArgs, svr.querygeodata.MaxMetrics()); err != nil {
+			return nil, err
+		}
+
 		// parse cols query strings into a ValueSet
 		catset, err := where.ParseMultiArgs(cols)
 		if err != nil {
@@ -3,20 +3,80 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/ONSdigital/dp-geodata-api/api"
 	"github.com/ONSdigital/dp-geodata-api/cache"
 	"github.com/ONSdigital/dp-geodata-api/metadata"
+	"github.com/ONSdigital/dp-geodata-api/pkg/apikey"
+	"github.com/ONSdigital/dp-geodata-api/pkg/exports"
 	"github.com/ONSdigital/dp-geodata-api/pkg/geodata"
+	"github.com/ONSdigital/dp-geodata-api/pkg/ratelimit"
+	"github.com/ONSdigital/dp-geodata-api/pkg/table"
 	"github.com/ONSdigital/dp-geodata-api/postcode"
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
 	Swagger "github.com/ONSdigital/dp-geodata-api/swagger"
 	"github.com/ONSdigital/log.go/v2/log"
 	"github.com/getkin/kin-openapi/openapi3"
 )
 
+// API key scopes needed by each endpoint
+const (
+	scopeMetadata        = "metadata"
+	scopeQuery           = "query"
+	scopeGeo             = "geo"
+	scopeSearch          = "search"
+	scopeCompare         = "compare"
+	scopeAggregate       = "aggregate"
+	scopeCkmeans         = "ckmeans"
+	scopeStats           = "stats"
+	scopeExports         = "exports"
+	scopeClearCache      = "admin/clear-cache"
+	scopeCacheStats      = "admin/cache-stats"
+	scopeCacheInvalidate = "admin/cache-invalidate"
+)
+
+// internalAPIKey authorizes requests the service makes to itself, such as cache
+// warming, whatever API keys are configured.
+// It has no admin scopes, so a warm file cannot clear the cache.
+var internalAPIKey = apikey.Key{
+	Name:    "internal",
+	Scopes:  []string{scopeMetadata, scopeQuery, scopeGeo, scopeSearch, scopeCompare, scopeAggregate, scopeCkmeans, scopeStats, scopeExports},
+	Enabled: true,
+}
+
+// internalContextKey marks the context of requests made with Internal.
+type internalContextKey struct{}
+
+// Internal returns a context for requests the service makes to itself in-process.
+// They are authorized with internalAPIKey rather than an Authorization header, and are
+// not rate limited.
+// Clients cannot set it, since it never crosses the network.
+func Internal(ctx context.Context) context.Context {
+	return context.WithValue(ctx, internalContextKey{}, true)
+}
+
+// isInternal is true if ctx comes from Internal.
+func isInternal(ctx context.Context) bool {
+	internal, _ := ctx.Value(internalContextKey{}).(bool)
+	return internal
+}
+
+// keyContextKey holds the name of the API key a request was authorized with.
+type keyContextKey struct{}
+
+// keyName returns the name of the API key ctx's request was authorized with, or "" if
+// it was not checked.
+func keyName(ctx context.Context) string {
+	name, _ := ctx.Value(keyContextKey{}).(string)
+	return name
+}
+
 type Server struct {
-	apiToken         string
+	keys             apikey.Store
 	bindAddr         string
 	baseURL          string
 	doCors           bool
@@ -25,12 +85,18 @@ type Server struct {
 	querygeodata     *geodata.Geodata // if nil, database not available
 	md               *metadata.Metadata
 	cm               *cache.Manager
+	streamCacheLimit int                      // largest streamed response to cache, in bytes
+	maxAge           time.Duration            // default Cache-Control max-age
+	maxAges          map[string]time.Duration // max-age by endpoint, eg "query"
+	limiter          *ratelimit.Limiter       // if nil, queries are not rate limited
+	trustedProxies   int                      // number of proxies whose X-Forwarded-For entries are trusted
 	pc               *postcode.Postcode
+	exports          *exports.Manager // if nil, exports not enabled
 }
 
-func New(apiToken, bindAddr, baseURL string, enableHeaderAuth, doCors, private bool, querygeodata *geodata.Geodata, md *metadata.Metadata, cm *cache.Manager, pc *postcode.Postcode) *Server {
+func New(keys apikey.Store, bindAddr, baseURL string, enableHeaderAuth, doCors, private bool, querygeodata *geodata.Geodata, md *metadata.Metadata, cm *cache.Manager, streamCacheLimit int, maxAge time.Duration, maxAges map[string]time.Duration, limiter *ratelimit.Limiter, trustedProxies int, pc *postcode.Postcode, ex *exports.Manager) *Server {
 	return &Server{
-		apiToken:         apiToken,
+		keys:             keys,
 		bindAddr:         bindAddr,
 		baseURL:          baseURL,
 		doCors:           doCors,
@@ -39,7 +105,13 @@ func New(apiToken, bindAddr, baseURL string, enableHeaderAuth, doCors, private b
 		querygeodata:     querygeodata,
 		md:               md,
 		cm:               cm,
+		streamCacheLimit: streamCacheLimit,
+		maxAge:           maxAge,
+		maxAges:          maxAges,
+		limiter:          limiter,
+		trustedProxies:   trustedProxies,
 		pc:               pc,
+		exports:          ex,
 	}
 }
 
@@ -74,7 +146,18 @@ func (svr *Server) GetSwaggerui(w http.ResponseWriter, r *http.Request) {
 }
 
 func (svr *Server) GetMetadataYear(w http.ResponseWriter, r *http.Request, year int, params api.GetMetadataYearParams) {
-	if !svr.assertAuthorized(w, r) || !svr.assertDatabaseEnabled(w, r) {
+	if !svr.assertAuthorized(w, r, scopeMetadata) || !svr.assertDatabaseEnabled(w, r) {
+		return
+	}
+
+	var langParam *string
+	if params.Lang != nil {
+		s := string(*params.Lang)
+		langParam = &s
+	}
+	language, err := negotiateLang(w, r, langParam)
+	if err != nil {
+		sendError(r.Context(), w, errorCode(err), err.Error())
 		return
 	}
 
@@ -86,10 +169,10 @@ func (svr *Server) GetMetadataYear(w http.ResponseWriter, r *http.Request, year
 			filtertotals = false
 		}
 
-		return svr.md.Get(r.Context(), year, filtertotals)
+		return svr.md.Get(r.Context(), year, filtertotals, language)
 	}
 
-	svr.respond(w, r, mimeCSV, generate)
+	svr.respond(w, r, mimeCSV, generate, language)
 }
 
 func (svr *Server) GetMsoaPostcode(w http.ResponseWriter, r *http.Request, pc string) {
@@ -107,58 +190,113 @@ func (svr *Server) GetMsoaPostcode(w http.ResponseWriter, r *http.Request, pc st
 }
 
 func (svr *Server) GetQueryYear(w http.ResponseWriter, r *http.Request, year int, params api.GetQueryYearParams) {
-	if !svr.assertAuthorized(w, r) || !svr.assertDatabaseEnabled(w, r) {
+	if !svr.assertAuthorized(w, r, scopeQuery) || !svr.assertDatabaseEnabled(w, r) {
 		return
 	}
 
-	generate := func() ([]byte, error) {
-		var rows []string
-		var cols []string
-		var bbox string
-		var geotype []string
-		var location string
-		var radius int
-		var polygon string
-		var censustable string
-		var divideby string
-		if params.Rows != nil {
-			rows = *params.Rows
-		}
-		if params.Cols != nil {
-			cols = *params.Cols
-		}
-		if params.Bbox != nil {
-			bbox = *params.Bbox
-		}
-		if params.Geotype != nil {
-			geotype = *params.Geotype
-		}
-		if params.Location != nil {
-			location = *params.Location
-		}
-		if params.Radius != nil {
-			radius = *params.Radius
-		}
-		if params.Polygon != nil {
-			polygon = *params.Polygon
-		}
-		if params.Censustable != nil {
-			censustable = *params.Censustable
+	var fmtParam *string
+	if params.Format != nil {
+		s := string(*params.Format)
+		fmtParam = &s
+	}
+	format, contentType, err := negotiateFormat(w, r, fmtParam)
+	if err != nil {
+		sendError(r.Context(), w, errorCode(err), err.Error())
+		return
+	}
+
+	var langParam *string
+	if params.Lang != nil {
+		s := string(*params.Lang)
+		langParam = &s
+	}
+	language, err := negotiateLang(w, r, langParam)
+	if err != nil {
+		sendError(r.Context(), w, errorCode(err), err.Error())
+		return
+	}
+
+	var rows []string
+	var cols []string
+	var bbox string
+	var geotype []string
+	var location string
+	var radius int
+	var polygon string
+	var censustable string
+	var divideby string
+	if params.Rows != nil {
+		rows = *params.Rows
+	}
+	if params.Cols != nil {
+		cols = *params.Cols
+	}
+	if params.Bbox != nil {
+		bbox = *params.Bbox
+	}
+	if params.Geotype != nil {
+		geotype = *params.Geotype
+	}
+	if params.Location != nil {
+		location = *params.Location
+	}
+	if params.Radius != nil {
+		radius = *params.Radius
+	}
+	if params.Polygon != nil {
+		polygon = *params.Polygon
+	}
+	if params.Censustable != nil {
+		censustable = *params.Censustable
+	}
+	if params.DivideBy != nil {
+		divideby = *params.DivideBy
+	}
+
+	ctx := r.Context()
+
+	args := geodata.CensusQuerySQLArgs{
+		Year:        year,
+		Geos:        rows,
+		BBox:        bbox,
+		Location:    location,
+		Radius:      radius,
+		Polygon:     polygon,
+		Geotypes:    geotype,
+		Cols:        cols,
+		Censustable: censustable,
+		DivideBy:    divideby,
+		Lang:        language,
+	}
+
+	if params.Stream != nil && *params.Stream {
+		if format != table.FormatCSV {
+			sendError(ctx, w, http.StatusBadRequest, "stream is only supported for csv output")
+			return
 		}
-		if params.DivideBy != nil {
-			divideby = *params.DivideBy
+		stream := func(w io.Writer) error {
+			// streams are not subject to MAX_METRICS, only to the rate limit
+			if err := svr.charge(r, args, 0); err != nil {
+				return err
+			}
+			return svr.querygeodata.StreamQuery(ctx, w, year, bbox, location, radius, polygon, geotype, rows, cols, censustable, divideby, language)
 		}
+		svr.respondStream(w, r, contentType, stream, language)
+		return
+	}
 
-		ctx := r.Context()
-		csv, err := svr.querygeodata.Query(ctx, year, bbox, location, radius, polygon, geotype, rows, cols, censustable, divideby)
-		return []byte(csv), err
+	generate := func() ([]byte, error) {
+		if err := svr.charge(r, args, svr.querygeodata.MaxMetrics()); err != nil {
+			return nil, err
+		}
+		return svr.querygeodata.Query(ctx, year, bbox, location, radius, polygon, geotype, rows, cols, censustable, divideby, language, format)
 	}
 
-	svr.respond(w, r, mimeCSV, generate)
+	svr.respond(w, r, contentType, generate, language)
 }
 
 func (svr *Server) GetClearCache(w http.ResponseWriter, r *http.Request) {
-	if !svr.assertPrivate(w, r) || !svr.assertAuthorized(w, r) {
+	if !svr.assertPrivate(w, r) || !svr.assertAuthorized(w, r, scopeClearCache) {
 		return
 	}
 
@@ -170,6 +308,56 @@ func (svr *Server) GetClearCache(w http.ResponseWriter, r *http.Request) {
 	sendError(ctx, w, http.StatusInternalServerError, "problem clearing cache", log.Data{"error": err.Error()})
 }
 
+func (svr *Server) GetCacheStats(w http.ResponseWriter, r *http.Request) {
+	if !svr.assertPrivate(w, r) || !svr.assertAuthorized(w, r, scopeCacheStats) {
+		return
+	}
+
+	body, err := toJSON(svr.cm.Stats())
+	if err != nil {
+		sendError(r.Context(), w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", mimeJSON)
+	w.Write(body)
+}
+
+func (svr *Server) PostCacheInvalidate(w http.ResponseWriter, r *http.Request, params api.PostCacheInvalidateParams) {
+	if !svr.assertPrivate(w, r) || !svr.assertAuthorized(w, r, scopeCacheInvalidate) {
+		return
+	}
+
+	ctx := r.Context()
+
+	var tags []string
+	if params.Tag != nil {
+		tags = *params.Tag
+	}
+	var prefix string
+	if params.Prefix != nil {
+		prefix = *params.Prefix
+	}
+	if len(tags) == 0 && prefix == "" {
+		sendError(ctx, w, http.StatusBadRequest, "tag or prefix required")
+		return
+	}
+
+	keys, err := svr.cm.Invalidate(ctx, tags, prefix)
+	if err != nil {
+		sendError(ctx, w, http.StatusInternalServerError, "problem invalidating cache", log.Data{"error": err.Error()})
+		return
+	}
+	log.Info(ctx, "cache invalidated", log.Data{"tags": tags, "prefix": prefix, "removed": len(keys)})
+
+	body, err := toJSON(map[string]int{"removed": len(keys)})
+	if err != nil {
+		sendError(ctx, w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", mimeJSON)
+	w.Write(body)
+}
+
 func (svr *Server) Preflight(w http.ResponseWriter, r *http.Request, path string, year int) {
 	if svr.doCors {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -188,29 +376,55 @@ func (svr *Server) assertPrivate(w http.ResponseWriter, r *http.Request) bool {
 	return false
 }
 
-// assertAuthorized send an error to the client if they are not authorized.
+// assertAuthorized sends an error to the client unless they presented an API key
+// granting scope.
+// Keys are sent as "Authorization: Bearer <key>", or as the bare key.
+// Each authorized request is logged with the name of its key, which is also kept in
+// req's context, for rate limiting.
 // Returns true if authorized.
-func (svr *Server) assertAuthorized(w http.ResponseWriter, req *http.Request) bool {
+func (svr *Server) assertAuthorized(w http.ResponseWriter, req *http.Request, scope string) bool {
 	// check Auth header
 	if !svr.enableHeaderAuth {
 		return true
 	}
 
-	ahdr := "Authorization"
-	auth := req.Header.Get(ahdr)
-	if auth == svr.apiToken {
-		return true
+	ctx := req.Context()
+	xhdr := "X-Forwarded-For"
+	data := log.Data{"scope": scope, "path": req.URL.Path, xhdr: req.Header.Get(xhdr)}
+
+	key := &internalAPIKey
+	if !isInternal(ctx) {
+		secret := bearer(req)
+		if secret == "" {
+			sendError(ctx, w, http.StatusUnauthorized, "unauthorized", data)
+			return false
+		}
+
+		var err error
+		key, err = svr.keys.Lookup(ctx, apikey.Hash(secret))
+		if errors.Is(err, sentinel.ErrNotFound) {
+			sendError(ctx, w, http.StatusUnauthorized, "unauthorized", data)
+			return false
+		}
+		if err != nil {
+			sendError(ctx, w, http.StatusInternalServerError, "cannot check api key", data, log.Data{"error": err.Error()})
+			return false
+		}
 	}
 
-	xhdr := "X-Forwarded-For"
-	sendError(
-		req.Context(),
-		w,
-		http.StatusUnauthorized,
-		"unauthorized",
-		log.Data{ahdr: auth, xhdr: req.Header.Get(xhdr)},
-	)
-	return false
+	data["key"] = key.Name
+	if err := key.Check(time.Now(), scope); err != nil {
+		code := http.StatusUnauthorized
+		if errors.Is(err, apikey.ErrScope) {
+			code = http.StatusForbidden
+		}
+		sendError(ctx, w, code, err.Error(), data)
+		return false
+	}
+
+	log.Info(ctx, "authorized", data)
+	*req = *req.WithContext(context.WithValue(ctx, keyContextKey{}, key.Name))
+	return true
 }
 
 // assertDatabaseEnabled sends and error to the client if database is not enabled.
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-geodata-api/pkg/apikey"
+)
+
+func Test_assertAuthorized(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	svr := &Server{
+		enableHeaderAuth: true,
+		keys: apikey.NewStatic(
+			apikey.Key{Name: "atlas", Hash: apikey.Hash("atlas-key"), Scopes: []string{"query", "ckmeans"}, Enabled: true},
+			apikey.Key{Name: "ops", Hash: apikey.Hash("ops-key"), Scopes: []string{"admin"}, Enabled: true},
+			apikey.Key{Name: "old", Hash: apikey.Hash("old-key"), Scopes: []string{"*"}, Enabled: true, Expires: &past},
+			apikey.Key{Name: "off", Hash: apikey.Hash("off-key"), Scopes: []string{"*"}},
+		),
+	}
+
+	var tests = map[string]struct {
+		auth     string
+		scope    string
+		wantCode int
+	}{
+		"bearer key": {
+			auth:     "Bearer atlas-key",
+			scope:    scopeQuery,
+			wantCode: http.StatusOK,
+		},
+		"bare key": {
+			auth:     "atlas-key",
+			scope:    scopeCkmeans,
+			wantCode: http.StatusOK,
+		},
+		"admin scope": {
+			auth:     "Bearer ops-key",
+			scope:    scopeClearCache,
+			wantCode: http.StatusOK,
+		},
+		"no header": {
+			scope:    scopeQuery,
+			wantCode: http.StatusUnauthorized,
+		},
+		"unknown key": {
+			auth:     "Bearer nope",
+			scope:    scopeQuery,
+			wantCode: http.StatusUnauthorized,
+		},
+		"wrong scope": {
+			auth:     "Bearer atlas-key",
+			scope:    scopeClearCache,
+			wantCode: http.StatusForbidden,
+		},
+		"expired": {
+			auth:     "Bearer old-key",
+			scope:    scopeQuery,
+			wantCode: http.StatusUnauthorized,
+		},
+		"disabled": {
+			auth:     "Bearer off-key",
+			scope:    scopeQuery,
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+
+	for name, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/query/2011", nil)
+		if test.auth != "" {
+			req.Header.Set("Authorization", test.auth)
+		}
+		rec := httptest.NewRecorder()
+		ok := svr.assertAuthorized(rec, req, test.scope)
+		if ok != (test.wantCode == http.StatusOK) {
+			t.Errorf("%s: authorized %t", name, ok)
+		}
+		if rec.Code != test.wantCode {
+			t.Errorf("%s: status %d, want %d", name, rec.Code, test.wantCode)
+		}
+	}
+
+	// internal requests need no header, but get no admin scopes
+	req := httptest.NewRequest(http.MethodGet, "/query/2011", nil)
+	req = req.WithContext(Internal(req.Context()))
+	if !svr.assertAuthorized(httptest.NewRecorder(), req, scopeQuery) {
+		t.Errorf("internal: not authorized")
+	}
+	rec := httptest.NewRecorder()
+	if svr.assertAuthorized(rec, req, scopeClearCache) || rec.Code != http.StatusForbidden {
+		t.Errorf("internal admin: status %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	// auth disabled
+	svr.enableHeaderAuth = false
+	req = httptest.NewRequest(http.MethodGet, "/query/2011", nil)
+	if !svr.assertAuthorized(httptest.NewRecorder(), req, scopeQuery) {
+		t.Errorf("auth disabled: not authorized")
+	}
+}
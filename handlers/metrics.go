@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/dp-geodata-api/pkg/geodata"
+	"github.com/ONSdigital/dp-geodata-api/pkg/metrics"
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+	Swagger "github.com/ONSdigital/dp-geodata-api/swagger"
+)
+
+var (
+	endpointsOnce sync.Once
+	endpoints     map[string]bool // first path segment of every path in the spec
+)
+
+// Metrics is middleware which records each request's duration and status in
+// Prometheus.
+// It also labels the request's context with its endpoint and geotype, so the timers
+// and row counts inside it are labelled too.
+func Metrics(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		labels := requestLabels(r)
+		sr := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+		h.ServeHTTP(sr, r.WithContext(metrics.WithLabels(r.Context(), labels)))
+		metrics.ObserveRequest(labels.Endpoint, sr.code, time.Since(start))
+	})
+}
+
+// requestLabels returns the metrics labels for r.
+// Only endpoints in the spec and known geotypes are used as label values, so clients
+// cannot create new time series at will.
+func requestLabels(r *http.Request) metrics.Labels {
+	endpointsOnce.Do(func() {
+		endpoints = map[string]bool{}
+		spec, err := Swagger.GetOpenAPISpec()
+		if err != nil {
+			return
+		}
+		for path := range spec.Paths {
+			segment := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0]
+			if !strings.HasPrefix(segment, "{") {
+				endpoints[segment] = true
+			}
+		}
+	})
+
+	labels := metrics.Labels{Endpoint: "other"}
+	if ep := endpoint(r); endpoints[ep] {
+		labels.Endpoint = ep
+	}
+
+	var geotypes []string
+	seen := map[string]bool{}
+	for _, value := range r.URL.Query()["geotype"] {
+		for _, token := range strings.Split(value, ",") {
+			geotype, err := geodata.FixGeotype(token)
+			if err != nil {
+				labels.Geotype = "invalid"
+				return labels
+			}
+			if !seen[geotype] {
+				seen[geotype] = true
+				geotypes = append(geotypes, geotype)
+			}
+		}
+	}
+	sort.Strings(geotypes)
+	labels.Geotype = strings.Join(geotypes, ",")
+	return labels
+}
+
+// countError counts err in Prometheus, by its sentinel error and status code.
+func countError(err error, code int) {
+	name := "other"
+	var s sentinel.Sentinel
+	if errors.As(err, &s) {
+		name = string(s)
+	}
+	metrics.CountError(name, code)
+}
+
+// A statusRecorder remembers the status code sent through it.
+type statusRecorder struct {
+	http.ResponseWriter
+	code        int
+	wroteHeader bool
+}
+
+func (sr *statusRecorder) WriteHeader(code int) {
+	if !sr.wroteHeader {
+		sr.code = code
+		sr.wroteHeader = true
+	}
+	sr.ResponseWriter.WriteHeader(code)
+}
+
+func (sr *statusRecorder) Write(p []byte) (int, error) {
+	sr.wroteHeader = true
+	return sr.ResponseWriter.Write(p)
+}
+
+// Flush lets streamed responses through the recorder.
+func (sr *statusRecorder) Flush() {
+	if f, ok := sr.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
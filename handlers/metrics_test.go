@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-geodata-api/pkg/metrics"
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+func Test_requestLabels(t *testing.T) {
+	var tests = map[string]struct {
+		uri  string
+		want metrics.Labels
+	}{
+		"no geotype": {
+			uri:  "/query/2011?rows=E01000001",
+			want: metrics.Labels{Endpoint: "query"},
+		},
+		"geotypes sorted and deduplicated": {
+			uri:  "/ckmeans/2011?geotype=msoa,lad&geotype=LAD",
+			want: metrics.Labels{Endpoint: "ckmeans", Geotype: "LAD,MSOA"},
+		},
+		"unknown geotype": {
+			uri:  "/query/2011?geotype=nope",
+			want: metrics.Labels{Endpoint: "query", Geotype: "invalid"},
+		},
+		"unknown endpoint": {
+			uri:  "/nope/2011",
+			want: metrics.Labels{Endpoint: "other"},
+		},
+	}
+
+	for desc, test := range tests {
+		got := requestLabels(httptest.NewRequest(http.MethodGet, test.uri, nil))
+		if got != test.want {
+			t.Errorf("%s: got %+v, want %+v", desc, got, test.want)
+		}
+	}
+}
+
+func Test_Metrics(t *testing.T) {
+	var got metrics.Labels
+	h := Metrics(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = metrics.LabelsFrom(r.Context())
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search/2011?geotype=lad", nil))
+
+	if want := (metrics.Labels{Endpoint: "search", Geotype: "LAD"}); got != want {
+		t.Errorf("got labels %+v, want %+v", got, want)
+	}
+	if rec.Code != http.StatusTeapot {
+		t.Errorf("got status %d, want %d", rec.Code, http.StatusTeapot)
+	}
+}
+
+func Test_countError(t *testing.T) {
+	errorCode(fmt.Errorf("%w: bad year", sentinel.ErrInvalidParams))
+	errorCode(errors.New("database is down"))
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{
+		`geodata_errors_total{code="400",error="invalid parameter"}`,
+		`geodata_errors_total{code="500",error="other"}`,
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("metrics do not contain %s", want)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ONSdigital/dp-geodata-api/pkg/lang"
+	"github.com/ONSdigital/dp-geodata-api/pkg/table"
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+// tableMimes maps each table output format to its content-type.
+var tableMimes = map[table.Format]string{
+	table.FormatCSV:     mimeCSV,
+	table.FormatJSON:    mimeJSON,
+	table.FormatGeoJSON: mimeGeoJSON,
+	table.FormatParquet: mimeParquet,
+}
+
+// negotiateFormat picks the output format for a table response.
+// An explicit format query parameter always wins.
+// Otherwise the Accept header is consulted, highest q-value first, and the first
+// media range we support is used.
+// CSV is the default when there is no Accept header, or when it accepts anything.
+// The Vary header notes that the response depends on Accept, so shared caches keep
+// each format separately.
+//
+// Returns the format and its content-type.
+func negotiateFormat(w http.ResponseWriter, r *http.Request, format *string) (table.Format, string, error) {
+	w.Header().Add("Vary", "Accept")
+	return pickFormat(r, format)
+}
+
+func pickFormat(r *http.Request, format *string) (table.Format, string, error) {
+	if format != nil && *format != "" {
+		f, err := table.ParseFormat(*format)
+		if err != nil {
+			return "", "", err
+		}
+		return f, tableMimes[f], nil
+	}
+
+	accept := r.Header.Values("Accept")
+	if len(accept) == 0 {
+		return table.FormatCSV, mimeCSV, nil
+	}
+
+	for _, mediaRange := range parseAccept(accept) {
+		switch mediaRange {
+		case "*/*", "text/*", mimeCSV:
+			return table.FormatCSV, mimeCSV, nil
+		case mimeJSON:
+			return table.FormatJSON, mimeJSON, nil
+		case mimeGeoJSON:
+			return table.FormatGeoJSON, mimeGeoJSON, nil
+		case mimeParquet, "application/x-parquet":
+			return table.FormatParquet, mimeParquet, nil
+		}
+	}
+	return "", "", fmt.Errorf("%w: %s", sentinel.ErrNotAcceptable, strings.Join(accept, ","))
+}
+
+// negotiateLang picks the language for names in a response, and notes it in the
+// Content-Language header.
+// An explicit lang query parameter always wins.
+// Otherwise the Accept-Language header is consulted, highest q-value first, and the
+// first language we support is used; regional variants such as cy-GB count as their
+// language.
+// English is the default, including when nothing in Accept-Language is supported.
+//
+func negotiateLang(w http.ResponseWriter, r *http.Request, langParam *string) (string, error) {
+	language, err := pickLang(r, langParam)
+	if err != nil {
+		return "", err
+	}
+	w.Header().Set("Content-Language", language)
+	w.Header().Add("Vary", "Accept-Language")
+	return language, nil
+}
+
+func pickLang(r *http.Request, langParam *string) (string, error) {
+	if langParam != nil && *langParam != "" {
+		return lang.Parse(*langParam)
+	}
+
+	// language ranges parse as media types without a subtype
+	for _, languageRange := range parseAccept(r.Header.Values("Accept-Language")) {
+		primary := strings.SplitN(languageRange, "-", 2)[0]
+		if l, err := lang.Parse(primary); err == nil {
+			return l, nil
+		}
+	}
+	return lang.English, nil
+}
+
+// negotiateEncoding picks the content coding for a response from the Accept-Encoding
+// header, and notes in the Vary header that we did.
+// The coding with the highest q-value wins, brotli before gzip when they tie.
+// Returns encodingIdentity when the client accepts neither.
+func negotiateEncoding(w http.ResponseWriter, r *http.Request) string {
+	w.Header().Add("Vary", "Accept-Encoding")
+	return pickEncoding(r)
+}
+
+func pickEncoding(r *http.Request) string {
+	// codings parse as media types without a subtype
+	qs := map[string]float64{}
+	for _, coding := range parseAcceptQ(r.Header.Values("Accept-Encoding")) {
+		name := strings.ToLower(coding.mediaRange)
+		if name == "x-gzip" {
+			name = encodingGzip
+		}
+		if _, ok := qs[name]; !ok {
+			qs[name] = coding.q
+		}
+	}
+
+	best := encodingIdentity
+	var bestQ float64
+	for _, encoding := range encodings {
+		q, ok := qs[encoding]
+		if !ok {
+			q = qs["*"]
+		}
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+// parseAccept returns the media ranges in Accept header values, ordered by
+// descending q-value.
+// Media ranges with q=0 are dropped, and parameters other than q are ignored.
+func parseAccept(values []string) []string {
+	var result []string
+	for _, r := range parseAcceptQ(values) {
+		if r.q > 0 {
+			result = append(result, r.mediaRange)
+		}
+	}
+	return result
+}
+
+// A weighted is a media range, or similar, from an Accept-style header, and its q-value.
+type weighted struct {
+	mediaRange string
+	q          float64
+}
+
+// parseAcceptQ is like parseAccept, but includes the q-values, and keeps ranges with
+// q=0, which exclude them where a wildcard would otherwise match.
+func parseAcceptQ(values []string) []weighted {
+	var ranges []weighted
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			mediaRange, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil {
+				continue
+			}
+			q := 1.0
+			if qs, ok := params["q"]; ok {
+				if q, err = strconv.ParseFloat(qs, 64); err != nil {
+					continue
+				}
+			}
+			ranges = append(ranges, weighted{mediaRange, q})
+		}
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+	return ranges
+}
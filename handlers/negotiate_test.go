@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dp-geodata-api/pkg/lang"
+	"github.com/ONSdigital/dp-geodata-api/pkg/table"
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+func Test_negotiateFormat(t *testing.T) {
+	var tests = map[string]struct {
+		accept  []string
+		format  string
+		want    table.Format
+		wantErr error
+	}{
+		"no Accept header": {
+			want: table.FormatCSV,
+		},
+		"browser Accept header": {
+			accept: []string{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"},
+			want:   table.FormatCSV,
+		},
+		"json": {
+			accept: []string{"application/json"},
+			want:   table.FormatJSON,
+		},
+		"geojson preferred by q-value": {
+			accept: []string{"application/json;q=0.5, application/geo+json"},
+			want:   table.FormatGeoJSON,
+		},
+		"parquet in second header": {
+			accept: []string{"image/png", "application/vnd.apache.parquet"},
+			want:   table.FormatParquet,
+		},
+		"q=0 excludes media range": {
+			accept:  []string{"text/csv;q=0"},
+			wantErr: sentinel.ErrNotAcceptable,
+		},
+		"nothing acceptable": {
+			accept:  []string{"image/png"},
+			wantErr: sentinel.ErrNotAcceptable,
+		},
+		"format parameter overrides Accept": {
+			accept: []string{"text/csv"},
+			format: "geojson",
+			want:   table.FormatGeoJSON,
+		},
+		"bad format parameter": {
+			format:  "xlsx",
+			wantErr: sentinel.ErrInvalidParams,
+		},
+	}
+
+	for name, test := range tests {
+		req := &http.Request{Header: http.Header{}}
+		for _, v := range test.accept {
+			req.Header.Add("Accept", v)
+		}
+		var format *string
+		if test.format != "" {
+			format = &test.format
+		}
+
+		rec := httptest.NewRecorder()
+		got, mime, err := negotiateFormat(rec, req, format)
+		if vary := rec.Header().Values("Vary"); len(vary) != 1 || vary[0] != "Accept" {
+			t.Errorf("%s: Vary %q, want Accept", name, vary)
+		}
+		if test.wantErr != nil {
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("%s: got error %v, want %s", name, err, test.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: %s, want %s", name, got, test.want)
+		}
+		if mime != tableMimes[test.want] {
+			t.Errorf("%s: content-type %s, want %s", name, mime, tableMimes[test.want])
+		}
+	}
+}
+
+func Test_negotiateLang(t *testing.T) {
+	var tests = map[string]struct {
+		accept  []string
+		lang    string
+		want    string
+		wantErr error
+	}{
+		"no Accept-Language header": {
+			want: lang.English,
+		},
+		"welsh": {
+			accept: []string{"cy"},
+			want:   lang.Welsh,
+		},
+		"regional variant": {
+			accept: []string{"cy-GB"},
+			want:   lang.Welsh,
+		},
+		"welsh preferred by q-value": {
+			accept: []string{"en-GB;q=0.8, cy;q=0.9"},
+			want:   lang.Welsh,
+		},
+		"unsupported languages skipped": {
+			accept: []string{"fr, de;q=0.9, cy;q=0.5"},
+			want:   lang.Welsh,
+		},
+		"nothing supported": {
+			accept: []string{"fr"},
+			want:   lang.English,
+		},
+		"lang parameter overrides Accept-Language": {
+			accept: []string{"cy"},
+			lang:   "en",
+			want:   lang.English,
+		},
+		"bad lang parameter": {
+			lang:    "fr",
+			wantErr: sentinel.ErrInvalidParams,
+		},
+	}
+
+	for name, test := range tests {
+		req := &http.Request{Header: http.Header{}}
+		for _, v := range test.accept {
+			req.Header.Add("Accept-Language", v)
+		}
+		var langParam *string
+		if test.lang != "" {
+			langParam = &test.lang
+		}
+
+		w := httptest.NewRecorder()
+		got, err := negotiateLang(w, req, langParam)
+		if test.wantErr != nil {
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("%s: got error %v, want %s", name, err, test.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: %s, want %s", name, got, test.want)
+		}
+		if cl := w.Header().Get("Content-Language"); cl != test.want {
+			t.Errorf("%s: Content-Language %s, want %s", name, cl, test.want)
+		}
+		if vary := w.Header().Get("Vary"); vary != "Accept-Language" {
+			t.Errorf("%s: Vary %q, want Accept-Language", name, vary)
+		}
+	}
+}
+
+func Test_negotiateEncoding(t *testing.T) {
+	var tests = map[string]struct {
+		accept []string
+		want   string
+	}{
+		"no Accept-Encoding header": {
+			want: encodingIdentity,
+		},
+		"gzip": {
+			accept: []string{"gzip"},
+			want:   encodingGzip,
+		},
+		"x-gzip": {
+			accept: []string{"x-gzip"},
+			want:   encodingGzip,
+		},
+		"brotli preferred on a tie": {
+			accept: []string{"gzip, deflate, br"},
+			want:   encodingBrotli,
+		},
+		"gzip preferred by q-value": {
+			accept: []string{"br;q=0.5, gzip"},
+			want:   encodingGzip,
+		},
+		"wildcard": {
+			accept: []string{"*"},
+			want:   encodingBrotli,
+		},
+		"wildcard with exclusion": {
+			accept: []string{"br;q=0, *"},
+			want:   encodingGzip,
+		},
+		"unsupported only": {
+			accept: []string{"deflate, identity"},
+			want:   encodingIdentity,
+		},
+	}
+
+	for name, test := range tests {
+		req := &http.Request{Header: http.Header{}}
+		for _, v := range test.accept {
+			req.Header.Add("Accept-Encoding", v)
+		}
+
+		w := httptest.NewRecorder()
+		got := negotiateEncoding(w, req)
+		if got != test.want {
+			t.Errorf("%s: got %q, want %q", name, got, test.want)
+		}
+		if vary := w.Header().Get("Vary"); vary != "Accept-Encoding" {
+			t.Errorf("%s: Vary %q", name, vary)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ONSdigital/dp-geodata-api/pkg/apikey"
+	"github.com/ONSdigital/dp-geodata-api/pkg/geodata"
+	"github.com/ONSdigital/dp-geodata-api/pkg/ratelimit"
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// charge takes the estimated cost of a census query from the client's budget.
+// Queries estimated to return more than maxMetrics metrics are refused up front with
+// sentinel.ErrTooManyMetrics, rather than run until they reach the limit; 0 means no
+// limit.
+// Queries are charged only when they are about to run, so cached responses are free.
+func (svr *Server) charge(r *http.Request, args geodata.CensusQuerySQLArgs, maxMetrics int) error {
+	if svr.limiter == nil && maxMetrics == 0 {
+		return nil
+	}
+
+	cost, err := svr.querygeodata.Cost(r.Context(), args)
+	if err != nil {
+		return err
+	}
+	if maxMetrics > 0 && cost > maxMetrics {
+		return fmt.Errorf("%w: estimated %d, limit is %d", sentinel.ErrTooManyMetrics, cost, maxMetrics)
+	}
+	return svr.take(r, cost)
+}
+
+// chargeEstimate is like charge, for queries which estimate their own cost.
+// They return summaries rather than metrics, so are not subject to MAX_METRICS.
+func (svr *Server) chargeEstimate(r *http.Request, estimate func(ctx context.Context) (int, error)) error {
+	if svr.limiter == nil {
+		return nil
+	}
+
+	cost, err := estimate(r.Context())
+	if err != nil {
+		return err
+	}
+	return svr.take(r, cost)
+}
+
+// take takes cost from the client's budget.
+// It returns a *ratelimit.Error if the client is over budget.
+// Internal requests are not charged, so cache warming does not use up a budget.
+func (svr *Server) take(r *http.Request, cost int) error {
+	if svr.limiter == nil || isInternal(r.Context()) {
+		return nil
+	}
+
+	client := svr.clientID(r)
+	err := svr.limiter.Take(client, cost)
+	if err != nil {
+		log.Info(r.Context(), "rate limited", log.Data{"client": client, "cost": cost, "error": err.Error()})
+	}
+	return err
+}
+
+// clientID identifies the client for rate limiting.
+// Requests authorized with an API key are identified by the key's name, so clients
+// get the same budget wherever they connect from.
+// Others are identified by address.
+// Unchecked headers are never used, since clients could rotate them to get a fresh
+// budget for each request.
+func (svr *Server) clientID(r *http.Request) string {
+	if name := keyName(r.Context()); name != "" {
+		return "key:" + name
+	}
+	return "addr:" + clientAddr(r, svr.trustedProxies)
+}
+
+// clientAddr returns the address of the client.
+// trustedProxies is the number of proxies in front of the service, each of which
+// appends the address it received the request from to X-Forwarded-For.
+// The client address is the one appended by the outermost proxy; entries before it
+// come from the client, so cannot be trusted.
+func clientAddr(r *http.Request, trustedProxies int) string {
+	if trustedProxies > 0 {
+		var hops []string
+		for _, xff := range r.Header.Values("X-Forwarded-For") {
+			for _, hop := range strings.Split(xff, ",") {
+				hops = append(hops, strings.TrimSpace(hop))
+			}
+		}
+		if len(hops) >= trustedProxies {
+			return hops[len(hops)-trustedProxies]
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return host
+}
+
+// bearer returns the API key in the Authorization header, which may be sent as
+// "Bearer <key>" or as the bare key.
+func bearer(r *http.Request) string {
+	return apikey.Secret(r.Header.Get("Authorization"))
+}
+
+// setRetryAfter sets the Retry-After header if err says when to retry.
+func setRetryAfter(w http.ResponseWriter, err error) {
+	var rerr *ratelimit.Error
+	if !errors.As(err, &rerr) {
+		return
+	}
+	secs := int(math.Ceil(rerr.RetryAfter.Seconds()))
+	if secs < 1 {
+		secs = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(secs))
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-geodata-api/cache"
+	"github.com/ONSdigital/dp-geodata-api/pkg/apikey"
+	"github.com/ONSdigital/dp-geodata-api/pkg/ratelimit"
+)
+
+func Test_clientID(t *testing.T) {
+	svr := &Server{
+		enableHeaderAuth: true,
+		keys: apikey.NewStatic(
+			apikey.Key{Name: "atlas", Hash: apikey.Hash("secret"), Scopes: []string{"*"}, Enabled: true},
+		),
+		trustedProxies: 1,
+	}
+
+	var tests = map[string]struct {
+		header map[string]string
+		noAuth bool
+		want   string
+	}{
+		"remote addr": {
+			want: "addr:192.0.2.1",
+		},
+		"forwarded": {
+			header: map[string]string{"X-Forwarded-For": "198.51.100.7"},
+			want:   "addr:198.51.100.7",
+		},
+		"forwarded entries from the client are ignored": {
+			header: map[string]string{"X-Forwarded-For": "203.0.113.99, 198.51.100.7"},
+			want:   "addr:198.51.100.7",
+		},
+		"api key": {
+			header: map[string]string{"Authorization": "Bearer secret", "X-Forwarded-For": "198.51.100.7"},
+			want:   "key:atlas",
+		},
+		"bare api key": {
+			header: map[string]string{"Authorization": "secret"},
+			want:   "key:atlas",
+		},
+		"unknown api key": {
+			header: map[string]string{"Authorization": "Bearer made-up"},
+			want:   "addr:192.0.2.1",
+		},
+		"api key without header auth": {
+			header: map[string]string{"Authorization": "Bearer secret"},
+			noAuth: true,
+			want:   "addr:192.0.2.1",
+		},
+	}
+
+	for desc, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/query/2011", nil)
+		for k, v := range test.header {
+			req.Header.Set(k, v)
+		}
+		svr.enableHeaderAuth = !test.noAuth
+		svr.assertAuthorized(httptest.NewRecorder(), req, scopeQuery)
+		if got := svr.clientID(req); got != test.want {
+			t.Errorf("%s: got %q, want %q", desc, got, test.want)
+		}
+	}
+}
+
+func Test_clientAddr(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/query/2011", nil)
+	req.Header.Set("X-Forwarded-For", "203.0.113.99, 198.51.100.7, 10.0.0.1")
+
+	for proxies, want := range map[int]string{
+		0: "192.0.2.1", // X-Forwarded-For not trusted at all
+		1: "10.0.0.1",
+		2: "198.51.100.7",
+		4: "192.0.2.1", // fewer entries than proxies, so some are missing
+	} {
+		if got := clientAddr(req, proxies); got != want {
+			t.Errorf("%d proxies: got %q, want %q", proxies, got, want)
+		}
+	}
+}
+
+func Test_take(t *testing.T) {
+	svr := &Server{limiter: ratelimit.New(1, 10)}
+	req := httptest.NewRequest(http.MethodGet, "/query/2011", nil)
+
+	// internal requests, such as cache warming, are not charged
+	internal := req.WithContext(Internal(req.Context()))
+	for i := 0; i < 3; i++ {
+		if err := svr.take(internal, 10); err != nil {
+			t.Fatalf("internal request %d: %s", i, err)
+		}
+	}
+
+	if err := svr.take(req, 10); err != nil {
+		t.Errorf("first request: %s", err)
+	}
+	if err := svr.take(req, 10); err == nil {
+		t.Errorf("second request allowed with empty bucket")
+	}
+}
+
+func Test_respond_RateLimited(t *testing.T) {
+	cm, err := cache.New(time.Minute, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svr := &Server{cm: cm}
+
+	generate := func() ([]byte, error) {
+		return nil, fmt.Errorf("query: %w", &ratelimit.Error{RetryAfter: 1500 * time.Millisecond})
+	}
+	req := httptest.NewRequest(http.MethodGet, "/query/2011", nil)
+	rec := httptest.NewRecorder()
+	svr.respond(rec, req, mimeCSV, generate)
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("got status %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("got Retry-After %q, want \"2\"", got)
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ONSdigital/dp-geodata-api/api"
+	"github.com/ONSdigital/dp-geodata-api/pkg/geodata"
+	"github.com/ONSdigital/dp-geodata-api/postcode"
+	"gorm.io/gorm"
+)
+
+func (svr *Server) GetSearch(w http.ResponseWriter, r *http.Request, year int, params api.GetSearchParams) {
+	if !svr.assertAuthorized(w, r, scopeSearch) || !svr.assertDatabaseEnabled(w, r) {
+		return
+	}
+
+	var geotype []string
+	var limit int
+	if params.Geotype != nil {
+		geotype = *params.Geotype
+	}
+	if params.Limit != nil {
+		limit = *params.Limit
+	}
+
+	generate := func() ([]byte, error) {
+		ctx := r.Context()
+
+		var results []geodata.SearchResult
+		if postcode.IsPostcode(params.Q) {
+			code, _, err := svr.pc.GetMSOA(params.Q)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return toJSON([]geodata.SearchResult{})
+			}
+			if err != nil {
+				return nil, err
+			}
+			results, err = svr.querygeodata.SearchCode(ctx, year, code, geotype, limit)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			var err error
+			results, err = svr.querygeodata.Search(ctx, year, params.Q, geotype, limit)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return toJSON(results)
+	}
+
+	svr.respond(w, r, mimeJSON, generate)
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/ONSdigital/dp-geodata-api/api"
+	"github.com/ONSdigital/dp-geodata-api/pkg/geodata"
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+func (svr *Server) GetStatsYear(w http.ResponseWriter, r *http.Request, year int, params api.GetStatsYearParams) {
+	if !svr.assertAuthorized(w, r, scopeStats) || !svr.assertDatabaseEnabled(w, r) {
+		return
+	}
+
+	generate := func() ([]byte, error) {
+		args := geodata.StatsArgs{Year: year}
+		if params.Cat != nil {
+			args.Cats = *params.Cat
+		}
+		if params.Geotype != nil {
+			args.Geotypes = *params.Geotype
+		}
+		if params.DivideBy != nil {
+			args.DivideBy = *params.DivideBy
+		}
+		if params.Percentiles != nil {
+			args.Percentiles = *params.Percentiles
+		}
+		if params.Bins != nil {
+			args.Bins = *params.Bins
+		}
+		if args.Cats == nil || args.Geotypes == nil {
+			return nil, fmt.Errorf("%w: cat and geotype required", sentinel.ErrMissingParams)
+		}
+
+		// like /ckmeans, /stats returns summaries, so is not subject to MAX_METRICS
+		err := svr.chargeEstimate(r, func(ctx context.Context) (int, error) {
+			return svr.querygeodata.StatsCost(ctx, args)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		stats, err := svr.querygeodata.Stats(r.Context(), args)
+		if err != nil {
+			return nil, err
+		}
+		return toJSON(stats)
+	}
+
+	svr.respond(w, r, mimeJSON, generate)
+}
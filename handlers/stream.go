@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ONSdigital/dp-geodata-api/cache"
+	"github.com/ONSdigital/dp-geodata-api/pkg/tracing"
+	"github.com/ONSdigital/log.go/v2/log"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type streamFunc func(w io.Writer) error
+
+// IsStreamRequest is true if the response will be streamed: a GET /query/{year} with
+// stream=true, or a GET /exports/{id} with download=true.
+// Streamed responses cannot go through http.TimeoutHandler, because it buffers the
+// entire response.
+// Other endpoints ignore these parameters, so must not escape the usual timeouts by
+// adding them.
+// r's path must already have had any optional prefix removed.
+func IsStreamRequest(r *http.Request) bool {
+	if r.Method != http.MethodGet {
+		return false
+	}
+	segments := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if len(segments) != 2 || segments[1] == "" {
+		return false
+	}
+	q := r.URL.Query()
+	switch segments[0] {
+	case "query":
+		stream, _ := strconv.ParseBool(q.Get("stream"))
+		return stream
+	case "exports":
+		download, _ := strconv.ParseBool(q.Get("download"))
+		return download
+	}
+	return false
+}
+
+// respondStream is like respond, but stream writes the response to the client as it goes.
+// A copy is kept and cached if the response turns out to be no larger than streamCacheLimit.
+// Compressed responses are compressed as they are streamed, and the compressed copy is
+// cached.
+// stream's writer has a Flush method, which sends everything written so far on to the
+// client, through the encoder if there is one.
+//
+// Errors returned by stream before it has written anything are sent to the client as usual.
+// After that the status has already gone, so the connection is aborted instead, to stop the
+// client mistaking a partial response for a complete one.
+//
+func (svr *Server) respondStream(w http.ResponseWriter, r *http.Request, contentType string, stream streamFunc, variants ...string) {
+
+	// add CORS header if application configured to do so
+	if svr.doCors {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	}
+
+	// compressed responses are streamed through an encoder, and cached compressed
+	encoding := negotiateEncoding(w, r)
+	if encoding != encodingIdentity {
+		variants = append(variants, encoding)
+	}
+	key := cache.CacheKey(r, append([]string{contentType}, variants...)...)
+
+	ctx, span := tracing.Start(r.Context(), "respondStream",
+		attribute.String("http.response.content_type", contentType),
+		attribute.String("http.response.content_encoding", encoding),
+	)
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	// allocate a serialiser for this cache key
+	ser := svr.cm.AllocateEntry(key)
+	defer ser.Free()
+
+	// Hold the lock while streaming, so concurrent requests for the same content wait
+	// for the cache rather than running the query again.
+	if err := ser.Lock(ctx); err != nil {
+		err = lockError(err)
+		tracing.Error(span, err)
+		sendError(ctx, w, errorCode(err), err.Error())
+		return
+	}
+	defer ser.Unlock()
+
+	if !noCache(r) {
+		value, err := ser.Get(ctx)
+		if err == nil {
+			svr.writeValue(w, r, contentType, encoding, value)
+			return
+		}
+	}
+
+	modified := svr.lastModified(r)
+	tw := &teeWriter{
+		w: w,
+		start: func() {
+			w.Header().Add("Content-Type", contentType)
+			if encoding != encodingIdentity {
+				w.Header().Set("Content-Encoding", encoding)
+			}
+			// the ETag depends on the whole body, so can only be sent once it is cached
+			svr.setValidators(w, r, "", modified)
+		},
+		limit: svr.streamCacheLimit,
+	}
+
+	var out io.Writer = tw
+	var enc encoder
+	if encoding != encodingIdentity {
+		var err error
+		if enc, err = newEncoder(tw, encoding); err != nil {
+			sendError(ctx, w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		out = encodedWriter{enc, tw}
+	}
+
+	err := stream(out)
+	if err == nil && enc != nil {
+		err = enc.Close()
+	}
+	if err != nil {
+		tracing.Error(span, err)
+		if !tw.started {
+			setRetryAfter(w, err)
+			sendError(ctx, w, errorCode(err), err.Error())
+			return
+		}
+		log.Error(ctx, "aborting streamed response", err, log.Data{"uri": key, "sent": tw.sent})
+		panic(http.ErrAbortHandler)
+	}
+	if !tw.started {
+		tw.start()
+	}
+
+	if tw.overflow {
+		log.Info(ctx, "streamed response too large to cache", log.Data{"uri": key, "size": tw.sent})
+		return
+	}
+
+	// if there is a problem saving response in cache, log it; the client already has it
+	err = ser.Set(ctx, cache.NewValue(tw.buf.Bytes(), modified), cacheTags(r)...)
+	if err != nil {
+		log.Warn(ctx, "cannot cache", log.Data{"message": err.Error(), "uri": key, "size": tw.buf.Len()})
+	}
+}
+
+// A teeWriter passes writes through to the client, keeping a copy for the cache
+// until the copy would grow past limit bytes.
+type teeWriter struct {
+	w        http.ResponseWriter
+	start    func() // sets the response headers, just before anything is sent
+	started  bool   // true once anything has been sent to the client
+	sent     int    // bytes sent to the client
+	limit    int
+	buf      bytes.Buffer
+	overflow bool // true if the response is too large to cache
+}
+
+func (tw *teeWriter) Write(p []byte) (int, error) {
+	if !tw.started {
+		tw.start()
+		tw.started = true
+	}
+
+	if !tw.overflow {
+		if tw.buf.Len()+len(p) > tw.limit {
+			tw.overflow = true
+			tw.buf = bytes.Buffer{}
+		} else {
+			tw.buf.Write(p)
+		}
+	}
+
+	n, err := tw.w.Write(p)
+	tw.sent += n
+	return n, err
+}
+
+// Flush sends anything buffered by the ResponseWriter on to the client.
+func (tw *teeWriter) Flush() error {
+	if !tw.started {
+		return nil
+	}
+	if f, ok := tw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// An encodedWriter compresses to a teeWriter.
+// Flush flushes the encoder first, so the client gets everything written so far.
+type encodedWriter struct {
+	encoder
+	tw *teeWriter
+}
+
+func (ew encodedWriter) Flush() error {
+	if err := ew.encoder.Flush(); err != nil {
+		return err
+	}
+	return ew.tw.Flush()
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-geodata-api/cache"
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+func newStreamServer(t *testing.T, limit int) *Server {
+	cm, err := cache.New(time.Minute, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Server{cm: cm, streamCacheLimit: limit}
+}
+
+func Test_respondStream(t *testing.T) {
+	var tests = map[string]struct {
+		limit      int
+		body       string
+		err        error
+		wantCode   int
+		wantCached bool
+	}{
+		"small response is cached": {
+			limit:      100,
+			body:       "a,b\n1,2\n",
+			wantCode:   http.StatusOK,
+			wantCached: true,
+		},
+		"large response is not cached": {
+			limit:    4,
+			body:     "a,b\n1,2\n",
+			wantCode: http.StatusOK,
+		},
+		"error before output": {
+			limit:    100,
+			err:      sentinel.ErrInvalidParams,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for name, test := range tests {
+		svr := newStreamServer(t, test.limit)
+		stream := func(w io.Writer) error {
+			if test.err != nil {
+				return test.err
+			}
+			// two writes, as if two rows
+			half := len(test.body) / 2
+			io.WriteString(w, test.body[:half])
+			_, err := io.WriteString(w, test.body[half:])
+			return err
+		}
+
+		req := httptest.NewRequest("GET", "/query/2011?stream=true", nil)
+		rec := httptest.NewRecorder()
+		svr.respondStream(rec, req, mimeCSV, stream)
+
+		if rec.Code != test.wantCode {
+			t.Errorf("%s: status %d, want %d", name, rec.Code, test.wantCode)
+		}
+		if test.err == nil {
+			if rec.Body.String() != test.body {
+				t.Errorf("%s: body %q, want %q", name, rec.Body.String(), test.body)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != mimeCSV {
+				t.Errorf("%s: Content-Type %q, want %q", name, ct, mimeCSV)
+			}
+		}
+
+		// a second request only succeeds if served from the cache
+		rec = httptest.NewRecorder()
+		svr.respondStream(rec, req, mimeCSV, func(io.Writer) error {
+			return errors.New("not cached")
+		})
+		gotCached := rec.Code == http.StatusOK
+		if gotCached != test.wantCached {
+			t.Errorf("%s: cached %t, want %t", name, gotCached, test.wantCached)
+		}
+		if gotCached && rec.Header().Get("ETag") == "" {
+			t.Errorf("%s: cached response has no ETag", name)
+		}
+	}
+}
+
+func Test_respondStream_Abort(t *testing.T) {
+	svr := newStreamServer(t, 100)
+	stream := func(w io.Writer) error {
+		io.WriteString(w, "a,b\n")
+		return fmt.Errorf("%w: limit is 1", sentinel.ErrTooManyMetrics)
+	}
+
+	defer func() {
+		if r := recover(); r != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want %v", r, http.ErrAbortHandler)
+		}
+	}()
+
+	req := httptest.NewRequest("GET", "/query/2011?stream=true", nil)
+	svr.respondStream(httptest.NewRecorder(), req, mimeCSV, stream)
+}
+
+func Test_IsStreamRequest(t *testing.T) {
+	for uri, want := range map[string]bool{
+		"/query/2011":               false,
+		"/query/2011?stream=true":   true,
+		"/query/2011?stream=1":      true,
+		"/query/2011?stream=false":  false,
+		"/query/2011?stream=banana": false,
+		"/exports/1?download=true":  true,
+		"/exports/1":                false,
+		"/exports?download=true":    false,
+		"/ckmeans/2011?stream=true": false,
+		"/stats/2011?stream=true":   false,
+		"/query2/2011?stream=true":  false,
+		"/query/2011/x?stream=true": false,
+		"/ckmeans/2011?download=1":  false,
+	} {
+		if got := IsStreamRequest(httptest.NewRequest("GET", uri, nil)); got != want {
+			t.Errorf("%s: %t, want %t", uri, got, want)
+		}
+	}
+
+	if IsStreamRequest(httptest.NewRequest("POST", "/query/2011?stream=true", nil)) {
+		t.Errorf("POST: true, want false")
+	}
+}
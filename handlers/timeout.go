@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+// QueryTimeout returns middleware giving each request a context deadline, so
+// database queries are cancelled once they run too long.
+// timeouts holds timeouts for particular endpoints, eg "ckmeans", overriding timeout.
+// A timeout of zero leaves the request without a deadline of its own.
+//
+// The timeout should be shorter than the server's WriteTimeout, so the client is sent
+// a 504 rather than the generic WRITE_TIMEOUT response.
+// Streamed responses are not given a deadline here, since they have their own, longer
+// one.
+//
+func QueryTimeout(timeout time.Duration, timeouts map[string]time.Duration) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			d := timeout
+			if t, ok := timeouts[endpoint(r)]; ok {
+				d = t
+			}
+			if d <= 0 || IsStreamRequest(r) {
+				h.ServeHTTP(w, r)
+				return
+			}
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			h.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// endpoint returns the first segment of r's path, eg "query".
+func endpoint(r *http.Request) string {
+	return strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)[0]
+}
+
+// lockError explains why a cache Entry could not be locked.
+// Waiting past the request's deadline counts as a timeout, just as if the request
+// had run the query itself.
+func lockError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: waiting for cache: %s", sentinel.ErrQueryTimeout, err)
+	}
+	return err
+}
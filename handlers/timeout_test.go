@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-geodata-api/cache"
+)
+
+func Test_QueryTimeout(t *testing.T) {
+	mw := QueryTimeout(time.Minute, map[string]time.Duration{"ckmeans": time.Second, "search": 0})
+
+	var tests = map[string]struct {
+		uri  string
+		want time.Duration // zero for no deadline
+	}{
+		"default":  {uri: "/query/2011", want: time.Minute},
+		"endpoint": {uri: "/ckmeans/2011", want: time.Second},
+		"disabled": {uri: "/search/2011"},
+		"stream":   {uri: "/query/2011?stream=true"},
+		// only /query streams, so stream=true elsewhere does not lift the deadline
+		"ckmeans stream": {uri: "/ckmeans/2011?stream=true", want: time.Second},
+		"stats download": {uri: "/stats/2011?download=true", want: time.Minute},
+	}
+
+	for desc, test := range tests {
+		var got time.Duration
+		h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if deadline, ok := r.Context().Deadline(); ok {
+				got = time.Until(deadline).Round(time.Second)
+			}
+		}))
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, test.uri, nil))
+		if got != test.want {
+			t.Errorf("%s: got deadline in %s, want %s", desc, got, test.want)
+		}
+	}
+}
+
+func Test_respond_Timeout(t *testing.T) {
+	cm, err := cache.New(time.Minute, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svr := &Server{cm: cm}
+
+	// the first request holds the cache key until it is told to finish
+	release := make(chan struct{})
+	started := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		generate := func() ([]byte, error) {
+			close(started)
+			<-release
+			return []byte("a,b\n"), nil
+		}
+		svr.respond(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/query/2011", nil), mimeCSV, generate)
+	}()
+	<-started
+
+	// a second request for the same key times out waiting for it
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "/query/2011", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	svr.respond(rec, req, mimeCSV, func() ([]byte, error) {
+		return nil, errors.New("should not run")
+	})
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("waiter: got status %d, want %d", rec.Code, http.StatusGatewayTimeout)
+	}
+
+	close(release)
+	<-done
+}
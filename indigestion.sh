@@ -22,6 +22,8 @@ cd dataingest/geoname && go run .
 cd ../spatial && ./lad2011ish.sh && go build ./geo2sql.go && ./import.sh
 cd longlatgeom  && go run .    
 cd ../../postcode  && go run . 
+cd ../hierarchy  && go run .
+cd ../breaks  && go run .
 delta=$((SECONDS-otime))
 echo "about" $((delta/60)) "min(s) elapsed"
 psql -c 'vacuum analyze'
@@ -8,6 +8,7 @@ import (
 	"github.com/ONSdigital/dp-geodata-api/api"
 	"github.com/ONSdigital/dp-geodata-api/model"
 	"github.com/ONSdigital/dp-geodata-api/pkg/database"
+	"github.com/ONSdigital/dp-geodata-api/pkg/lang"
 	"github.com/ONSdigital/dp-healthcheck/healthcheck"
 	"github.com/ONSdigital/log.go/v2/log"
 	"github.com/gosimple/slug"
@@ -34,7 +35,11 @@ func New(dbs ...*gorm.DB) (*Metadata, error) {
 	return &Metadata{gdb: dbs[0]}, err
 }
 
-func (md *Metadata) Get(ctx context.Context, year int, filterTotals bool) ([]byte, error) {
+// Get returns the topics, tables and categories for year as JSON.
+// Names are given in language, falling back to English where there is no Welsh name.
+// Slugs are always made from the English names, so they stay the same across languages.
+//
+func (md *Metadata) Get(ctx context.Context, year int, filterTotals bool, language string) ([]byte, error) {
 	var topics []model.NomisTopic
 
 	md.gdb.Preload(
@@ -66,14 +71,14 @@ func (md *Metadata) Get(ctx context.Context, year int, filterTotals bool) ([]byt
 
 			// partially populate table here to allow optional inclusion of Total if filterTotals == true
 			table := api.Table{
-				Name: spointer(nd.Name),
+				Name: spointer(lang.Name(language, nd.Name, nd.WelshName)),
 				Slug: spointer(slug.Make(nd.Name)),
 				Code: spointer(nd.ShortNomisCode),
 			}
 
 			var cats api.Categories
 			for _, trip := range nd.NomisCategories {
-				cat := api.Triplet{Code: spointer(trip.LongNomisCode), Name: spointer(trip.CategoryName), Slug: spointer(slug.Make(trip.CategoryName))}
+				cat := api.Triplet{Code: spointer(trip.LongNomisCode), Name: spointer(lang.Name(language, trip.CategoryName, trip.WelshCategoryName)), Slug: spointer(slug.Make(trip.CategoryName))}
 				if filterTotals && isTotalCat(trip.LongNomisCode) {
 					table.Total = &cat
 				} else {
@@ -86,7 +91,7 @@ func (md *Metadata) Get(ctx context.Context, year int, filterTotals bool) ([]byt
 
 		mdr = append(mdr, api.Metadata{
 			Code:   spointer(topic.TopNomisCode),
-			Name:   spointer(topic.Name),
+			Name:   spointer(lang.Name(language, topic.Name, topic.WelshName)),
 			Slug:   spointer(slug.Make(topic.Name)),
 			Tables: &newTabs,
 		})
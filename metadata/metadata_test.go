@@ -10,6 +10,7 @@ import (
 
 	"github.com/ONSdigital/dp-geodata-api/comptests"
 	"github.com/ONSdigital/dp-geodata-api/model"
+	"github.com/ONSdigital/dp-geodata-api/pkg/lang"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -48,7 +49,7 @@ func TestMetaDataTest(t *testing.T) {
 		md, _ := New(tx)
 
 		filterTotals := false
-		b, err := md.Get(context.Background(), 2011, filterTotals)
+		b, err := md.Get(context.Background(), 2011, filterTotals, lang.English)
 		if err != nil {
 			t.Error(err)
 		}
@@ -81,7 +82,7 @@ func TestMetaDataFiltertotals(t *testing.T) {
 		md, _ := New(tx)
 
 		filterTotals := true
-		b, err := md.Get(context.Background(), 2011, filterTotals)
+		b, err := md.Get(context.Background(), 2011, filterTotals, lang.English)
 		if err != nil {
 			t.Error(err)
 		}
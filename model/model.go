@@ -2,6 +2,7 @@ package model
 
 import (
 	"database/sql"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -36,6 +37,21 @@ func (DataVer) TableName() string {
 	return "data_ver"
 }
 
+// APIKey is a client's API key, stored as a hash, and the scopes it grants.
+type APIKey struct {
+	gorm.Model
+	Name      string `gorm:"uniqueIndex"`
+	KeyHash   string `gorm:"uniqueIndex"`
+	Scopes    string // comma separated, eg "query,ckmeans"
+	Enabled   bool
+	ExpiresAt *time.Time // never expires if null
+}
+
+// don't pluralise table name
+func (APIKey) TableName() string {
+	return "api_key"
+}
+
 // THIS TABLE NEEDS RESTRUCTURING & FIELDS RENAMING
 
 type YearMapping struct {
@@ -49,6 +65,55 @@ func (YearMapping) TableName() string {
 	return "lsoa2011_lad2020_lookup"
 }
 
+// GeoHierarchy links an area to each area of a larger geotype which contains it,
+// eg an OA to its LSOA, MSOA, LAD and so on.
+type GeoHierarchy struct {
+	ID       int32 `gorm:"primaryKey"`
+	ChildID  int32 `gorm:"index"`
+	ParentID int32 `gorm:"index"`
+}
+
+// don't pluralise table name
+func (GeoHierarchy) TableName() string {
+	return "geo_hierarchy"
+}
+
+// GeoVintageLookup maps a geocode from one census year's geography to its best fit in
+// another's, where boundaries have changed between the two.
+type GeoVintageLookup struct {
+	ID       int32  `gorm:"primaryKey"`
+	FromYear int32  `gorm:"index:idx_geo_vintage_lookup_from"`
+	FromCode string `gorm:"index:idx_geo_vintage_lookup_from"`
+	ToYear   int32
+	ToCode   string
+}
+
+// don't pluralise table name
+func (GeoVintageLookup) TableName() string {
+	return "geo_vintage_lookup"
+}
+
+// GeoBreaks holds class breaks precomputed at ingest for a category and geotype, so
+// /ckmeans need not load the metrics of every area.
+// DataUpdatedAt is data_ver.updated_at when the breaks were computed; the breaks are
+// stale if the data has been updated since.
+type GeoBreaks struct {
+	ID            int32  `gorm:"primaryKey"`
+	DataVerID     int32  `gorm:"uniqueIndex:idx_geo_breaks_key"`
+	Category      string `gorm:"uniqueIndex:idx_geo_breaks_key"`
+	Geotype       string `gorm:"uniqueIndex:idx_geo_breaks_key"`
+	DivideBy      string `gorm:"uniqueIndex:idx_geo_breaks_key"` // empty unless breaks are of ratios
+	K             int32  `gorm:"uniqueIndex:idx_geo_breaks_key"`
+	Method        string `gorm:"uniqueIndex:idx_geo_breaks_key"`
+	Stats         string `gorm:"type:jsonb"` // this category and geotype's part of a /ckmeans response with detail=true
+	DataUpdatedAt time.Time
+}
+
+// don't pluralise table name
+func (GeoBreaks) TableName() string {
+	return "geo_breaks"
+}
+
 type GeoType struct {
 	ID   int32 `gorm:"primaryKey;autoIncrement:false"`
 	Name string
@@ -100,14 +165,15 @@ func (GeoMetric) TableName() string {
 
 type NomisCategory struct {
 	// why do we need uniqueIndex? composite key!
-	ID              int32 `gorm:"uniqueIndex;primaryKey"`
-	NomisDescID     int32 `gorm:"primaryKey"`
-	CategoryName    string
-	MeasurementUnit string
-	StatUnit        string
-	LongNomisCode   string `gorm:"uniqueIndex"`
-	Year            int32
-	GoMetrics       []GeoMetric `gorm:"foreignKey:CategoryID;references:ID"`
+	ID                int32 `gorm:"uniqueIndex;primaryKey"`
+	NomisDescID       int32 `gorm:"primaryKey"`
+	CategoryName      string
+	WelshCategoryName string
+	MeasurementUnit   string
+	StatUnit          string
+	LongNomisCode     string `gorm:"uniqueIndex"`
+	Year              int32
+	GoMetrics         []GeoMetric `gorm:"foreignKey:CategoryID;references:ID"`
 }
 
 // don't pluralise table name
@@ -119,6 +185,7 @@ type NomisDesc struct {
 	ID              int32 `gorm:"uniqueIndex;primaryKey"`
 	NomisTopicID    int32 `gorm:"primaryKey"`
 	Name            string
+	WelshName       string
 	PopStat         string
 	ShortNomisCode  string `gorm:"uniqueIndex"`
 	Year            int32
@@ -134,6 +201,7 @@ type NomisTopic struct {
 	ID           int32 `gorm:"primaryKey"`
 	TopNomisCode string
 	Name         string
+	WelshName    string
 	NomisDescs   []NomisDesc `gorm:"foreignKey:NomisTopicID;references:ID"`
 }
 
@@ -38,7 +38,10 @@ func SetupUpdateDB(dsn string) {
 			log.Fatal(err)
 		}
 
-		execSQL(gdb, []string{"CREATE EXTENSION IF NOT EXISTS postgis"})
+		execSQL(gdb, []string{
+			"CREATE EXTENSION IF NOT EXISTS postgis",
+			"CREATE EXTENSION IF NOT EXISTS pg_trgm",
+		})
 	}
 
 	{
@@ -103,6 +106,10 @@ func Migrate(db *gorm.DB) {
 		&NomisCategory{},
 		&GeoMetric{},
 		&YearMapping{},
+		&GeoHierarchy{},
+		&GeoVintageLookup{},
+		&APIKey{},
+		&GeoBreaks{},
 	); err != nil {
 		log.Fatal(err)
 	}
@@ -111,7 +118,9 @@ func Migrate(db *gorm.DB) {
 		"ALTER TABLE geo ADD COLUMN wkb_geometry geometry(Geometry,4326)",
 		"CREATE INDEX geo_wkb_geometry_geom_idx ON public.geo USING gist (wkb_geometry)",
 		"ALTER TABLE geo ADD COLUMN wkb_long_lat_geom geometry(Geometry,4326)",
-		"CREATE INDEX geo_long_lat_geom_idx ON public.geo USING gist ( wkb_long_lat_geom)"})
+		"CREATE INDEX geo_long_lat_geom_idx ON public.geo USING gist ( wkb_long_lat_geom)",
+		"CREATE INDEX geo_name_trgm_idx ON public.geo USING gin (name gin_trgm_ops)",
+		"CREATE INDEX geo_welsh_name_trgm_idx ON public.geo USING gin (welsh_name gin_trgm_ops)"})
 
 }
 
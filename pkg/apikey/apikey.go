@@ -0,0 +1,140 @@
+// The apikey package looks up the API keys clients present, and checks what they may
+// be used for.
+//
+// Keys are random secrets handed to each client.
+// Only a hash of each key is stored, alongside a name for the client, the scopes the
+// key grants, whether it is enabled, and when it expires.
+//
+// Scopes are names such as "query" or "admin/clear-cache".
+// A key with scope "admin" grants every "admin/..." scope, and "*" grants everything.
+//
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+var (
+	ErrDisabled = errors.New("api key disabled")
+	ErrExpired  = errors.New("api key expired")
+	ErrScope    = errors.New("api key not valid for this request")
+)
+
+// A Key is a stored API key.
+type Key struct {
+	Name    string     `json:"name"`
+	Hash    string     `json:"hash"` // as returned by Hash
+	Scopes  []string   `json:"scopes"`
+	Enabled bool       `json:"enabled"`
+	Expires *time.Time `json:"expires,omitempty"` // never expires if nil
+}
+
+// A Store finds keys by hash.
+// Lookup returns an error wrapping sentinel.ErrNotFound for unknown hashes.
+type Store interface {
+	Lookup(ctx context.Context, hash string) (*Key, error)
+}
+
+// Hash returns the hash stored for secret.
+// Keys are long random strings, so a plain SHA-256 is enough.
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Secret returns the secret in an Authorization header value, without any
+// "Bearer " scheme.
+func Secret(authorization string) string {
+	secret := strings.TrimSpace(authorization)
+	if len(secret) > len("bearer ") && strings.EqualFold(secret[:len("bearer ")], "bearer ") {
+		secret = strings.TrimSpace(secret[len("bearer "):])
+	}
+	return secret
+}
+
+// Generate returns a new random secret.
+func Generate() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Check returns an error if k cannot be used for scope at time now.
+func (k *Key) Check(now time.Time, scope string) error {
+	if !k.Enabled {
+		return fmt.Errorf("%w: %s", ErrDisabled, k.Name)
+	}
+	if k.Expires != nil && !now.Before(*k.Expires) {
+		return fmt.Errorf("%w: %s", ErrExpired, k.Name)
+	}
+	if !k.Allows(scope) {
+		return fmt.Errorf("%w: %s lacks scope %s", ErrScope, k.Name, scope)
+	}
+	return nil
+}
+
+// Allows is true if k grants scope.
+func (k *Key) Allows(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == "*" || s == scope || strings.HasPrefix(scope, s+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseScopes splits a comma separated list of scopes.
+func ParseScopes(s string) []string {
+	var scopes []string
+	for _, scope := range strings.Split(s, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// Static is a fixed set of keys.
+type Static map[string]*Key
+
+// NewStatic returns a Static store holding keys.
+func NewStatic(keys ...Key) Static {
+	s := Static{}
+	for i := range keys {
+		s[keys[i].Hash] = &keys[i]
+	}
+	return s
+}
+
+func (s Static) Lookup(ctx context.Context, hash string) (*Key, error) {
+	key, ok := s[hash]
+	if !ok {
+		return nil, fmt.Errorf("%w: api key", sentinel.ErrNotFound)
+	}
+	return key, nil
+}
+
+// Multi looks up keys in each of its stores in turn.
+type Multi []Store
+
+func (m Multi) Lookup(ctx context.Context, hash string) (*Key, error) {
+	for _, s := range m {
+		key, err := s.Lookup(ctx, hash)
+		if errors.Is(err, sentinel.ErrNotFound) {
+			continue
+		}
+		return key, err
+	}
+	return nil, fmt.Errorf("%w: api key", sentinel.ErrNotFound)
+}
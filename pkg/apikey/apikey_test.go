@@ -0,0 +1,155 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+func TestCheck(t *testing.T) {
+	now := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	var tests = map[string]struct {
+		key     Key
+		scope   string
+		wantErr error
+	}{
+		"exact scope": {
+			key:   Key{Name: "k", Scopes: []string{"query", "ckmeans"}, Enabled: true},
+			scope: "ckmeans",
+		},
+		"missing scope": {
+			key:     Key{Name: "k", Scopes: []string{"query"}, Enabled: true},
+			scope:   "ckmeans",
+			wantErr: ErrScope,
+		},
+		"parent scope": {
+			key:   Key{Name: "k", Scopes: []string{"admin"}, Enabled: true},
+			scope: "admin/clear-cache",
+		},
+		"parent scope is not a prefix match": {
+			key:     Key{Name: "k", Scopes: []string{"admin"}, Enabled: true},
+			scope:   "administer",
+			wantErr: ErrScope,
+		},
+		"child scope does not grant parent": {
+			key:     Key{Name: "k", Scopes: []string{"admin/clear-cache"}, Enabled: true},
+			scope:   "admin/cache-stats",
+			wantErr: ErrScope,
+		},
+		"wildcard": {
+			key:   Key{Name: "k", Scopes: []string{"*"}, Enabled: true},
+			scope: "admin/clear-cache",
+		},
+		"disabled": {
+			key:     Key{Name: "k", Scopes: []string{"*"}},
+			scope:   "query",
+			wantErr: ErrDisabled,
+		},
+		"expired": {
+			key:     Key{Name: "k", Scopes: []string{"*"}, Enabled: true, Expires: &past},
+			scope:   "query",
+			wantErr: ErrExpired,
+		},
+		"not yet expired": {
+			key:   Key{Name: "k", Scopes: []string{"*"}, Enabled: true, Expires: &future},
+			scope: "query",
+		},
+	}
+
+	for desc, test := range tests {
+		err := test.key.Check(now, test.scope)
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("%s: got error %v, want %v", desc, err, test.wantErr)
+		}
+	}
+}
+
+func TestSecret(t *testing.T) {
+	var tests = map[string]string{
+		"key":            "key",
+		"Bearer key":     "key",
+		"bearer  key ":   "key",
+		" BEARER key":    "key",
+		"Bearer":         "Bearer",
+		"Bearerkey":      "Bearerkey",
+		"Basic dXNlcjpw": "Basic dXNlcjpw",
+	}
+	for authorization, want := range tests {
+		if got := Secret(authorization); got != want {
+			t.Errorf("Secret(%q) = %q, want %q", authorization, got, want)
+		}
+	}
+}
+
+func TestMulti(t *testing.T) {
+	ctx := context.Background()
+	a := NewStatic(Key{Name: "a", Hash: Hash("secret a")})
+	b := NewStatic(Key{Name: "b", Hash: Hash("secret b")})
+	m := Multi{a, b}
+
+	key, err := m.Lookup(ctx, Hash("secret b"))
+	if err != nil || key.Name != "b" {
+		t.Errorf("got %v, %v; want key b", key, err)
+	}
+	if _, err := m.Lookup(ctx, Hash("secret c")); !errors.Is(err, sentinel.ErrNotFound) {
+		t.Errorf("unknown key: got error %v, want %v", err, sentinel.ErrNotFound)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	name := filepath.Join(t.TempDir(), "keys.json")
+	write := func(content string, mtime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(name, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(name, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	start := time.Now().Add(-time.Hour)
+	write(`[{"name": "atlas", "hash": "`+Hash("secret")+`", "scopes": ["query"], "enabled": true}]`, start)
+
+	fs, err := NewFileStore(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := fs.Lookup(ctx, Hash("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Name != "atlas" || !key.Enabled || !key.Allows("query") {
+		t.Errorf("got %+v", key)
+	}
+
+	// disabling the key takes effect without a restart
+	write(`[{"name": "atlas", "hash": "`+Hash("secret")+`", "scopes": ["query"], "enabled": false}]`, start.Add(time.Minute))
+	key, err = fs.Lookup(ctx, Hash("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Enabled {
+		t.Errorf("key still enabled after file changed")
+	}
+
+	// a broken file leaves the previous keys in place
+	write(`[{"name": "atlas"`, start.Add(2*time.Minute))
+	if _, err := fs.Lookup(ctx, Hash("secret")); err != nil {
+		t.Errorf("broken file: %v", err)
+	}
+
+	write(`[{"name": "atlas"}]`, start)
+	if _, err := NewFileStore(name); err == nil {
+		t.Errorf("key without hash: expected error")
+	}
+}
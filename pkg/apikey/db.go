@@ -0,0 +1,57 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+// DBStore holds keys in the api_key table.
+type DBStore struct {
+	db *sql.DB
+}
+
+func NewDBStore(db *sql.DB) *DBStore {
+	return &DBStore{db: db}
+}
+
+// LookupSQL finds a key by hash, ignoring soft deleted rows.
+const LookupSQL = `
+SELECT
+	api_key.name,
+	api_key.scopes,
+	api_key.enabled,
+	api_key.expires_at
+FROM
+	api_key
+WHERE api_key.key_hash = $1
+AND api_key.deleted_at IS NULL
+`
+
+func (ds *DBStore) Lookup(ctx context.Context, hash string) (*Key, error) {
+	var name, scopes string
+	var enabled bool
+	var expires sql.NullTime
+	err := ds.db.QueryRowContext(ctx, LookupSQL, hash).Scan(&name, &scopes, &enabled, &expires)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: api key", sentinel.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &Key{
+		Name:    name,
+		Hash:    hash,
+		Scopes:  ParseScopes(scopes),
+		Enabled: enabled,
+	}
+	if expires.Valid {
+		t := expires.Time
+		key.Expires = &t
+	}
+	return key, nil
+}
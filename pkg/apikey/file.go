@@ -0,0 +1,74 @@
+package apikey
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// FileStore holds keys in a JSON file, as an array of Keys.
+// The file is read again when it changes, so keys can be added, disabled or removed
+// without a restart.
+type FileStore struct {
+	name string
+
+	mu      sync.Mutex // protects the fields below
+	modTime time.Time  // of the file when last read
+	keys    Static
+}
+
+// NewFileStore reads keys from the file called name.
+func NewFileStore(name string) (*FileStore, error) {
+	fs := &FileStore{name: name}
+	if err := fs.reload(); err != nil {
+		return nil, err
+	}
+	return fs, nil
+}
+
+// Lookup finds a key, first rereading the file if it has changed.
+// If the file cannot be reread, the keys already read are used.
+func (fs *FileStore) Lookup(ctx context.Context, hash string) (*Key, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if err := fs.reload(); err != nil {
+		log.Warn(ctx, "cannot reload api keys, using previous keys", log.Data{"file": fs.name, "message": err.Error()})
+	}
+	return fs.keys.Lookup(ctx, hash)
+}
+
+// reload rereads the file if its modification time has changed.
+// Must be called with fs.mu locked, or before fs is shared.
+func (fs *FileStore) reload() error {
+	info, err := os.Stat(fs.name)
+	if err != nil {
+		return err
+	}
+	if fs.keys != nil && info.ModTime().Equal(fs.modTime) {
+		return nil
+	}
+	// don't retry a bad file until it changes again
+	fs.modTime = info.ModTime()
+
+	b, err := os.ReadFile(fs.name)
+	if err != nil {
+		return err
+	}
+	var keys []Key
+	if err := json.Unmarshal(b, &keys); err != nil {
+		return fmt.Errorf("%s: %w", fs.name, err)
+	}
+	for _, key := range keys {
+		if key.Name == "" || key.Hash == "" {
+			return fmt.Errorf("%s: every key needs a name and hash", fs.name)
+		}
+	}
+
+	fs.keys = NewStatic(keys...)
+	return nil
+}
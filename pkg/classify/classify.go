@@ -0,0 +1,295 @@
+// Package classify divides values into classes for choropleth maps.
+//
+// Each method returns the upper breakpoint of each class, in ascending order, so the
+// last breakpoint is always the largest value.
+// A method may return fewer than k breakpoints, when the values cannot be split into
+// k distinct classes.
+//
+// Describe gives descriptive statistics of the values as a whole.
+//
+package classify
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+	"github.com/jtrim-ons/ckmeans/pkg/ckmeans"
+)
+
+// A Method is a way of choosing class breakpoints.
+type Method string
+
+const (
+	// Ckmeans finds optimal natural breaks, minimising the variance within classes.
+	Ckmeans Method = "ckmeans"
+
+	// JenksSample is Ckmeans over an evenly spaced sample of the sorted values, so
+	// it costs much less on large sets of values, such as OAs.
+	JenksSample Method = "jenks-sample"
+
+	// Quantile puts the same number of values in each class.
+	Quantile Method = "quantile"
+
+	// EqualInterval divides the range of values into classes of equal width.
+	EqualInterval Method = "equal-interval"
+
+	// StandardDeviation uses classes one standard deviation wide, centred on the mean.
+	StandardDeviation Method = "standard-deviation"
+
+	// HeadTail repeatedly splits the values above the mean, for heavy tailed
+	// distributions. It stops early once the head is no longer a minority.
+	HeadTail Method = "head-tail"
+)
+
+// Methods lists every Method, Ckmeans first.
+var Methods = []Method{Ckmeans, JenksSample, Quantile, EqualInterval, StandardDeviation, HeadTail}
+
+// sampleSize is the number of values JenksSample passes to ckmeans.
+const sampleSize = 10000
+
+// headLimit is the largest fraction of values allowed in the head for HeadTail to
+// carry on splitting it.
+const headLimit = 0.4
+
+// ErrNoValues is returned when there are no values to classify.
+var ErrNoValues = errors.New("no values to classify")
+
+// ParseMethod returns the Method called name.
+// An empty name means Ckmeans, which was the only method before the others were added.
+func ParseMethod(name string) (Method, error) {
+	if name == "" {
+		return Ckmeans, nil
+	}
+	for _, method := range Methods {
+		if Method(name) == method {
+			return method, nil
+		}
+	}
+	return "", fmt.Errorf("%w: unknown classification method %q", sentinel.ErrInvalidParams, name)
+}
+
+// Breaks divides values into at most k classes using method, and returns the upper
+// breakpoint of each class.
+// values may be reordered.
+func Breaks(method Method, values []float64, k int) ([]float64, error) {
+	if len(values) == 0 {
+		return nil, ErrNoValues
+	}
+	if k < 1 {
+		return nil, fmt.Errorf("%w: k must be at least 1", sentinel.ErrInvalidParams)
+	}
+
+	switch method {
+	case Ckmeans, "":
+		return ckmeansBreaks(values, k)
+	case JenksSample:
+		return jenksSample(values, k)
+	case Quantile:
+		return quantile(values, k), nil
+	case EqualInterval:
+		return equalInterval(values, k), nil
+	case StandardDeviation:
+		return standardDeviation(values, k), nil
+	case HeadTail:
+		return headTail(values, k), nil
+	}
+	return nil, fmt.Errorf("%w: unknown classification method %q", sentinel.ErrInvalidParams, method)
+}
+
+// MinMax returns the smallest and largest of values, which must not be empty.
+func MinMax(values []float64) []float64 {
+	max := values[0]
+	min := values[0]
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+		if v < min {
+			min = v
+		}
+	}
+	return []float64{min, max}
+}
+
+// ckmeansBreaks gets k ckmeans clusters from values and returns the upper breakpoints
+// for each cluster.
+func ckmeansBreaks(values []float64, k int) ([]float64, error) {
+	clusters, err := ckmeans.Ckmeans(values, k)
+	if err != nil {
+		return nil, err
+	}
+
+	var breaks []float64
+	for _, cluster := range clusters {
+		bp := cluster[len(cluster)-1]
+		breaks = append(breaks, bp)
+	}
+	return breaks, nil
+}
+
+// jenksSample runs ckmeans over at most sampleSize of values, taken evenly from the
+// sorted values and always including the smallest and largest.
+func jenksSample(values []float64, k int) ([]float64, error) {
+	if len(values) <= sampleSize {
+		return ckmeansBreaks(values, k)
+	}
+	sort.Float64s(values)
+	sample := make([]float64, sampleSize)
+	step := float64(len(values)-1) / float64(sampleSize-1)
+	for i := range sample {
+		sample[i] = values[int(math.Round(float64(i)*step))]
+	}
+	return ckmeansBreaks(sample, k)
+}
+
+// quantile returns the value at the top of each k-quantile.
+// Classes made empty by runs of equal values are dropped.
+func quantile(values []float64, k int) []float64 {
+	sort.Float64s(values)
+	n := len(values)
+	var breaks []float64
+	for i := 1; i <= k; i++ {
+		idx := int(math.Ceil(float64(i*n)/float64(k))) - 1
+		if idx < 0 {
+			continue
+		}
+		breaks = appendBreak(breaks, values[idx])
+	}
+	return breaks
+}
+
+// equalInterval divides the range of values into k classes of equal width.
+func equalInterval(values []float64, k int) []float64 {
+	minmax := MinMax(values)
+	min, max := minmax[0], minmax[1]
+	if min == max {
+		return []float64{max}
+	}
+	width := (max - min) / float64(k)
+	var breaks []float64
+	for i := 1; i < k; i++ {
+		breaks = append(breaks, min+float64(i)*width)
+	}
+	return append(breaks, max)
+}
+
+// standardDeviation returns breakpoints one standard deviation apart, placed so the
+// mean is in the middle of the middle class when k is odd, or on the middle
+// breakpoint when k is even.
+// Breakpoints beyond the range of values are dropped.
+func standardDeviation(values []float64, k int) []float64 {
+	mean, sd := meanSD(values)
+	minmax := MinMax(values)
+	min, max := minmax[0], minmax[1]
+	if sd == 0 {
+		return []float64{max}
+	}
+	var breaks []float64
+	for i := 1; i < k; i++ {
+		bp := mean + (float64(i)-float64(k)/2)*sd
+		if bp > min && bp < max {
+			breaks = append(breaks, bp)
+		}
+	}
+	return append(breaks, max)
+}
+
+// headTail returns the mean of values as the first breakpoint, then the mean of the
+// values above it, and so on, until there are k classes, or the head holds more than
+// headLimit of the values it was taken from.
+func headTail(values []float64, k int) []float64 {
+	var breaks []float64
+	head := values
+	max := MinMax(values)[1]
+	for len(breaks) < k-1 && len(head) > 1 {
+		mean, _ := meanSD(head)
+		var next []float64
+		for _, v := range head {
+			if v > mean {
+				next = append(next, v)
+			}
+		}
+		if len(next) == 0 {
+			break // all the same
+		}
+		breaks = append(breaks, mean)
+		if float64(len(next)) > headLimit*float64(len(head)) {
+			break
+		}
+		head = next
+	}
+	return append(breaks, max)
+}
+
+// meanSD returns the mean and population standard deviation of values.
+func meanSD(values []float64) (mean, sd float64) {
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	for _, v := range values {
+		sd += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sd / float64(len(values)))
+}
+
+// appendBreak appends bp to breaks unless it repeats the last breakpoint.
+func appendBreak(breaks []float64, bp float64) []float64 {
+	if len(breaks) > 0 && breaks[len(breaks)-1] == bp {
+		return breaks
+	}
+	return append(breaks, bp)
+}
+
+// ClassOf returns the index of the class holding value, given the upper breakpoints
+// of each class.
+// Values above the last breakpoint are put in the last class.
+func ClassOf(breaks []float64, value float64) int {
+	i := sort.SearchFloat64s(breaks, value)
+	if i == len(breaks) {
+		return len(breaks) - 1
+	}
+	return i
+}
+
+// Stats describe the values in each class.
+// The mean and median of an empty class are 0.
+type Stats struct {
+	Counts  []float64 // number of values; float64 so it can go alongside breaks
+	Means   []float64
+	Medians []float64
+}
+
+// Summarise returns the Stats of the values in each class, given the upper breakpoints
+// of each class.
+func Summarise(values, breaks []float64) Stats {
+	classes := make([][]float64, len(breaks))
+	for _, v := range values {
+		i := ClassOf(breaks, v)
+		classes[i] = append(classes[i], v)
+	}
+
+	stats := Stats{
+		Counts:  make([]float64, len(breaks)),
+		Means:   make([]float64, len(breaks)),
+		Medians: make([]float64, len(breaks)),
+	}
+	for i, class := range classes {
+		n := len(class)
+		if n == 0 {
+			continue
+		}
+		stats.Counts[i] = float64(n)
+		stats.Means[i], _ = meanSD(class)
+		sort.Float64s(class)
+		if n%2 == 1 {
+			stats.Medians[i] = class[n/2]
+		} else {
+			stats.Medians[i] = (class[n/2-1] + class[n/2]) / 2
+		}
+	}
+	return stats
+}
@@ -0,0 +1,232 @@
+package classify
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+func TestParseMethod(t *testing.T) {
+	var tests = []struct {
+		name    string
+		want    Method
+		wantErr error
+	}{
+		{"", Ckmeans, nil},
+		{"ckmeans", Ckmeans, nil},
+		{"quantile", Quantile, nil},
+		{"head-tail", HeadTail, nil},
+		{"Quantile", "", sentinel.ErrInvalidParams},
+		{"jenks", "", sentinel.ErrInvalidParams},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMethod(test.name)
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("%q: got error %v, want %v", test.name, err, test.wantErr)
+		}
+		if got != test.want {
+			t.Errorf("%q: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestBreaks(t *testing.T) {
+	var tests = []struct {
+		desc   string
+		method Method
+		values []float64
+		k      int
+		want   []float64
+	}{
+		{
+			// the example from the original javascript repo
+			desc:   "ckmeans",
+			method: Ckmeans,
+			values: []float64{-1, 2, -1, 2, 4, 5, 6, -1, 2, -1},
+			k:      3,
+			want:   []float64{-1, 2, 6},
+		},
+		{
+			desc:   "jenks-sample small enough to use every value",
+			method: JenksSample,
+			values: []float64{-1, 2, -1, 2, 4, 5, 6, -1, 2, -1},
+			k:      3,
+			want:   []float64{-1, 2, 6},
+		},
+		{
+			desc:   "quantile",
+			method: Quantile,
+			values: []float64{10, 9, 8, 7, 6, 5, 4, 3, 2, 1},
+			k:      5,
+			want:   []float64{2, 4, 6, 8, 10},
+		},
+		{
+			desc:   "quantile uneven",
+			method: Quantile,
+			values: []float64{1, 2, 3, 4, 5, 6, 7},
+			k:      3,
+			want:   []float64{3, 5, 7},
+		},
+		{
+			desc:   "quantile with many equal values",
+			method: Quantile,
+			values: []float64{0, 0, 0, 0, 0, 0, 0, 1, 5, 100},
+			k:      5,
+			want:   []float64{0, 1, 100},
+		},
+		{
+			desc:   "equal-interval",
+			method: EqualInterval,
+			values: []float64{0, 3, 10, 1},
+			k:      5,
+			want:   []float64{2, 4, 6, 8, 10},
+		},
+		{
+			desc:   "equal-interval one value",
+			method: EqualInterval,
+			values: []float64{3, 3, 3},
+			k:      5,
+			want:   []float64{3},
+		},
+		{
+			// mean 5, standard deviation 2
+			desc:   "standard-deviation",
+			method: StandardDeviation,
+			values: []float64{2, 4, 4, 4, 5, 5, 7, 9},
+			k:      4,
+			want:   []float64{3, 5, 7, 9},
+		},
+		{
+			desc:   "standard-deviation drops breaks beyond the values",
+			method: StandardDeviation,
+			values: []float64{2, 4, 4, 4, 5, 5, 7, 9},
+			k:      5,
+			want:   []float64{4, 6, 8, 9},
+		},
+		{
+			desc:   "head-tail",
+			method: HeadTail,
+			values: []float64{1, 1, 1, 1, 1, 1, 1, 2, 4, 100},
+			k:      5,
+			want:   []float64{11.3, 100},
+		},
+		{
+			desc:   "head-tail stops at k",
+			method: HeadTail,
+			values: append(make([]float64, 46), 10, 10, 100, 190),
+			k:      2,
+			want:   []float64{6.2, 190},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := Breaks(test.method, test.values, test.k)
+		if err != nil {
+			t.Errorf("%s: %s", test.desc, err)
+			continue
+		}
+		if !approx(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.desc, got, test.want)
+		}
+	}
+}
+
+func TestBreaksErrors(t *testing.T) {
+	if _, err := Breaks(Quantile, nil, 5); !errors.Is(err, ErrNoValues) {
+		t.Errorf("no values: got %v, want %v", err, ErrNoValues)
+	}
+	if _, err := Breaks(Quantile, []float64{1}, 0); !errors.Is(err, sentinel.ErrInvalidParams) {
+		t.Errorf("k == 0: got %v, want %v", err, sentinel.ErrInvalidParams)
+	}
+	if _, err := Breaks("jenks", []float64{1}, 5); !errors.Is(err, sentinel.ErrInvalidParams) {
+		t.Errorf("unknown method: got %v, want %v", err, sentinel.ErrInvalidParams)
+	}
+}
+
+func TestJenksSample(t *testing.T) {
+	// three well separated groups, too many values to classify them all
+	var values []float64
+	for i := 0; i < 3*sampleSize; i++ {
+		values = append(values, float64((i%3)*100+i%7))
+	}
+	got, err := Breaks(JenksSample, values, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []float64{6, 106, 206}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestMinMax(t *testing.T) {
+	got := MinMax([]float64{3, -1, 7, 2})
+	want := []float64{-1, 7}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func approx(got, want []float64) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if math.Abs(got[i]-want[i]) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestClassOf(t *testing.T) {
+	breaks := []float64{-1, 2, 6}
+	var tests = []struct {
+		value float64
+		want  int
+	}{
+		{-5, 0},
+		{-1, 0},
+		{0, 1},
+		{2, 1},
+		{6, 2},
+		{7, 2},
+	}
+
+	for _, test := range tests {
+		if got := ClassOf(breaks, test.value); got != test.want {
+			t.Errorf("%g: got %d, want %d", test.value, got, test.want)
+		}
+	}
+}
+
+func TestSummarise(t *testing.T) {
+	got := Summarise([]float64{6, -1, 2, -1, 2, 4, 5, -1, 2, -1}, []float64{-1, 2, 6, 10})
+	want := Stats{
+		Counts:  []float64{4, 3, 3, 0},
+		Means:   []float64{-1, 2, 5, 0},
+		Medians: []float64{-1, 2, 5, 0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	got = Summarise([]float64{1, 2, 3, 10}, []float64{3, 10})
+	want = Stats{
+		Counts:  []float64{3, 1},
+		Means:   []float64{2, 10},
+		Medians: []float64{2, 10},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	got = Summarise([]float64{1, 2, 4, 10}, []float64{4, 10})
+	if got.Medians[0] != 2 {
+		t.Errorf("even median: got %g, want 2", got.Medians[0])
+	}
+}
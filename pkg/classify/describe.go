@@ -0,0 +1,120 @@
+package classify
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+// Description holds descriptive statistics of a set of values.
+type Description struct {
+	Count int     `json:"count"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Mean  float64 `json:"mean"`
+	// WeightedMean is the mean weighted by population; nil if there were no weights.
+	WeightedMean *float64 `json:"weighted_mean,omitempty"`
+	Median       float64  `json:"median"`
+	// Percentiles is keyed by percentile, eg "90".
+	Percentiles map[string]float64 `json:"percentiles"`
+	StdDev      float64            `json:"std_dev"`
+	Histogram   Histogram          `json:"histogram"`
+}
+
+// Histogram counts values in bins of equal width.
+// Bin i holds values from Edges[i] up to, but not including, Edges[i+1], except that the
+// last bin includes the maximum.
+type Histogram struct {
+	Edges  []float64 `json:"edges"`
+	Counts []int     `json:"counts"`
+}
+
+// Describe returns the Description of values, with the given percentiles (0 to 100) and
+// number of histogram bins.
+// If there is a weight for each value, the weighted mean is included too.
+// If every value is the same, the histogram has a single bin.
+func Describe(values, weights, percentiles []float64, bins int) (Description, error) {
+	if len(values) == 0 {
+		return Description{}, ErrNoValues
+	}
+	if bins < 1 {
+		return Description{}, fmt.Errorf("%w: bins must be at least 1: %d", sentinel.ErrInvalidParams, bins)
+	}
+	for _, p := range percentiles {
+		if p < 0 || p > 100 || math.IsNaN(p) {
+			return Description{}, fmt.Errorf("%w: percentiles must be 0..100: %g", sentinel.ErrInvalidParams, p)
+		}
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	desc := Description{
+		Count:       len(sorted),
+		Min:         sorted[0],
+		Max:         sorted[len(sorted)-1],
+		Median:      percentile(sorted, 50),
+		Percentiles: map[string]float64{},
+		Histogram:   histogram(sorted, bins),
+	}
+	desc.Mean, desc.StdDev = meanSD(sorted)
+	for _, p := range percentiles {
+		desc.Percentiles[strconv.FormatFloat(p, 'f', -1, 64)] = percentile(sorted, p)
+	}
+
+	if len(weights) == len(values) {
+		var sum, total float64
+		for i, w := range weights {
+			sum += values[i] * w
+			total += w
+		}
+		if total != 0 {
+			mean := sum / total
+			desc.WeightedMean = &mean
+		}
+	}
+	return desc, nil
+}
+
+// percentile returns the pth percentile of sorted, interpolating linearly between the
+// values either side.
+func percentile(sorted []float64, p float64) float64 {
+	pos := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	if lo >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lo] + (sorted[lo+1]-sorted[lo])*(pos-float64(lo))
+}
+
+// histogram counts sorted into bins of equal width between its min and max.
+func histogram(sorted []float64, bins int) Histogram {
+	min, max := sorted[0], sorted[len(sorted)-1]
+	if min == max {
+		return Histogram{
+			Edges:  []float64{min, max},
+			Counts: []int{len(sorted)},
+		}
+	}
+
+	width := (max - min) / float64(bins)
+	h := Histogram{
+		Edges:  make([]float64, bins+1),
+		Counts: make([]int, bins),
+	}
+	for i := range h.Edges {
+		h.Edges[i] = min + float64(i)*width
+	}
+	h.Edges[bins] = max
+	for _, v := range sorted {
+		i := int((v - min) / width)
+		if i >= bins {
+			i = bins - 1
+		}
+		h.Counts[i]++
+	}
+	return h
+}
@@ -0,0 +1,82 @@
+package classify
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+func TestDescribe(t *testing.T) {
+	// mean 5, standard deviation 2
+	values := []float64{9, 4, 4, 2, 5, 5, 7, 4}
+	weights := []float64{1, 1, 1, 1, 1, 1, 1, 3}
+	got, err := Describe(values, weights, []float64{0, 25, 87.5, 100}, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	weighted := 4.8
+	want := Description{
+		Count:        8,
+		Min:          2,
+		Max:          9,
+		Mean:         5,
+		WeightedMean: &weighted,
+		Median:       4.5,
+		Percentiles: map[string]float64{
+			"0":    2,
+			"25":   4,
+			"87.5": 7.25,
+			"100":  9,
+		},
+		StdDev: 2,
+		Histogram: Histogram{
+			Edges:  []float64{2, 3.75, 5.5, 7.25, 9},
+			Counts: []int{1, 5, 1, 1},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// values must not be reordered, so they stay in step with the caller's geocodes
+	if !reflect.DeepEqual(values, []float64{9, 4, 4, 2, 5, 5, 7, 4}) {
+		t.Errorf("values reordered: %v", values)
+	}
+}
+
+func TestDescribeUnweighted(t *testing.T) {
+	got, err := Describe([]float64{3, 3, 3}, nil, nil, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.WeightedMean != nil {
+		t.Errorf("weighted mean: got %g, want none", *got.WeightedMean)
+	}
+	want := Histogram{Edges: []float64{3, 3}, Counts: []int{3}}
+	if !reflect.DeepEqual(got.Histogram, want) {
+		t.Errorf("histogram: got %+v, want %+v", got.Histogram, want)
+	}
+
+	got, err = Describe([]float64{1, 2}, []float64{0, 0}, nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.WeightedMean != nil {
+		t.Errorf("zero weights: got %g, want none", *got.WeightedMean)
+	}
+}
+
+func TestDescribeErrors(t *testing.T) {
+	if _, err := Describe(nil, nil, nil, 10); !errors.Is(err, ErrNoValues) {
+		t.Errorf("no values: got %v, want %v", err, ErrNoValues)
+	}
+	if _, err := Describe([]float64{1}, nil, nil, 0); !errors.Is(err, sentinel.ErrInvalidParams) {
+		t.Errorf("bins == 0: got %v, want %v", err, sentinel.ErrInvalidParams)
+	}
+	if _, err := Describe([]float64{1}, nil, []float64{101}, 10); !errors.Is(err, sentinel.ErrInvalidParams) {
+		t.Errorf("percentile 101: got %v, want %v", err, sentinel.ErrInvalidParams)
+	}
+}
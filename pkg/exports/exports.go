@@ -0,0 +1,351 @@
+// The exports package runs bulk census queries as background jobs.
+//
+// A client submits the arguments of a census query and gets back a Job.
+// Workers run queued jobs one at a time each, writing the CSV results to a Store.
+// The client polls the Job until it is complete, and then downloads the results.
+//
+// Jobs and their results live in the Store, so they survive a restart.
+// Jobs that were queued or running when the service stopped are run again by Start.
+// Finished jobs are deleted, with their results, once they are older than the
+// Manager's ttl.
+//
+package exports
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/dp-geodata-api/pkg/geodata"
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+type Status string
+
+const (
+	StatusQueued   Status = "queued"
+	StatusRunning  Status = "running"
+	StatusComplete Status = "complete"
+	StatusFailed   Status = "failed"
+)
+
+// A Job is a single export request and its progress.
+type Job struct {
+	ID      string                     `json:"id"`
+	Status  Status                     `json:"status"`
+	Args    geodata.CensusQuerySQLArgs `json:"args"`
+	Created time.Time                  `json:"created"`
+	Updated time.Time                  `json:"updated"`
+	Size    int64                      `json:"size,omitempty"`  // bytes of output, once complete
+	Error   string                     `json:"error,omitempty"` // why the job failed
+}
+
+// A Store holds jobs and their output.
+// Get and Open return an error wrapping sentinel.ErrNotFound for unknown ids.
+type Store interface {
+	Put(ctx context.Context, job *Job) error
+	Get(ctx context.Context, id string) (*Job, error)
+	List(ctx context.Context) ([]*Job, error)
+
+	// Delete removes a job and any output; unknown ids are not an error.
+	Delete(ctx context.Context, id string) error
+
+	// Create returns a writer for a job's output.
+	Create(ctx context.Context, id string) (Writer, error)
+	Open(ctx context.Context, id string) (io.ReadCloser, error)
+}
+
+// A Writer receives a job's output.
+// The output is not visible to Open until Close; Abort discards it instead.
+type Writer interface {
+	io.WriteCloser
+	Abort() error
+}
+
+// A QueryFunc writes the CSV results of the census query described by args to w.
+type QueryFunc func(ctx context.Context, w io.Writer, args geodata.CensusQuerySQLArgs) error
+
+// sweepEvery is how often the Manager looks for expired jobs.
+const sweepEvery = 10 * time.Minute
+
+// A Manager queues jobs and runs them in the background.
+type Manager struct {
+	store   Store
+	query   QueryFunc
+	workers int
+	ttl     time.Duration // how long finished jobs are kept; 0 keeps them forever
+
+	mu      sync.Mutex // protects pending and closed
+	cond    *sync.Cond // signalled when pending grows or closed is set
+	pending []string   // ids of queued jobs, oldest first
+	closed  bool
+
+	cancel context.CancelFunc // stops running jobs
+	wg     sync.WaitGroup     // running workers and sweeper
+}
+
+// New sets up a Manager.
+// Finished jobs are deleted once they have not been updated for ttl; 0 keeps them
+// forever.
+// Nothing is run until Start is called.
+func New(store Store, query QueryFunc, workers int, ttl time.Duration) *Manager {
+	if workers < 1 {
+		workers = 1
+	}
+	m := &Manager{
+		store:   store,
+		query:   query,
+		workers: workers,
+		ttl:     ttl,
+	}
+	m.cond = sync.NewCond(&m.mu)
+	return m
+}
+
+// Start requeues jobs left unfinished by a previous run, and starts the workers and
+// the sweeper.
+func (m *Manager) Start(ctx context.Context) error {
+	jobs, err := m.store.List(ctx)
+	if err != nil {
+		return err
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].Created.Before(jobs[j].Created)
+	})
+	for _, job := range jobs {
+		if job.Status == StatusQueued || job.Status == StatusRunning {
+			log.Info(ctx, "requeueing export", log.Data{"id": job.ID, "status": job.Status})
+			m.enqueue(job.ID)
+		}
+	}
+
+	ctx, m.cancel = context.WithCancel(ctx)
+	for i := 0; i < m.workers; i++ {
+		m.wg.Add(1)
+		go m.worker(ctx)
+	}
+	if m.ttl > 0 {
+		m.wg.Add(1)
+		go m.sweeper(ctx)
+	}
+	return nil
+}
+
+// Close stops the workers and sweeper, and waits for them to finish.
+// Jobs that are interrupted stay in the running state, so the next Start runs them again.
+func (m *Manager) Close() {
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+	m.cond.Broadcast()
+
+	if m.cancel != nil {
+		m.cancel()
+	}
+	m.wg.Wait()
+}
+
+// Submit saves a new job and queues it.
+func (m *Manager) Submit(ctx context.Context, args geodata.CensusQuerySQLArgs) (*Job, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	job := &Job{
+		ID:      id,
+		Status:  StatusQueued,
+		Args:    args,
+		Created: now,
+		Updated: now,
+	}
+	if err := m.store.Put(ctx, job); err != nil {
+		return nil, err
+	}
+	m.enqueue(id)
+	return job, nil
+}
+
+// Get returns the current state of a job.
+func (m *Manager) Get(ctx context.Context, id string) (*Job, error) {
+	return m.store.Get(ctx, id)
+}
+
+// Open returns the output of a complete job.
+// The returned error wraps sentinel.ErrNotReady if the job is not complete.
+func (m *Manager) Open(ctx context.Context, id string) (*Job, io.ReadCloser, error) {
+	job, err := m.store.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if job.Status != StatusComplete {
+		return nil, nil, fmt.Errorf("%w: export %s is %s", sentinel.ErrNotReady, id, job.Status)
+	}
+	r, err := m.store.Open(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return job, r, nil
+}
+
+// Sweep deletes finished jobs which have not been updated for the Manager's ttl, and
+// returns how many it deleted.
+// Queued and running jobs are never deleted.
+func (m *Manager) Sweep(ctx context.Context) (int, error) {
+	if m.ttl <= 0 {
+		return 0, nil
+	}
+	jobs, err := m.store.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().UTC().Add(-m.ttl)
+	var n int
+	for _, job := range jobs {
+		if job.Status != StatusComplete && job.Status != StatusFailed {
+			continue
+		}
+		if !job.Updated.Before(cutoff) {
+			continue
+		}
+		if err := m.store.Delete(ctx, job.ID); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// sweeper runs Sweep every sweepEvery until ctx is done.
+func (m *Manager) sweeper(ctx context.Context) {
+	defer m.wg.Done()
+	ticker := time.NewTicker(sweepEvery)
+	defer ticker.Stop()
+	for {
+		n, err := m.Sweep(ctx)
+		if err != nil {
+			log.Error(ctx, "sweeping exports", err)
+		} else if n > 0 {
+			log.Info(ctx, "deleted expired exports", log.Data{"deleted": n})
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *Manager) enqueue(id string) {
+	m.mu.Lock()
+	m.pending = append(m.pending, id)
+	m.mu.Unlock()
+	m.cond.Signal()
+}
+
+// next waits for a queued job.
+// ok is false once the Manager is closed.
+func (m *Manager) next() (id string, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for len(m.pending) == 0 && !m.closed {
+		m.cond.Wait()
+	}
+	if m.closed {
+		return "", false
+	}
+	id = m.pending[0]
+	m.pending = m.pending[1:]
+	return id, true
+}
+
+func (m *Manager) worker(ctx context.Context) {
+	defer m.wg.Done()
+	for {
+		id, ok := m.next()
+		if !ok {
+			return
+		}
+		if err := m.run(ctx, id); err != nil {
+			log.Error(ctx, "export", err, log.Data{"id": id})
+		}
+	}
+}
+
+// run runs a single job and records the outcome in the store.
+// The returned error is only for problems with the store itself.
+func (m *Manager) run(ctx context.Context, id string) error {
+	job, err := m.store.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	job.Status = StatusRunning
+	job.Updated = time.Now().UTC()
+	if err := m.store.Put(ctx, job); err != nil {
+		return err
+	}
+	log.Info(ctx, "running export", log.Data{"id": id})
+
+	size, err := m.write(ctx, job)
+
+	if ctx.Err() != nil {
+		// shutting down; leave the job running so it is picked up on restart
+		return nil
+	}
+
+	job.Updated = time.Now().UTC()
+	if err != nil {
+		job.Status = StatusFailed
+		job.Error = err.Error()
+	} else {
+		job.Status = StatusComplete
+		job.Size = size
+	}
+	log.Info(ctx, "export finished", log.Data{"id": id, "status": job.Status, "size": job.Size})
+	return m.store.Put(ctx, job)
+}
+
+// write runs the query for job, saving the output in the store.
+// If the query fails, the partial output is discarded.
+func (m *Manager) write(ctx context.Context, job *Job) (int64, error) {
+	w, err := m.store.Create(ctx, job.ID)
+	if err != nil {
+		return 0, err
+	}
+	cw := &countWriter{w: w}
+	if err := m.query(ctx, cw, job.Args); err != nil {
+		if aerr := w.Abort(); aerr != nil {
+			log.Error(ctx, "discarding export output", aerr, log.Data{"id": job.ID})
+		}
+		return cw.n, err
+	}
+	return cw.n, w.Close()
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// newID returns a random job id.
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,219 @@
+package exports_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-geodata-api/pkg/exports"
+	"github.com/ONSdigital/dp-geodata-api/pkg/geodata"
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+// waitFor polls until the job reaches status, or fails the test.
+func waitFor(t *testing.T, m *exports.Manager, id string, status exports.Status) *exports.Job {
+	t.Helper()
+	for i := 0; i < 200; i++ {
+		job, err := m.Get(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status == status {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("export %s never reached %s", id, status)
+	return nil
+}
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := exports.NewFSStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := func(ctx context.Context, w io.Writer, args geodata.CensusQuerySQLArgs) error {
+		if args.Censustable == "bad" {
+			io.WriteString(w, "geography_code,cat\n")
+			return errors.New("query failed")
+		}
+		_, err := io.WriteString(w, "geography_code,cat\nE01000001,1\n")
+		return err
+	}
+
+	m := exports.New(store, query, 2, 0)
+	if err := m.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	good, err := m.Submit(ctx, geodata.CensusQuerySQLArgs{Year: 2011, Geos: []string{"ALL"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bad, err := m.Submit(ctx, geodata.CensusQuerySQLArgs{Year: 2011, Censustable: "bad"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job := waitFor(t, m, good.ID, exports.StatusComplete)
+	if job.Size != 31 {
+		t.Errorf("size %d, want 31", job.Size)
+	}
+	if job.Args.Geos[0] != "ALL" {
+		t.Errorf("args not saved: %+v", job.Args)
+	}
+	_, r, err := m.Open(ctx, good.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(r)
+	r.Close()
+	if string(body) != "geography_code,cat\nE01000001,1\n" {
+		t.Errorf("output %q", body)
+	}
+
+	job = waitFor(t, m, bad.ID, exports.StatusFailed)
+	if job.Error != "query failed" {
+		t.Errorf("error %q, want %q", job.Error, "query failed")
+	}
+	if _, _, err := m.Open(ctx, bad.ID); !errors.Is(err, sentinel.ErrNotReady) {
+		t.Errorf("open failed export: got %v, want %s", err, sentinel.ErrNotReady)
+	}
+
+	// the partial output of the failed export is discarded
+	if _, err := store.Open(ctx, bad.ID); !errors.Is(err, sentinel.ErrNotFound) {
+		t.Errorf("failed export output: got %v, want %s", err, sentinel.ErrNotFound)
+	}
+	if temps, _ := filepath.Glob(filepath.Join(dir, ".*")); len(temps) != 0 {
+		t.Errorf("temporary files left behind: %v", temps)
+	}
+
+	if _, err := m.Get(ctx, "0123"); !errors.Is(err, sentinel.ErrNotFound) {
+		t.Errorf("unknown id: got %v, want %s", err, sentinel.ErrNotFound)
+	}
+	if _, err := m.Get(ctx, "../etc/passwd"); !errors.Is(err, sentinel.ErrNotFound) {
+		t.Errorf("bad id: got %v, want %s", err, sentinel.ErrNotFound)
+	}
+}
+
+func TestManagerRestart(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := exports.NewFSStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// first run: the query blocks until shutdown
+	started := make(chan struct{})
+	blocked := func(ctx context.Context, w io.Writer, args geodata.CensusQuerySQLArgs) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	m := exports.New(store, blocked, 1, 0)
+	if err := m.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	running, err := m.Submit(ctx, geodata.CensusQuerySQLArgs{Year: 2011})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	queued, err := m.Submit(ctx, geodata.CensusQuerySQLArgs{Year: 2011})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Close()
+
+	job, _ := m.Get(ctx, running.ID)
+	if job.Status != exports.StatusRunning {
+		t.Fatalf("interrupted export is %s, want %s", job.Status, exports.StatusRunning)
+	}
+
+	// second run, with a fresh store on the same directory, which clears up after
+	// a crash
+	temp := filepath.Join(dir, "."+running.ID+".csv.123")
+	if err := os.WriteFile(temp, []byte("partial"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store, err = exports.NewFSStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok := func(ctx context.Context, w io.Writer, args geodata.CensusQuerySQLArgs) error {
+		_, err := io.WriteString(w, "x\n")
+		return err
+	}
+	m = exports.New(store, ok, 1, 0)
+	if err := m.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	if _, err := os.Stat(temp); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("temporary file from previous run: got %v, want %s", err, os.ErrNotExist)
+	}
+
+	waitFor(t, m, running.ID, exports.StatusComplete)
+	waitFor(t, m, queued.ID, exports.StatusComplete)
+}
+
+func TestManagerSweep(t *testing.T) {
+	ctx := context.Background()
+	store, err := exports.NewFSStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := func(ctx context.Context, w io.Writer, args geodata.CensusQuerySQLArgs) error {
+		if args.Censustable == "slow" {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		_, err := io.WriteString(w, "x\n")
+		return err
+	}
+	m := exports.New(store, query, 2, time.Millisecond)
+	if err := m.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	done, err := m.Submit(ctx, geodata.CensusQuerySQLArgs{Year: 2011})
+	if err != nil {
+		t.Fatal(err)
+	}
+	running, err := m.Submit(ctx, geodata.CensusQuerySQLArgs{Year: 2011, Censustable: "slow"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, m, done.ID, exports.StatusComplete)
+	waitFor(t, m, running.ID, exports.StatusRunning)
+	time.Sleep(5 * time.Millisecond)
+
+	n, err := m.Sweep(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("swept %d exports, want 1", n)
+	}
+	if _, err := m.Get(ctx, done.ID); !errors.Is(err, sentinel.ErrNotFound) {
+		t.Errorf("expired export: got %v, want %s", err, sentinel.ErrNotFound)
+	}
+	if _, err := store.Open(ctx, done.ID); !errors.Is(err, sentinel.ErrNotFound) {
+		t.Errorf("expired export output: got %v, want %s", err, sentinel.ErrNotFound)
+	}
+	if _, err := m.Get(ctx, running.ID); err != nil {
+		t.Errorf("running export swept: %s", err)
+	}
+}
@@ -0,0 +1,177 @@
+package exports
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+// FSStore is a Store that keeps each job in a directory on the local filesystem.
+// Job state is in <id>.json and output is in <id>.csv.
+// Files are written under temporary names, beginning with ".", and renamed into place,
+// so readers never see partial files.
+// Only one process should use the directory at a time.
+type FSStore struct {
+	dir string
+}
+
+// NewFSStore returns a Store using dir, creating it if needed.
+// Temporary files left by a previous process are removed; their jobs are rerun by
+// Manager.Start.
+func NewFSStore(dir string) (*FSStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	temps, err := filepath.Glob(filepath.Join(dir, ".*"))
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range temps {
+		if err := os.Remove(name); err != nil {
+			return nil, err
+		}
+	}
+	return &FSStore{dir: dir}, nil
+}
+
+func (s *FSStore) Put(ctx context.Context, job *Job) error {
+	if err := checkID(job.ID); err != nil {
+		return err
+	}
+	buf, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(s.dir, "."+job.ID+".json.*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(buf)
+	return s.finish(f, err, s.path(job.ID, ".json"))
+}
+
+func (s *FSStore) Get(ctx context.Context, id string) (*Job, error) {
+	if err := checkID(id); err != nil {
+		return nil, err
+	}
+	buf, err := os.ReadFile(s.path(id, ".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: export %s", sentinel.ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var job Job
+	if err := json.Unmarshal(buf, &job); err != nil {
+		return nil, fmt.Errorf("export %s: %w", id, err)
+	}
+	return &job, nil
+}
+
+func (s *FSStore) List(ctx context.Context) ([]*Job, error) {
+	names, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []*Job
+	for _, name := range names {
+		job, err := s.Get(ctx, strings.TrimSuffix(filepath.Base(name), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// Delete removes the output before the job, so if anything fails, the job is still
+// listed and the next sweep tries again.
+func (s *FSStore) Delete(ctx context.Context, id string) error {
+	if err := checkID(id); err != nil {
+		return err
+	}
+	for _, ext := range []string{".csv", ".json"} {
+		if err := os.Remove(s.path(id, ext)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *FSStore) Create(ctx context.Context, id string) (Writer, error) {
+	if err := checkID(id); err != nil {
+		return nil, err
+	}
+	f, err := os.CreateTemp(s.dir, "."+id+".csv.*")
+	if err != nil {
+		return nil, err
+	}
+	return &fsWriter{File: f, store: s, path: s.path(id, ".csv")}, nil
+}
+
+func (s *FSStore) Open(ctx context.Context, id string) (io.ReadCloser, error) {
+	if err := checkID(id); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(s.path(id, ".csv"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: export %s output", sentinel.ErrNotFound, id)
+	}
+	return f, err
+}
+
+func (s *FSStore) path(id, ext string) string {
+	return filepath.Join(s.dir, id+ext)
+}
+
+// finish closes temporary file f and renames it to path.
+// If err is not nil, or anything goes wrong, f is removed.
+func (s *FSStore) finish(f *os.File, err error, path string) error {
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// An fsWriter renames its temporary file into place on Close, or removes it on Abort.
+type fsWriter struct {
+	*os.File
+	store *FSStore
+	path  string
+}
+
+func (w *fsWriter) Close() error {
+	return w.store.finish(w.File, nil, w.path)
+}
+
+func (w *fsWriter) Abort() error {
+	w.File.Close()
+	return os.Remove(w.File.Name())
+}
+
+// checkID stops ids from escaping the store directory.
+func checkID(id string) error {
+	if id == "" || strings.HasPrefix(id, ".") || strings.ContainsAny(id, `/\`) {
+		return fmt.Errorf("%w: export %q", sentinel.ErrNotFound, id)
+	}
+	return nil
+}
@@ -0,0 +1,394 @@
+package geodata
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"sort"
+
+	"github.com/ONSdigital/dp-geodata-api/data-tiles/cat"
+	"github.com/ONSdigital/dp-geodata-api/data-tiles/types"
+	"github.com/ONSdigital/dp-geodata-api/pkg/table"
+	"github.com/ONSdigital/dp-geodata-api/pkg/timer"
+	"github.com/ONSdigital/dp-geodata-api/pkg/tracing"
+	"github.com/ONSdigital/dp-geodata-api/pkg/where"
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// defaultAggregateGeotype is the geotype summed over when an aggregate is defined by a
+// polygon or radius and no geotype is given.
+const defaultAggregateGeotype = "OA"
+
+// percentSuffix is appended to a category code to name its percentage column.
+const percentSuffix = "_percent"
+
+type AggregateArgs struct {
+	Year     int
+	Geos     []string
+	Location string
+	Radius   int
+	Polygon  string
+	Geotype  string
+	Cols     []string
+}
+
+// Aggregate sums the categories in args.Cols over all the areas making up a
+// user-defined area, and returns the result as a single row of CSV.
+//
+// The user-defined area is either a list of geocodes, a polygon, or a location and radius.
+// Spatial areas are made up of "best fit" areas of a single geotype: an area is included
+// if its centroid falls within the polygon or circle.
+// Areas which are included but extend beyond the edge are counted as clipped.
+//
+// Percentages are recomputed from the summed totals category for every category
+// whose totals category is known.
+//
+func (app *Geodata) Aggregate(ctx context.Context, args AggregateArgs) ([]byte, error) {
+	ctx, span := tracing.Start(ctx, "Geodata.Aggregate")
+	defer span.End()
+
+	catset, err := aggregateCats(args.Cols)
+	if err != nil {
+		return nil, err
+	}
+
+	sql, values, err := AggregateAreasSQL(args)
+	if err != nil {
+		return nil, err
+	}
+	log.Info(ctx, "sql", log.Data{"query": sql, "args": values})
+
+	ids, geotype, clipped, err := app.aggregateAreas(ctx, sql, values)
+	if err != nil {
+		return nil, err
+	}
+	if geotype == "" {
+		geotype = args.Geotype
+	}
+
+	sums := map[string]float64{}
+	if len(ids) > 0 {
+		sql, values = AggregateMetricsSQL(args.Year, ids, catset)
+		log.Info(ctx, "sql", log.Data{"query": sql, "args": values})
+
+		sums, err = app.aggregateMetrics(ctx, sql, values)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var body bytes.Buffer
+	if err := writeAggregate(&body, geotype, len(ids), clipped, sums); err != nil {
+		return nil, err
+	}
+	return body.Bytes(), nil
+}
+
+// aggregateAreas runs the areas query, returning the ids of the selected areas, their
+// geotype, and the number of them that were clipped.
+func (app *Geodata) aggregateAreas(ctx context.Context, sql string, values []interface{}) (ids []int64, geotype string, clipped int, err error) {
+	t := timer.New("areas")
+	t.Start()
+	rows, err := app.db.DB().QueryContext(ctx, sql, values...)
+	t.Stop()
+	t.Log(ctx)
+	if err != nil {
+		return nil, "", 0, queryError(ctx, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var gt string
+		var isClipped bool
+		if err := rows.Scan(&id, &gt, &isClipped); err != nil {
+			return nil, "", 0, err
+		}
+		if geotype != "" && gt != geotype {
+			return nil, "", 0, fmt.Errorf("%w: areas must all be the same geotype (found %s and %s)", sentinel.ErrInvalidParams, geotype, gt)
+		}
+		geotype = gt
+		ids = append(ids, id)
+		if isClipped {
+			clipped++
+		}
+	}
+	return ids, geotype, clipped, queryError(ctx, rows.Err())
+}
+
+// aggregateMetrics runs the metrics query, returning the sum of each category.
+func (app *Geodata) aggregateMetrics(ctx context.Context, sql string, values []interface{}) (map[string]float64, error) {
+	t := timer.New("query")
+	t.Start()
+	rows, err := app.db.DB().QueryContext(ctx, sql, values...)
+	t.Stop()
+	t.Log(ctx)
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+	defer rows.Close()
+
+	sums := map[string]float64{}
+	for rows.Next() {
+		var code string
+		var sum float64
+		if err := rows.Scan(&code, &sum); err != nil {
+			return nil, err
+		}
+		sums[code] = sum
+	}
+	return sums, queryError(ctx, rows.Err())
+}
+
+// AggregateAreasSQL returns a query selecting the id, geotype and clipped flag of each
+// area making up the user-defined area in args, along with the values for its placeholders.
+//
+func AggregateAreasSQL(args AggregateArgs) (string, []interface{}, error) {
+	var sqlArgs where.Args
+
+	var selectors int
+	for _, given := range []bool{
+		len(args.Geos) > 0,
+		args.Polygon != "",
+		args.Location != "" || args.Radius != 0,
+	} {
+		if given {
+			selectors++
+		}
+	}
+	if selectors == 0 {
+		return "", nil, fmt.Errorf("%w: must specify rows, polygon, or location/radius", sentinel.ErrMissingParams)
+	}
+	if selectors > 1 {
+		return "", nil, fmt.Errorf("%w: only one of rows, polygon, or location/radius may be given", sentinel.ErrInvalidParams)
+	}
+
+	geotype := args.Geotype
+	if geotype == "" && len(args.Geos) == 0 {
+		geotype = defaultAggregateGeotype
+	}
+
+	var clippedSQL string
+	var geoConditions string
+	switch {
+	case len(args.Geos) > 0:
+		set, err := where.ParseMultiArgs(args.Geos)
+		if err != nil {
+			return "", nil, err
+		}
+		if err := ValidateAllToken(set); err != nil {
+			return "", nil, err
+		}
+		if wantAllRows(args.Geos) {
+			return "", nil, fmt.Errorf("%w: rows=ALL cannot be aggregated", sentinel.ErrInvalidParams)
+		}
+		clippedSQL = "FALSE"
+		geoConditions = where.WherePart("geo.code", set, &sqlArgs)
+
+	case args.Polygon != "":
+		linestring, err := parsePolygon(args.Polygon)
+		if err != nil {
+			return "", nil, err
+		}
+		clippedSQL = fmt.Sprintf(
+			"NOT ST_Covers(%s, geo.wkb_geometry)",
+			polygonExpr(linestring, &sqlArgs),
+		)
+		geoConditions = fmt.Sprintf(
+			"ST_Covers(%s, geo.wkb_long_lat_geom)",
+			polygonExpr(linestring, &sqlArgs),
+		)
+
+	default:
+		coords, err := parseRadius(args.Location, args.Radius)
+		if err != nil {
+			return "", nil, err
+		}
+		clippedSQL = fmt.Sprintf(
+			"NOT ST_Covers(%s, geo.wkb_geometry)",
+			circleExpr(coords, args.Radius, &sqlArgs),
+		)
+		radiusCondition, err := radiusSQL(args.Location, args.Radius, &sqlArgs)
+		if err != nil {
+			return "", nil, err
+		}
+		geoConditions = radiusCondition
+	}
+
+	var geotypeConditions string
+	if geotype != "" {
+		var err error
+		geotypeConditions, err = geotypeSQL("geo_type.name", []string{geotype}, &sqlArgs)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	template := `
+SELECT
+	geo.id,
+	geo_type.name AS geotype,
+	%s AS clipped
+FROM
+	geo,
+	geo_type
+WHERE geo.valid
+AND geo_type.id = geo.type_id
+	-- geotype conditions:
+%s
+	-- geo conditions:
+AND (
+%s
+)
+`
+	sql := fmt.Sprintf(
+		template,
+		clippedSQL,
+		geotypeConditions,
+		geoConditions,
+	)
+	return sql, sqlArgs.Values(), nil
+}
+
+// circleExpr returns an SQL geometry expression for the circle of radius metres
+// around the lon,lat pair in coords.
+func circleExpr(coords []float64, radius int, sqlArgs *where.Args) string {
+	return fmt.Sprintf(`ST_Buffer(
+		ST_SetSRID(
+			ST_Point(%s, %s),
+			4326
+		)::geography,
+		%s
+	)::geometry`,
+		sqlArgs.Add(coords[0]),
+		sqlArgs.Add(coords[1]),
+		sqlArgs.Add(radius),
+	)
+}
+
+// AggregateMetricsSQL returns a query summing each category in catset over the areas
+// in ids, along with the values for its placeholders.
+//
+func AggregateMetricsSQL(year int, ids []int64, catset *where.ValueSet) (string, []interface{}) {
+	var sqlArgs where.Args
+
+	template := `
+SELECT
+	nomis_category.long_nomis_code AS category_code,
+	SUM(geo_metric.metric) AS value
+FROM
+	geo_metric,
+	data_ver,
+	nomis_category
+WHERE geo_metric.geo_id = ANY( %s )
+AND data_ver.id = geo_metric.data_ver_id
+AND data_ver.census_year = %s
+AND data_ver.ver_string = '2.2'
+AND nomis_category.id = geo_metric.category_id
+AND nomis_category.year = data_ver.census_year
+	-- category conditions:
+AND (
+%s
+)
+GROUP BY nomis_category.long_nomis_code
+`
+	sql := fmt.Sprintf(
+		template,
+		sqlArgs.Add(ids),
+		sqlArgs.Add(year),
+		where.WherePart("nomis_category.long_nomis_code", catset, &sqlArgs),
+	)
+	return sql, sqlArgs.Values()
+}
+
+// aggregateCats parses cols into a ValueSet, adding the totals category for every
+// category and range end so percentages can be calculated.
+func aggregateCats(cols []string) (*where.ValueSet, error) {
+	catset, err := where.ParseMultiArgs(cols)
+	if err != nil {
+		return nil, err
+	}
+	if len(catset.Singles) == 0 && len(catset.Ranges) == 0 {
+		return nil, fmt.Errorf("%w: must specify cols", sentinel.ErrMissingParams)
+	}
+
+	totals := map[string]bool{}
+	callback := func(single, low, high *string) (*string, *string, *string, error) {
+		for _, code := range []*string{single, low, high} {
+			if code == nil {
+				continue
+			}
+			if isSpecialCol(*code) {
+				return nil, nil, nil, fmt.Errorf("%w: %s cannot be aggregated", sentinel.ErrInvalidParams, *code)
+			}
+			if totcat, err := cat.GuessTotalsCat(types.Category(*code)); err == nil {
+				totals[string(totcat)] = true
+			}
+		}
+		return single, low, high, nil
+	}
+	catset, err = catset.Walk(callback)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, single := range catset.Singles {
+		delete(totals, single)
+	}
+	for totcat := range totals {
+		catset.AddSingle(totcat)
+	}
+	sort.Strings(catset.Singles)
+	return catset, nil
+}
+
+// writeAggregate writes the single row aggregate result as CSV.
+// Each category in sums gets a column, followed by a percentage column for each category
+// whose totals category is also in sums.
+func writeAggregate(w *bytes.Buffer, geotype string, areas, clipped int, sums map[string]float64) error {
+	var codes []string
+	for code := range sums {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	header := []string{"geotype", "areas", "clipped"}
+	row := []string{geotype, fmt.Sprint(areas), fmt.Sprint(clipped)}
+	for _, code := range codes {
+		header = append(header, code)
+		row = append(row, table.FormatValue(sums[code]))
+	}
+	for _, code := range codes {
+		total, ok := totalFor(code, sums)
+		if !ok {
+			continue
+		}
+		header = append(header, code+percentSuffix)
+		row = append(row, table.FormatValue(100*sums[code]/total))
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	if err := cw.Write(row); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// totalFor returns the sum of the totals category for code, if it is known and non-zero.
+func totalFor(code string, sums map[string]float64) (float64, bool) {
+	totcat, err := cat.GuessTotalsCat(types.Category(code))
+	if err != nil {
+		return 0, false
+	}
+	total, ok := sums[string(totcat)]
+	if !ok || total == 0 {
+		return 0, false
+	}
+	return total, true
+}
@@ -0,0 +1,227 @@
+package geodata
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-geodata-api/pkg/where"
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+const testPolygon = "0.0844,51.4897,0.1214,51.4910,0.1338,51.4635,0.1017,51.4647,0.0844,51.4897"
+
+func TestAggregateAreasSQL_Errors(t *testing.T) {
+	var tests = map[string]struct {
+		args    AggregateArgs
+		wantErr error
+	}{
+		"no selector": {
+			args:    AggregateArgs{Year: 2011},
+			wantErr: sentinel.ErrMissingParams,
+		},
+		"two selectors": {
+			args:    AggregateArgs{Year: 2011, Geos: []string{"E01000001"}, Polygon: testPolygon},
+			wantErr: sentinel.ErrInvalidParams,
+		},
+		"rows=ALL": {
+			args:    AggregateArgs{Year: 2011, Geos: []string{"ALL"}},
+			wantErr: sentinel.ErrInvalidParams,
+		},
+		"radius without location": {
+			args:    AggregateArgs{Year: 2011, Radius: 1000},
+			wantErr: sentinel.ErrInvalidParams,
+		},
+		"open polygon": {
+			args:    AggregateArgs{Year: 2011, Polygon: "0.0844,51.4897,0.1214,51.4910,0.1338,51.4635,0.1017,51.4647"},
+			wantErr: sentinel.ErrInvalidParams,
+		},
+		"bad geotype": {
+			args:    AggregateArgs{Year: 2011, Polygon: testPolygon, Geotype: "nope"},
+			wantErr: sentinel.ErrInvalidParams,
+		},
+	}
+
+	for desc, test := range tests {
+		_, _, err := AggregateAreasSQL(test.args)
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("%s: got error %v, want %v", desc, err, test.wantErr)
+		}
+	}
+}
+
+func TestAggregateAreasSQL(t *testing.T) {
+	var tests = map[string]struct {
+		args     AggregateArgs
+		wantSQL  []string
+		wantArgs []interface{}
+	}{
+		"rows": {
+			args: AggregateArgs{Year: 2011, Geos: []string{"E01000001,E01000002"}},
+			wantSQL: []string{
+				"FALSE AS clipped",
+				"geo.code = ANY( $1 )",
+			},
+			wantArgs: []interface{}{[]string{"E01000001", "E01000002"}},
+		},
+		"polygon defaults to OA": {
+			args: AggregateArgs{Year: 2011, Polygon: testPolygon},
+			wantSQL: []string{
+				"NOT ST_Covers(ST_Polygon(",
+				"ST_GeomFromText($1)",
+				"geo.wkb_geometry) AS clipped",
+				"ST_GeomFromText($2)",
+				"geo.wkb_long_lat_geom)",
+				"geo_type.name = ANY( $3 )",
+			},
+			wantArgs: []interface{}{
+				"LINESTRING (0.0844 51.4897,0.1214 51.491,0.1338 51.4635,0.1017 51.4647,0.0844 51.4897)",
+				"LINESTRING (0.0844 51.4897,0.1214 51.491,0.1338 51.4635,0.1017 51.4647,0.0844 51.4897)",
+				[]string{"OA"},
+			},
+		},
+		"radius with geotype": {
+			args: AggregateArgs{Year: 2011, Location: "0.1338,51.4635", Radius: 1000, Geotype: "lsoa"},
+			wantSQL: []string{
+				"NOT ST_Covers(ST_Buffer(",
+				"ST_Point($1, $2)",
+				"::geometry, geo.wkb_geometry) AS clipped",
+				"ST_DWithin(",
+				"ST_Point($4, $5)",
+				"geo_type.name = ANY( $7 )",
+			},
+			wantArgs: []interface{}{0.1338, 51.4635, 1000, 0.1338, 51.4635, 1000, []string{"LSOA"}},
+		},
+	}
+
+	for desc, test := range tests {
+		sql, values, err := AggregateAreasSQL(test.args)
+		if err != nil {
+			t.Errorf("%s: %s", desc, err)
+			continue
+		}
+		flat := strings.Join(strings.Fields(sql), " ")
+		for _, want := range test.wantSQL {
+			if !strings.Contains(flat, want) {
+				t.Errorf("%s: SQL does not contain %q:\n%s", desc, want, flat)
+			}
+		}
+		if !reflect.DeepEqual(values, test.wantArgs) {
+			t.Errorf("%s: got args %#v, want %#v", desc, values, test.wantArgs)
+		}
+	}
+}
+
+func TestAggregateMetricsSQL(t *testing.T) {
+	set := where.NewValueSet()
+	set.AddSingle("QS101EW0001")
+	set.AddRange("QS101EW0002", "QS101EW0004")
+
+	sql, values := AggregateMetricsSQL(2011, []int64{1, 2}, set)
+
+	flat := strings.Join(strings.Fields(sql), " ")
+	for _, want := range []string{
+		"geo_metric.geo_id = ANY( $1 )",
+		"data_ver.census_year = $2",
+		"nomis_category.long_nomis_code = ANY( $3 )",
+		"nomis_category.long_nomis_code BETWEEN $4 AND $5",
+		"GROUP BY nomis_category.long_nomis_code",
+	} {
+		if !strings.Contains(flat, want) {
+			t.Errorf("SQL does not contain %q:\n%s", want, flat)
+		}
+	}
+	wantArgs := []interface{}{[]int64{1, 2}, 2011, []string{"QS101EW0001"}, "QS101EW0002", "QS101EW0004"}
+	if !reflect.DeepEqual(values, wantArgs) {
+		t.Errorf("got args %#v, want %#v", values, wantArgs)
+	}
+}
+
+func TestAggregateCats(t *testing.T) {
+	var tests = map[string]struct {
+		cols        []string
+		wantSingles []string
+		wantErr     error
+	}{
+		"no cols": {
+			wantErr: sentinel.ErrMissingParams,
+		},
+		"special col": {
+			cols:    []string{"geography_code"},
+			wantErr: sentinel.ErrInvalidParams,
+		},
+		"adds totals for singles": {
+			cols:        []string{"QS402EW0012,QS101EW0002"},
+			wantSingles: []string{"QS101EW0001", "QS101EW0002", "QS402EW0001", "QS402EW0012"},
+		},
+		"adds totals for ranges": {
+			cols:        []string{"QS101EW0002...QS101EW0004"},
+			wantSingles: []string{"QS101EW0001"},
+		},
+		"totals not repeated": {
+			cols:        []string{"QS101EW0001,QS101EW0002"},
+			wantSingles: []string{"QS101EW0001", "QS101EW0002"},
+		},
+	}
+
+	for desc, test := range tests {
+		set, err := aggregateCats(test.cols)
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("%s: got error %v, want %v", desc, err, test.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if !reflect.DeepEqual(set.Singles, test.wantSingles) {
+			t.Errorf("%s: got singles %v, want %v", desc, set.Singles, test.wantSingles)
+		}
+	}
+}
+
+func TestWriteAggregate(t *testing.T) {
+	var tests = map[string]struct {
+		sums map[string]float64
+		want string
+	}{
+		"no metrics": {
+			sums: map[string]float64{},
+			want: "geotype,areas,clipped\nOA,0,0\n",
+		},
+		"percentages from totals": {
+			sums: map[string]float64{
+				"QS101EW0001": 200,
+				"QS101EW0002": 50,
+				"QS101EW0003": 150,
+			},
+			want: "geotype,areas,clipped,QS101EW0001,QS101EW0002,QS101EW0003,QS101EW0002_percent,QS101EW0003_percent\n" +
+				"OA,0,0,200,50,150,25,75\n",
+		},
+		"no totals": {
+			sums: map[string]float64{
+				"QS101EW0002": 50,
+			},
+			want: "geotype,areas,clipped,QS101EW0002\nOA,0,0,50\n",
+		},
+		"zero totals": {
+			sums: map[string]float64{
+				"QS101EW0001": 0,
+				"QS101EW0002": 0,
+			},
+			want: "geotype,areas,clipped,QS101EW0001,QS101EW0002\nOA,0,0,0,0\n",
+		},
+	}
+
+	for desc, test := range tests {
+		var buf bytes.Buffer
+		if err := writeAggregate(&buf, "OA", 0, 0, test.sums); err != nil {
+			t.Errorf("%s: %s", desc, err)
+			continue
+		}
+		if buf.String() != test.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", desc, buf.String(), test.want)
+		}
+	}
+}
@@ -0,0 +1,347 @@
+package geodata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dp-geodata-api/model"
+	"github.com/ONSdigital/dp-geodata-api/pkg/classify"
+	"github.com/ONSdigital/dp-geodata-api/pkg/tracing"
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// Breaks for /ckmeans are precomputed at ingest and kept in geo_breaks, one row per
+// category, geotype, divide_by, k and method.
+// CKmeans uses them when it can, and only loads metrics for combinations which are
+// missing or stale.
+// Each row records data_ver.updated_at at the time it was computed, so breaks from data
+// which has since been reloaded are ignored until the ingest step is run again.
+
+// totalsSuffix ends the code of the category holding the total of its table, which
+// ratios are computed against, eg QS101EW0001.
+const totalsSuffix = "0001"
+
+// ComputeBreaks computes the breaks and class detail for every category and geotype in
+// year's data, for each k and method, both of the metrics themselves and of their ratio
+// to their table's totals category.
+// save is called with each result, ready to be stored in geo_breaks.
+//
+// Combinations that CKmeans would reject, such as ratios with zero denominators, are
+// logged and left out, so CKmeans will compute them, and report the error, itself.
+func (app *Geodata) ComputeBreaks(ctx context.Context, year int, geotypes []string, ks []int, methods []string, save func(model.GeoBreaks) error) error {
+	ctx, span := tracing.Start(ctx, "Geodata.ComputeBreaks")
+	defer span.End()
+
+	var verID int32
+	var updated time.Time
+	if err := app.db.DB().QueryRowContext(ctx, dataVerSQL, year).Scan(&verID, &updated); err != nil {
+		return fmt.Errorf("data version for %d: %w", year, queryError(ctx, err))
+	}
+
+	var classifications []classify.Method
+	for _, method := range methods {
+		classification, err := classify.ParseMethod(method)
+		if err != nil {
+			return err
+		}
+		classifications = append(classifications, classification)
+	}
+
+	groups, err := app.categoryGroups(ctx, year)
+	if err != nil {
+		return err
+	}
+
+	params := &CkmeansParams{
+		year: year,
+		db:   app.db.DB(),
+	}
+
+	// compute calculates every k and method over values
+	compute := func(values []float64, catcode, geotype, divideBy string) error {
+		for _, k := range ks {
+			for _, classification := range classifications {
+				params.k = k
+				params.method = classification
+				params.detail = true
+				params.breaks = map[string]map[string][]float64{}
+				if err := params.collectStats(values, nil, geotype, catcode); err != nil {
+					log.Warn(ctx, "cannot compute breaks", log.Data{"message": err.Error(), "category": catcode, "geotype": geotype, "divide_by": divideBy})
+					continue
+				}
+				stats, err := json.Marshal(params.breaks[catcode])
+				if err != nil {
+					return err
+				}
+				err = save(model.GeoBreaks{
+					DataVerID:     verID,
+					Category:      catcode,
+					Geotype:       geotype,
+					DivideBy:      divideBy,
+					K:             int32(k),
+					Method:        string(classification),
+					Stats:         string(stats),
+					DataUpdatedAt: updated,
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	values := []float64{}
+	denominator := map[string]float64{}
+	numerator := map[string]float64{}
+	for _, geotype := range geotypes {
+		for _, group := range groups {
+			if err := params.loadMetrics(ctx, geotype, group.totals, denominator); err != nil {
+				return err
+			}
+			for _, catcode := range group.catcodes {
+				if err := params.loadMetrics(ctx, geotype, catcode, numerator); err != nil {
+					return err
+				}
+				if len(numerator) == 0 {
+					continue
+				}
+
+				values = values[:0]
+				for _, value := range numerator {
+					values = append(values, value)
+				}
+				if err := compute(values, catcode, geotype, ""); err != nil {
+					return err
+				}
+
+				if catcode == group.totals || len(denominator) == 0 {
+					continue
+				}
+				values, _, err = ratios(values[:0], nil, numerator, denominator)
+				if err != nil {
+					log.Warn(ctx, "cannot compute ratio breaks", log.Data{"message": err.Error(), "category": catcode, "geotype": geotype, "divide_by": group.totals})
+					continue
+				}
+				if err := compute(values, catcode, geotype, group.totals); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// dataVerSQL finds the data version whose metrics CKmeans uses.
+const dataVerSQL = `
+SELECT
+	data_ver.id,
+	data_ver.updated_at
+FROM
+	data_ver
+WHERE data_ver.census_year = $1
+AND data_ver.ver_string = '2.2'
+`
+
+// categoryGroup is a totals category and the categories in its table.
+type categoryGroup struct {
+	totals   string
+	catcodes []string
+}
+
+// categoryGroups returns year's categories grouped by their totals category, so each
+// totals category's metrics need only be loaded once.
+func (app *Geodata) categoryGroups(ctx context.Context, year int) ([]categoryGroup, error) {
+	rows, err := app.db.DB().QueryContext(ctx, categoriesSQL, year)
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+	defer rows.Close()
+
+	var catcodes []string
+	for rows.Next() {
+		var catcode string
+		if err := rows.Scan(&catcode); err != nil {
+			return nil, err
+		}
+		catcodes = append(catcodes, catcode)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, queryError(ctx, err)
+	}
+	return groupCategories(catcodes), nil
+}
+
+// categoriesSQL lists the categories of a census year.
+const categoriesSQL = `
+SELECT
+	nomis_category.long_nomis_code
+FROM
+	nomis_category
+WHERE nomis_category.year = $1
+ORDER BY nomis_category.long_nomis_code
+`
+
+// groupCategories groups catcodes by their totals category, in order of totals category.
+func groupCategories(catcodes []string) []categoryGroup {
+	byTotals := map[string][]string{}
+	for _, catcode := range catcodes {
+		totals := totalsCategory(catcode)
+		byTotals[totals] = append(byTotals[totals], catcode)
+	}
+
+	var groups []categoryGroup
+	for totals, members := range byTotals {
+		sort.Strings(members)
+		groups = append(groups, categoryGroup{totals: totals, catcodes: members})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].totals < groups[j].totals
+	})
+	return groups
+}
+
+// totalsCategory returns the totals category of catcode's table, eg QS101EW0001 for
+// QS101EW0002.
+func totalsCategory(catcode string) string {
+	if len(catcode) < len(totalsSuffix) {
+		return catcode
+	}
+	return catcode[:len(catcode)-len(totalsSuffix)] + totalsSuffix
+}
+
+// ratios appends the ratio of each numerator to its denominator to values, and its
+// geocode to geocodes.
+// It fails with sentinel.ErrPartialContent if the two do not match, or
+// sentinel.ErrInvalidParams if a denominator is 0.
+func ratios(values []float64, geocodes []string, numerator, denominator map[string]float64) ([]float64, []string, error) {
+	if len(denominator) == 0 || len(numerator) != len(denominator) {
+		return values, geocodes, fmt.Errorf("%w: %d numerators but %d denominators", sentinel.ErrPartialContent, len(numerator), len(denominator))
+	}
+	for geocode, d := range denominator {
+		if d == 0 {
+			return values, geocodes, fmt.Errorf("%w: %s denominator is 0", sentinel.ErrInvalidParams, geocode)
+		}
+		n, ok := numerator[geocode]
+		if !ok {
+			return values, geocodes, fmt.Errorf("%w: %s has no numerator", sentinel.ErrPartialContent, geocode)
+		}
+		values = append(values, n/d)
+		geocodes = append(geocodes, geocode)
+	}
+	return values, geocodes, nil
+}
+
+// loadPrecomputed puts the fresh breaks in geo_breaks for the requested categories and
+// geotypes into params.precomputed.
+// Stale breaks are logged and ignored.
+func (params *CkmeansParams) loadPrecomputed(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "CkmeansParams.loadPrecomputed")
+	defer span.End()
+
+	rows, err := params.db.QueryContext(
+		ctx,
+		precomputedBreaksSQL,
+		params.year,
+		params.catcodes,
+		params.geotypes,
+		params.divideBy,
+		params.k,
+		string(params.method),
+	)
+	if err != nil {
+		return queryError(ctx, err)
+	}
+	defer rows.Close()
+
+	params.precomputed = map[string]map[string]map[string][]float64{}
+	var stale []string
+	for rows.Next() {
+		var catcode, geotype string
+		var body []byte
+		var fresh bool
+		if err := rows.Scan(&catcode, &geotype, &body, &fresh); err != nil {
+			return err
+		}
+		if !fresh {
+			stale = append(stale, catcode+" "+geotype)
+			continue
+		}
+		var stats map[string][]float64
+		if err := json.Unmarshal(body, &stats); err != nil {
+			return fmt.Errorf("geo_breaks %s %s: %w", catcode, geotype, err)
+		}
+		if params.precomputed[catcode] == nil {
+			params.precomputed[catcode] = map[string]map[string][]float64{}
+		}
+		params.precomputed[catcode][geotype] = precomputedStats(stats, geotype, params.detail)
+	}
+	if err := rows.Err(); err != nil {
+		return queryError(ctx, err)
+	}
+
+	if len(stale) > 0 {
+		log.Warn(ctx, "stale precomputed breaks; rerun dataingest/breaks", log.Data{"stale": strings.Join(stale, ",")})
+	}
+	return nil
+}
+
+// precomputedBreaksSQL finds the precomputed breaks for a set of categories and geotypes,
+// and whether the data has changed since they were computed.
+const precomputedBreaksSQL = `
+SELECT
+	geo_breaks.category,
+	geo_breaks.geotype,
+	geo_breaks.stats,
+	geo_breaks.data_updated_at = data_ver.updated_at AS fresh
+FROM
+	geo_breaks,
+	data_ver
+WHERE data_ver.id = geo_breaks.data_ver_id
+AND data_ver.census_year = $1
+AND data_ver.ver_string = '2.2'
+AND geo_breaks.category = ANY( $2 )
+AND geo_breaks.geotype = ANY( $3 )
+AND geo_breaks.divide_by = $4
+AND geo_breaks.k = $5
+AND geo_breaks.method = $6
+`
+
+// precomputedStats returns the parts of stats a CKmeans response needs: the breaks and
+// min-max, plus the class detail if detail is set.
+func precomputedStats(stats map[string][]float64, geotype string, detail bool) map[string][]float64 {
+	keys := []string{geotype, geotype + "_min_max"}
+	if detail {
+		keys = append(keys, geotype+"_counts", geotype+"_means", geotype+"_medians")
+	}
+	result := map[string][]float64{}
+	for _, key := range keys {
+		if value, ok := stats[key]; ok {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// usePrecomputed copies the precomputed breaks for catcode and geotype into
+// params.breaks, and reports whether there were any.
+func (params *CkmeansParams) usePrecomputed(catcode, geotype string) bool {
+	stats, ok := params.precomputed[catcode][geotype]
+	if !ok {
+		return false
+	}
+	cc, ok := params.breaks[catcode]
+	if !ok {
+		cc = map[string][]float64{}
+	}
+	for key, value := range stats {
+		cc[key] = value
+	}
+	params.breaks[catcode] = cc
+	return true
+}
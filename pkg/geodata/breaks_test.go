@@ -0,0 +1,120 @@
+package geodata
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+func TestTotalsCategory(t *testing.T) {
+	var tests = map[string]string{
+		"QS101EW0001": "QS101EW0001",
+		"QS101EW0002": "QS101EW0001",
+		"KS102EW0015": "KS102EW0001",
+		"abc":         "abc",
+	}
+
+	for catcode, want := range tests {
+		if got := totalsCategory(catcode); got != want {
+			t.Errorf("%s: got %s, want %s", catcode, got, want)
+		}
+	}
+}
+
+func TestGroupCategories(t *testing.T) {
+	got := groupCategories([]string{"QS102EW0002", "QS101EW0003", "QS101EW0001", "QS102EW0001", "QS101EW0002"})
+	want := []categoryGroup{
+		{totals: "QS101EW0001", catcodes: []string{"QS101EW0001", "QS101EW0002", "QS101EW0003"}},
+		{totals: "QS102EW0001", catcodes: []string{"QS102EW0001", "QS102EW0002"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestRatios(t *testing.T) {
+	var tests = map[string]struct {
+		numerator   map[string]float64
+		denominator map[string]float64
+		want        []float64
+		wantErr     error
+	}{
+		"ok": {
+			numerator:   map[string]float64{"a": 1, "b": 3},
+			denominator: map[string]float64{"a": 4, "b": 4},
+			want:        []float64{0.25, 0.75},
+		},
+		"no denominators": {
+			numerator:   map[string]float64{"a": 1},
+			denominator: map[string]float64{},
+			wantErr:     sentinel.ErrPartialContent,
+		},
+		"mismatched": {
+			numerator:   map[string]float64{"a": 1, "b": 3},
+			denominator: map[string]float64{"a": 4},
+			wantErr:     sentinel.ErrPartialContent,
+		},
+		"missing numerator": {
+			numerator:   map[string]float64{"a": 1},
+			denominator: map[string]float64{"b": 4},
+			wantErr:     sentinel.ErrPartialContent,
+		},
+		"zero denominator": {
+			numerator:   map[string]float64{"a": 1},
+			denominator: map[string]float64{"a": 0},
+			wantErr:     sentinel.ErrInvalidParams,
+		},
+	}
+
+	for name, test := range tests {
+		got, geocodes, err := ratios(nil, nil, test.numerator, test.denominator)
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("%s: got error %v, want %v", name, err, test.wantErr)
+			continue
+		}
+		if test.wantErr != nil {
+			continue
+		}
+		for i, geocode := range geocodes {
+			if got[i] != test.numerator[geocode]/test.denominator[geocode] {
+				t.Errorf("%s: %s: got %g, out of step with its geocode", name, geocode, got[i])
+			}
+		}
+		sort.Float64s(got)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", name, got, test.want)
+		}
+	}
+}
+
+func TestPrecomputedStats(t *testing.T) {
+	stats := map[string][]float64{
+		"LAD":         {1, 2},
+		"LAD_min_max": {0, 2},
+		"LAD_counts":  {3, 4},
+		"LAD_means":   {0.5, 1.5},
+		"LAD_medians": {0.5, 1.5},
+	}
+
+	got := precomputedStats(stats, "LAD", false)
+	want := map[string][]float64{
+		"LAD":         {1, 2},
+		"LAD_min_max": {0, 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("without detail: got %v, want %v", got, want)
+	}
+
+	got = precomputedStats(stats, "LAD", true)
+	if !reflect.DeepEqual(got, stats) {
+		t.Errorf("with detail: got %v, want %v", got, stats)
+	}
+
+	got = precomputedStats(stats, "MSOA", true)
+	if len(got) != 0 {
+		t.Errorf("other geotype: got %v, want nothing", got)
+	}
+}
@@ -1,14 +1,18 @@
 package geodata
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"encoding/csv"
 	"fmt"
+	"strconv"
 
+	"github.com/ONSdigital/dp-geodata-api/pkg/classify"
 	"github.com/ONSdigital/dp-geodata-api/pkg/timer"
+	"github.com/ONSdigital/dp-geodata-api/pkg/tracing"
 	"github.com/ONSdigital/dp-geodata-api/pkg/where"
 	"github.com/ONSdigital/dp-geodata-api/sentinel"
-	"github.com/jtrim-ons/ckmeans/pkg/ckmeans"
 )
 
 // Implementation notes:
@@ -48,6 +52,8 @@ type CkmeansParams struct {
 	catcodes []string
 	divideBy string
 	k        int
+	method   classify.Method
+	detail   bool // add class counts, means and medians to breaks
 	db       *sql.DB
 
 	// breaks holds the results to be returned by Ckmeans.
@@ -60,9 +66,48 @@ type CkmeansParams struct {
 	// or
 	//	breaks := map["QS501EW0008"]["OA"]
 	//
+	// With detail, there are also <geotype>_counts, <geotype>_means and
+	// <geotype>_medians, each holding one value per class.
+	//
 	// Certain combinations of errors or missing data call for either nil or an empty map to
 	// be returned.
 	breaks map[string]map[string][]float64
+
+	// classes, if not nil, gets a CSV row giving the class of each geocode.
+	classes *csv.Writer
+
+	// precomputed holds breaks from geo_breaks, by category code and geotype, in the
+	// same form as breaks.
+	precomputed map[string]map[string]map[string][]float64
+
+	// areaSQL, if not empty, is a condition on geo restricting loaded metrics to the
+	// areas selected by AreaArgs.
+	// Its placeholders follow ckquery's, and areaValues holds their values.
+	areaSQL    string
+	areaValues []interface{}
+}
+
+// AreaArgs restricts CKmeans to the areas selected by the same conditions as Query,
+// so a map of one region is not classified by national extremes.
+// If no conditions are given, or Geos is ALL, every area is classified.
+type AreaArgs struct {
+	Geos     []string
+	BBox     string
+	Location string
+	Radius   int
+	Polygon  string
+}
+
+// national is true if args select every area.
+func (args AreaArgs) national() bool {
+	if wantAllRows(args.Geos) {
+		return true
+	}
+	return len(args.Geos) == 0 &&
+		args.BBox == "" &&
+		args.Location == "" &&
+		args.Radius == 0 &&
+		args.Polygon == ""
 }
 
 // Ckmeans calculates ckmean breaks and min-max values for the metrics in
@@ -73,7 +118,163 @@ type CkmeansParams struct {
 // The metrics of each geotype-catcode combination are divided by the metrics
 // of the denominator, matching geocode to geocode.
 // ckmeans and min-max are then are calculated over the ratios.
-func (app *Geodata) CKmeans(ctx context.Context, year int, cat, geotype []string, k int, divideBy string) (map[string]map[string][]float64, error) {
+//
+// method names the classify.Method used to find the breaks instead of ckmeans,
+// such as "quantile"; ckmeans is used if it is empty.
+//
+// With detail, the number of areas in each class, and their mean and median values,
+// are returned too.
+//
+// area limits the areas classified; precomputed breaks are national, so are only used
+// when area selects every area.
+func (app *Geodata) CKmeans(ctx context.Context, year int, cat, geotype []string, k int, divideBy, method string, detail bool, area AreaArgs) (map[string]map[string][]float64, error) {
+	ctx, span := tracing.Start(ctx, "Geodata.CKmeans")
+	defer span.End()
+
+	params, err := app.newCkmeansParams(year, cat, geotype, k, divideBy, method)
+	if err != nil {
+		return nil, err
+	}
+	params.detail = detail
+
+	if area.national() {
+		if err := params.loadPrecomputed(ctx); err != nil {
+			return nil, err
+		}
+	} else if err := params.selectArea(area); err != nil {
+		return nil, err
+	}
+	if err := params.run(ctx); err != nil {
+		return nil, err
+	}
+	return params.breaks, nil
+}
+
+// CKmeansCost estimates the number of metrics CKmeans will load, for rate limiting.
+// Combinations with precomputed breaks load no metrics, so add nothing, but as with
+// EstimateCost the estimate is at least 1.
+func (app *Geodata) CKmeansCost(ctx context.Context, year int, cat, geotype []string, k int, divideBy, method string, area AreaArgs) (int, error) {
+	ctx, span := tracing.Start(ctx, "Geodata.CKmeansCost")
+	defer span.End()
+
+	params, err := app.newCkmeansParams(year, cat, geotype, k, divideBy, method)
+	if err != nil {
+		return 0, err
+	}
+	if area.national() {
+		if err := params.loadPrecomputed(ctx); err != nil {
+			return 0, err
+		}
+	}
+	return app.loadCost(ctx, params, area, func(geotype string) int {
+		var cats int
+		for _, catcode := range params.catcodes {
+			if _, ok := params.precomputed[catcode][geotype]; !ok {
+				cats++
+			}
+		}
+		if cats > 0 && params.divideBy != "" {
+			cats++
+		}
+		return cats
+	})
+}
+
+// CKmeansClassesCost is CKmeansCost for CKmeansClasses, which never uses precomputed
+// breaks.
+func (app *Geodata) CKmeansClassesCost(ctx context.Context, year int, cat, geotype []string, k int, divideBy, method string, area AreaArgs) (int, error) {
+	ctx, span := tracing.Start(ctx, "Geodata.CKmeansClassesCost")
+	defer span.End()
+
+	params, err := app.newCkmeansParams(year, cat, geotype, k, divideBy, method)
+	if err != nil {
+		return 0, err
+	}
+	return app.loadCost(ctx, params, area, func(string) int {
+		cats := len(params.catcodes)
+		if params.divideBy != "" {
+			cats++
+		}
+		return cats
+	})
+}
+
+// loadCost estimates the number of metrics loadMetrics will load for params over area,
+// given how many categories it loads for each geotype.
+func (app *Geodata) loadCost(ctx context.Context, params *CkmeansParams, area AreaArgs, cats func(geotype string) int) (int, error) {
+	selected := CensusQuerySQLArgs{
+		Geos:     area.Geos,
+		BBox:     area.BBox,
+		Location: area.Location,
+		Radius:   area.Radius,
+		Polygon:  area.Polygon,
+	}
+	if area.national() {
+		selected.Geos = []string{allRowsToken}
+	}
+	stats, err := app.costStats(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var cost int
+	for _, geotype := range params.geotypes {
+		n := cats(geotype)
+		if n == 0 {
+			continue
+		}
+		selected.Geotypes = []string{geotype}
+		areas, err := estimateAreas(selected, stats)
+		if err != nil {
+			return 0, err
+		}
+		cost += areas * n
+	}
+	return max1(cost), nil
+}
+
+// CKmeansClasses is like CKmeans, but returns a CSV giving the class of each geocode
+// in each geotype-catcode combination.
+// Classes are numbered from 0, which is the class with the lowest values; class i
+// holds the values up to the ith breakpoint returned by CKmeans.
+func (app *Geodata) CKmeansClasses(ctx context.Context, year int, cat, geotype []string, k int, divideBy, method string, area AreaArgs) ([]byte, error) {
+	ctx, span := tracing.Start(ctx, "Geodata.CKmeansClasses")
+	defer span.End()
+
+	params, err := app.newCkmeansParams(year, cat, geotype, k, divideBy, method)
+	if err != nil {
+		return nil, err
+	}
+	if !area.national() {
+		if err := params.selectArea(area); err != nil {
+			return nil, err
+		}
+	}
+
+	var body bytes.Buffer
+	params.classes = csv.NewWriter(&body)
+	if err := params.classes.Write([]string{"category", "geotype", "geography_code", "class"}); err != nil {
+		return nil, err
+	}
+
+	if err := params.run(ctx); err != nil {
+		return nil, err
+	}
+	params.classes.Flush()
+	if err := params.classes.Error(); err != nil {
+		return nil, err
+	}
+	return body.Bytes(), nil
+}
+
+// newCkmeansParams parses and validates the arguments shared by CKmeans and
+// CKmeansClasses.
+func (app *Geodata) newCkmeansParams(year int, cat, geotype []string, k int, divideBy, method string) (*CkmeansParams, error) {
+	classification, err := classify.ParseMethod(method)
+	if err != nil {
+		return nil, err
+	}
+
 	catcodes, err := parseCat(cat)
 	if err != nil {
 		return nil, err
@@ -84,33 +285,69 @@ func (app *Geodata) CKmeans(ctx context.Context, year int, cat, geotype []string
 		return nil, err
 	}
 
-	params := &CkmeansParams{
+	return &CkmeansParams{
 		year:     year,
 		catcodes: catcodes,
 		geotypes: geotypes,
 		divideBy: divideBy,
 		k:        k,
+		method:   classification,
 		db:       app.db.DB(),
 		breaks:   map[string]map[string][]float64{},
+	}, nil
+}
+
+// selectArea restricts the metrics loaded to the areas of params.geotypes selected by
+// area.
+func (params *CkmeansParams) selectArea(area AreaArgs) error {
+	err := validateCensusQuery(
+		CensusQuerySQLArgs{
+			Year:     params.year,
+			Geos:     area.Geos,
+			BBox:     area.BBox,
+			Location: area.Location,
+			Radius:   area.Radius,
+			Polygon:  area.Polygon,
+			Geotypes: params.geotypes,
+		},
+	)
+	if err != nil {
+		return err
 	}
 
-	if divideBy == "" {
-		err = params.nonratio(ctx)
-	} else {
-		err = params.ratio(ctx)
+	// ckquery's own placeholders come first
+	var sqlArgs where.Args
+	for i := 0; i < ckqueryArgs; i++ {
+		sqlArgs.Add(nil)
 	}
+	params.areaSQL, err = geoConditionsSQL(area.Geos, area.BBox, area.Location, area.Radius, area.Polygon, &sqlArgs)
 	if err != nil {
-		params.breaks = nil
+		return err
+	}
+	params.areaValues = sqlArgs.Values()[ckqueryArgs:]
+	return nil
+}
+
+// run calculates breaks over ratios if there is a divideBy, or the metrics themselves
+// if not.
+// Precomputed breaks are used where there are any.
+func (params *CkmeansParams) run(ctx context.Context) error {
+	if params.divideBy == "" {
+		return params.nonratio(ctx)
 	}
-	return params.breaks, err
+	return params.ratio(ctx)
 }
 
 // nonratio calculates ckmeans over geotype-category metrics directly.
 func (params *CkmeansParams) nonratio(ctx context.Context) error {
 	values := []float64{}
+	geocodes := []string{}
 	metrics := map[string]float64{}
 	for _, geotype := range params.geotypes {
 		for _, catcode := range params.catcodes {
+			if params.usePrecomputed(catcode, geotype) {
+				continue
+			}
 			if err := params.loadMetrics(ctx, geotype, catcode, metrics); err != nil {
 				return err
 			}
@@ -119,12 +356,16 @@ func (params *CkmeansParams) nonratio(ctx context.Context) error {
 				return nil
 			}
 
-			values = values[:0] // reuse existing slice
-			for _, value := range metrics {
+			values = values[:0] // reuse existing slices
+			geocodes = geocodes[:0]
+			for geocode, value := range metrics {
 				values = append(values, value)
+				if params.classes != nil {
+					geocodes = append(geocodes, geocode)
+				}
 			}
 
-			if err := params.collectStats(values, geotype, catcode); err != nil {
+			if err := params.collectStats(values, geocodes, geotype, catcode); err != nil {
 				return err
 			}
 		}
@@ -136,38 +377,37 @@ func (params *CkmeansParams) nonratio(ctx context.Context) error {
 // metrics of geotype-divideBy.
 func (params *CkmeansParams) ratio(ctx context.Context) error {
 	values := []float64{}
+	geocodes := []string{}
 	denominator := map[string]float64{}
 	numerator := map[string]float64{}
 	for _, geotype := range params.geotypes {
-		if err := params.loadMetrics(ctx, geotype, params.divideBy, denominator); err != nil {
-			return err
-		}
-		if len(denominator) == 0 {
-			params.breaks = map[string]map[string][]float64{} // special case
-			return nil
-		}
-
+		loaded := false // denominator is only loaded if something is not precomputed
 		for _, catcode := range params.catcodes {
+			if params.usePrecomputed(catcode, geotype) {
+				continue
+			}
+			if !loaded {
+				if err := params.loadMetrics(ctx, geotype, params.divideBy, denominator); err != nil {
+					return err
+				}
+				if len(denominator) == 0 {
+					params.breaks = map[string]map[string][]float64{} // special case
+					return nil
+				}
+				loaded = true
+			}
+
 			if err := params.loadMetrics(ctx, geotype, catcode, numerator); err != nil {
 				return err
 			}
-			if len(numerator) == 0 || len(numerator) != len(denominator) {
-				return fmt.Errorf("%w: %s %s", sentinel.ErrPartialContent, geotype, catcode)
-			}
 
-			values = values[:0] // reuse existing slice
-			for geocode, d := range denominator {
-				if d == 0 {
-					return fmt.Errorf("%w: %s %s %s == 0", sentinel.ErrInvalidParams, geotype, catcode, geocode)
-				}
-				n, ok := numerator[geocode]
-				if !ok {
-					return fmt.Errorf("%w: %s %s %s", sentinel.ErrPartialContent, geotype, catcode, geocode)
-				}
-				values = append(values, n/d)
+			var err error
+			values, geocodes, err = ratios(values[:0], geocodes[:0], numerator, denominator) // reuse existing slices
+			if err != nil {
+				return fmt.Errorf("%s %s: %w", geotype, catcode, err)
 			}
 
-			if err := params.collectStats(values, geotype, catcode); err != nil {
+			if err := params.collectStats(values, geocodes, geotype, catcode); err != nil {
 				return err
 			}
 		}
@@ -175,24 +415,47 @@ func (params *CkmeansParams) ratio(ctx context.Context) error {
 	return nil
 }
 
-// collectStats calculates statistics on metrics (ckmeans, min, max) and saves the results
+// collectStats calculates statistics on metrics (breaks, min, max) and saves the results
 // against geotype and catcode.
-func (params *CkmeansParams) collectStats(metrics []float64, geotype, catcode string) error {
-	catBreaks, err := getBreaks(metrics, params.k)
+// If classes are wanted, geocodes holds the geocode of each metric.
+func (params *CkmeansParams) collectStats(metrics []float64, geocodes []string, geotype, catcode string) error {
+	// classification may reorder its input, so keep metrics in step with geocodes
+	input := metrics
+	if params.detail || params.classes != nil {
+		input = append([]float64(nil), metrics...)
+	}
+	catBreaks, err := classify.Breaks(params.method, input, params.k)
 	if err != nil {
 		return err
 	}
 
+	if params.classes != nil {
+		for i, geocode := range geocodes {
+			class := classify.ClassOf(catBreaks, metrics[i])
+			if err := params.classes.Write([]string{catcode, geotype, geocode, strconv.Itoa(class)}); err != nil {
+				return err
+			}
+		}
+	}
+
 	cc, ok := params.breaks[catcode]
 	if !ok {
 		cc = map[string][]float64{}
 	}
 	cc[geotype] = catBreaks
-	cc[geotype+"_min_max"] = getMinMax(metrics)
+	cc[geotype+"_min_max"] = classify.MinMax(metrics)
+	if params.detail {
+		stats := classify.Summarise(metrics, catBreaks)
+		cc[geotype+"_counts"] = stats.Counts
+		cc[geotype+"_means"] = stats.Means
+		cc[geotype+"_medians"] = stats.Medians
+	}
 	params.breaks[catcode] = cc
 	return nil
 }
 
+// ckquery selects the metrics of a geotype ($1), year ($2) and category ($3).
+// It is followed by CkmeansParams.areaSQL, if any.
 var ckquery = `
 SELECT
 	geo.code AS geography_code,
@@ -215,13 +478,24 @@ AND nomis_category.year = data_ver.census_year
 AND nomis_category.long_nomis_code = $3
 `
 
+// ckqueryArgs is the number of placeholders in ckquery.
+const ckqueryArgs = 3
+
+// metricsQuery returns the SQL and placeholder values loading the metrics of geotype
+// and catcode, in the selected area if any.
+func (params *CkmeansParams) metricsQuery(geotype, catcode string) (string, []interface{}) {
+	values := append([]interface{}{geotype, params.year, catcode}, params.areaValues...)
+	return ckquery + params.areaSQL, values
+}
+
 // loadMetrics retrieves metrics for geotype and catcode and places them in result.
 // Result keys are geocodes and the metric values are the map values.
 func (params *CkmeansParams) loadMetrics(ctx context.Context, geotype, catcode string, result map[string]float64) error {
 	var err error
-	rows, err := params.db.QueryContext(ctx, ckquery, geotype, params.year, catcode)
+	sql, values := params.metricsQuery(geotype, catcode)
+	rows, err := params.db.QueryContext(ctx, sql, values...)
 	if err != nil {
-		return err
+		return queryError(ctx, err)
 	}
 	defer rows.Close()
 
@@ -238,7 +512,7 @@ func (params *CkmeansParams) loadMetrics(ctx context.Context, geotype, catcode s
 		result[geocode] = value
 	}
 	if err := rows.Err(); err != nil {
-		return err
+		return queryError(ctx, err)
 	}
 
 	return nil
@@ -275,39 +549,12 @@ func parseValidateGeotype(geotype []string) ([]string, error) {
 	return geoset.Singles, nil
 }
 
-// getBreaks gets k ckmeans clusters from metrics and returns the upper breakpoints for each cluster.
-//
-func getBreaks(metrics []float64, k int) ([]float64, error) {
-	clusters, err := ckmeans.Ckmeans(metrics, k)
-	if err != nil {
-		return nil, err
-	}
-
-	var breaks []float64
-	for _, cluster := range clusters {
-		bp := cluster[len(cluster)-1]
-		breaks = append(breaks, bp)
-	}
-	return breaks, nil
-}
-
-func getMinMax(values []float64) []float64 {
-	max := values[0]
-	min := values[0]
-	for _, v := range values {
-		if v > max {
-			max = v
-		}
-		if v < min {
-			min = v
-		}
-	}
-	return []float64{min, max}
-}
-
 // !!!! DEPRECATED CKMEANSRATIO TO BE REMOVED WHEN FRONT END REMOVES DEPENDENCY ON IT !!!!
 //
 func (app *Geodata) CKmeansRatio(ctx context.Context, year int, cat1 string, cat2 string, geotype string, k int) ([]float64, error) {
+	ctx, span := tracing.Start(ctx, "Geodata.CKmeansRatio")
+	defer span.End()
+
 	sql := `
 SELECT
     geo_metric.metric
@@ -348,7 +595,7 @@ AND data_ver.ver_string = '2.2'
 	)
 
 	if err != nil {
-		return nil, err
+		return nil, queryError(ctx, err)
 	}
 	t.Stop()
 	t.Log(ctx)
@@ -393,7 +640,7 @@ AND data_ver.ver_string = '2.2'
 	tscan.Log(ctx)
 
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return nil, queryError(ctx, err)
 	}
 
 	if nmetricsCat1 == 0 && nmetricsCat2 == 0 {
@@ -412,5 +659,5 @@ AND data_ver.ver_string = '2.2'
 		metrics = append(metrics, metricCat1/metricCat2)
 	}
 
-	return getBreaks(metrics, k)
+	return classify.Breaks(classify.Ckmeans, metrics, k)
 }
@@ -9,7 +9,10 @@ import (
 	"fmt"
 	"log"
 	"reflect"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/ONSdigital/dp-geodata-api/comptests"
 	"github.com/ONSdigital/dp-geodata-api/pkg/database"
@@ -172,6 +175,9 @@ func TestCkmeansHappyPathSingleCategorySingleGeotype(t *testing.T) {
 			[]string{"LAD"},
 			testK,
 			"",
+			"",
+			false,
+			AreaArgs{},
 		)
 
 		// THEN we expect the breakpoints to match the example given in the original javascript repo
@@ -190,6 +196,342 @@ func TestCkmeansHappyPathSingleCategorySingleGeotype(t *testing.T) {
 	}()
 }
 
+func TestCkmeansDetailAndClasses(t *testing.T) {
+	// GIVEN the database is setup
+	dsn := comptests.DefaultDSN
+	db, err := database.Open("pgx", dsn)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	func() {
+		db.DB().Exec("BEGIN")
+		defer db.DB().Exec("ROLLBACK")
+
+		// AND GIVEN we have seeded the same datapoints as the single category test
+		metrics := map[string]map[string][]float64{
+			"LAD": {
+				"category1": {-1.0, 2.0, -1.0, 2.0, 4.0, 5.0, 6.0, -1.0, 2.0, -1.0},
+			},
+		}
+		ckmeansTestSetup(t, db, metrics)
+
+		app, err := New(db, nil, 100)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// WHEN we ask for detail
+		result, err := app.CKmeans(
+			context.Background(),
+			2011,
+			[]string{"category1"},
+			[]string{"LAD"},
+			3,
+			"",
+			"",
+			true,
+			AreaArgs{},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// THEN we get the counts, means and medians of each class
+		wantBreaks := map[string]map[string][]float64{
+			"category1": {
+				"LAD":         {-1.0, 2.0, 6.0},
+				"LAD_min_max": {-1.0, 6.0},
+				"LAD_counts":  {4, 3, 3},
+				"LAD_means":   {-1.0, 2.0, 5.0},
+				"LAD_medians": {-1.0, 2.0, 5.0},
+			},
+		}
+		if !reflect.DeepEqual(result, wantBreaks) {
+			t.Errorf("got %#v, wanted %#v", result, wantBreaks)
+		}
+
+		// AND WHEN we ask for classes
+		body, err := app.CKmeansClasses(
+			context.Background(),
+			2011,
+			[]string{"category1"},
+			[]string{"LAD"},
+			3,
+			"",
+			"",
+			AreaArgs{},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// THEN we get the class of each geography, in any order
+		lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+		sort.Strings(lines[1:])
+		wantLines := []string{
+			"category,geotype,geography_code,class",
+			"category1,LAD,testGeography1,0",
+			"category1,LAD,testGeography10,0",
+			"category1,LAD,testGeography2,1",
+			"category1,LAD,testGeography3,0",
+			"category1,LAD,testGeography4,1",
+			"category1,LAD,testGeography5,2",
+			"category1,LAD,testGeography6,2",
+			"category1,LAD,testGeography7,2",
+			"category1,LAD,testGeography8,0",
+			"category1,LAD,testGeography9,1",
+		}
+		if !reflect.DeepEqual(lines, wantLines) {
+			t.Errorf("got %q, wanted %q", lines, wantLines)
+		}
+	}()
+}
+
+func TestCkmeansArea(t *testing.T) {
+	// GIVEN the database is setup
+	dsn := comptests.DefaultDSN
+	db, err := database.Open("pgx", dsn)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	func() {
+		db.DB().Exec("BEGIN")
+		defer db.DB().Exec("ROLLBACK")
+
+		// AND GIVEN we have seeded the same datapoints as the single category test
+		metrics := map[string]map[string][]float64{
+			"LAD": {
+				"category1": {-1.0, 2.0, -1.0, 2.0, 4.0, 5.0, 6.0, -1.0, 2.0, -1.0},
+			},
+		}
+		ckmeansTestSetup(t, db, metrics)
+
+		app, err := New(db, nil, 100)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// WHEN we only classify some of the geographies
+		result, err := app.CKmeans(
+			context.Background(),
+			2011,
+			[]string{"category1"},
+			[]string{"LAD"},
+			3,
+			"",
+			"",
+			false,
+			AreaArgs{Geos: []string{"testGeography1,testGeography2,testGeography5"}},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// THEN the breaks only cover their values
+		wantBreaks := map[string]map[string][]float64{
+			"category1": {
+				"LAD":         {-1.0, 2.0, 4.0},
+				"LAD_min_max": {-1.0, 4.0},
+			},
+		}
+		if !reflect.DeepEqual(result, wantBreaks) {
+			t.Errorf("got %#v, wanted %#v", result, wantBreaks)
+		}
+
+		// AND WHEN rows=ALL
+		result, err = app.CKmeans(
+			context.Background(),
+			2011,
+			[]string{"category1"},
+			[]string{"LAD"},
+			3,
+			"",
+			"",
+			false,
+			AreaArgs{Geos: []string{"ALL"}},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// THEN every geography is classified
+		wantBreaks = map[string]map[string][]float64{
+			"category1": {
+				"LAD":         {-1.0, 2.0, 6.0},
+				"LAD_min_max": {-1.0, 6.0},
+			},
+		}
+		if !reflect.DeepEqual(result, wantBreaks) {
+			t.Errorf("rows=ALL: got %#v, wanted %#v", result, wantBreaks)
+		}
+
+		// AND WHEN the area is badly formed
+		_, err = app.CKmeans(
+			context.Background(),
+			2011,
+			[]string{"category1"},
+			[]string{"LAD"},
+			3,
+			"",
+			"",
+			false,
+			AreaArgs{BBox: "1,2,3"},
+		)
+
+		// THEN we get an invalid params error
+		if !errors.Is(err, sentinel.ErrInvalidParams) {
+			t.Errorf("bad bbox: got %v, wanted %v", err, sentinel.ErrInvalidParams)
+		}
+	}()
+}
+
+func TestCkmeansCost(t *testing.T) {
+	// GIVEN the database is setup
+	dsn := comptests.DefaultDSN
+	db, err := database.Open("pgx", dsn)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	func() {
+		db.DB().Exec("BEGIN")
+		defer db.DB().Exec("ROLLBACK")
+
+		// AND GIVEN we have seeded 10 geographies with two categories
+		metrics := map[string]map[string][]float64{
+			"LAD": {
+				"category1": {-1.0, 2.0, -1.0, 2.0, 4.0, 5.0, 6.0, -1.0, 2.0, -1.0},
+				"category2": {1.0, 2.0, 3.0, 4.0, 5.0, 6.0, 7.0, 8.0, 9.0, 10.0},
+			},
+		}
+		ckmeansTestSetup(t, db, metrics)
+
+		// AND GIVEN category1 has precomputed breaks
+		comptests.DoSQL(
+			t,
+			db,
+			`INSERT INTO geo_breaks (id,data_ver_id,category,geotype,divide_by,k,method,stats,data_updated_at)
+			VALUES (1,1,'category1','LAD','',3,'ckmeans','{"LAD":[-1,2,6],"LAD_min_max":[-1,6]}','2021-12-06 11:52:26.142808')`,
+		)
+
+		app, err := New(db, nil, 100)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		var tests = map[string]struct {
+			divideBy string
+			area     AreaArgs
+			want     int
+		}{
+			// THEN only the category without precomputed breaks is counted
+			"national": {want: 10},
+			// THEN the denominator is counted, and nothing is precomputed for it
+			"ratio": {divideBy: "category2", want: 30},
+			// THEN precomputed breaks are not used for a region
+			"region": {area: AreaArgs{Geos: []string{"testGeography1,testGeography2,testGeography5"}}, want: 6},
+		}
+
+		for desc, test := range tests {
+			// WHEN we estimate the cost
+			got, err := app.CKmeansCost(
+				context.Background(),
+				2011,
+				[]string{"category1", "category2"},
+				[]string{"LAD"},
+				3,
+				test.divideBy,
+				"",
+				test.area,
+			)
+			if err != nil {
+				t.Errorf("%s: %s", desc, err)
+				continue
+			}
+			if got != test.want {
+				t.Errorf("%s: got %d, wanted %d", desc, got, test.want)
+			}
+		}
+
+		// WHEN we estimate the cost of the same classes
+		got, err := app.CKmeansClassesCost(
+			context.Background(),
+			2011,
+			[]string{"category1", "category2"},
+			[]string{"LAD"},
+			3,
+			"",
+			"",
+			AreaArgs{},
+		)
+		// THEN precomputed breaks don't help, so both categories are counted
+		if err != nil {
+			t.Error(err)
+		} else if got != 20 {
+			t.Errorf("classes: got %d, wanted 20", got)
+		}
+	}()
+}
+
+func TestCostStatsReload(t *testing.T) {
+	// GIVEN the database is setup
+	dsn := comptests.DefaultDSN
+	db, err := database.Open("pgx", dsn)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	func() {
+		db.DB().Exec("BEGIN")
+		defer db.DB().Exec("ROLLBACK")
+
+		// AND GIVEN we have seeded 10 geographies
+		metrics := map[string]map[string][]float64{
+			"LAD": {
+				"category1": {1.0, 2.0, 3.0, 4.0, 5.0, 6.0, 7.0, 8.0, 9.0, 10.0},
+			},
+		}
+		ckmeansTestSetup(t, db, metrics)
+
+		app, err := New(db, nil, 100)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// WHEN we load the cost stats
+		stats, err := app.costStats(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		// THEN all the geographies are counted
+		if stats.Areas["LAD"] != 10 {
+			t.Errorf("got %d LADs, wanted 10", stats.Areas["LAD"])
+		}
+
+		// AND WHEN new data is loaded
+		comptests.DoSQL(
+			t,
+			db,
+			`INSERT INTO geo (id,type_id,code,name,lat,long,valid,wkb_geometry,wkb_long_lat_geom)
+			VALUES (11,1,'testGeography11','City of Test 0011',1,-0.1,true,null,null)`,
+		)
+		comptests.DoSQL(t, db, "UPDATE data_ver SET updated_at = '2022-01-01 00:00:00' WHERE id = 1")
+
+		// AND the stats are next checked
+		app.stats.checked = time.Time{}
+		stats, err = app.costStats(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		// THEN they are reloaded
+		if stats.Areas["LAD"] != 11 {
+			t.Errorf("after reload: got %d LADs, wanted 11", stats.Areas["LAD"])
+		}
+	}()
+}
+
 func TestCkmeansHappyPathMultiCategorySingleGeotype(t *testing.T) {
 	// GIVEN the database is setup
 	dsn := comptests.DefaultDSN
@@ -227,6 +569,9 @@ func TestCkmeansHappyPathMultiCategorySingleGeotype(t *testing.T) {
 			[]string{"LAD"},
 			testK,
 			"",
+			"",
+			false,
+			AreaArgs{},
 		)
 
 		// THEN we expect the breakpoints to match the example given in the original javascript repo, after adjustment
@@ -295,6 +640,9 @@ func TestCkmeansHappyPathMultiCategoryMultiGeotype(t *testing.T) {
 			[]string{"LAD,MSOA"},
 			testK,
 			"",
+			"",
+			false,
+			AreaArgs{},
 		)
 
 		// THEN we expect the breakpoints to match the example given in the original javascript repo, after adjustment
@@ -359,6 +707,9 @@ func TestCkmeansNoData(t *testing.T) {
 			[]string{"LAD"},
 			testK,
 			"",
+			"",
+			false,
+			AreaArgs{},
 		)
 
 		// THEN we expect to receive no data
@@ -413,6 +764,9 @@ func TestCkmeansRatiosHappyPathSingleGeotype(t *testing.T) {
 			[]string{"LAD"},
 			testK,
 			"denominator",
+			"",
+			false,
+			AreaArgs{},
 		)
 
 		// THEN we expect to get breakpoints matching the order-of-magnitude breaks in our test data
@@ -485,6 +839,9 @@ func TestCkmeansRatiosHappyPathMultipleGeotypes(t *testing.T) {
 			[]string{"LAD,MSOA"},
 			testK,
 			"denominator",
+			"",
+			false,
+			AreaArgs{},
 		)
 
 		// THEN we expect to get breakpoints matching the order-of-magnitude breaks in our test data
@@ -558,6 +915,9 @@ func TestCkmeansRatiosPartialDataOneCategoryMissing(t *testing.T) {
 			[]string{"LAD"},
 			testK,
 			"denominator",
+			"",
+			false,
+			AreaArgs{},
 		)
 
 		// THEN we expect to receive no data
@@ -615,6 +975,9 @@ func TestCkmeansRatiosPartialDataOneCategoryPartialDataOnly(t *testing.T) {
 			[]string{"LAD"},
 			testK,
 			"denominator",
+			"",
+			false,
+			AreaArgs{},
 		)
 
 		// THEN we expect to receive no data
@@ -659,6 +1022,9 @@ func TestCkmeansRatiosNoData(t *testing.T) {
 			[]string{"LAD"},
 			testK,
 			"doesNotExist3",
+			"",
+			false,
+			AreaArgs{},
 		)
 
 		// THEN we expect to receive no data
@@ -736,6 +1102,9 @@ func TestCkmeansArgParsingAndValidation(t *testing.T) {
 				argset["geotype"],
 				testK,
 				"denominator",
+				"",
+				false,
+				AreaArgs{},
 			)
 
 			// THEN we expect to get breakpoints matching the order-of-magnitude breaks in our test data, in all cases
@@ -775,6 +1144,9 @@ func TestCkmeansArgParsingAndValidation(t *testing.T) {
 			[]string{"LAD,MSOA"},
 			testK,
 			"denominator",
+			"",
+			false,
+			AreaArgs{},
 		)
 
 		// THEN we expect to get breakpoints matching the order-of-magnitude breaks in our test data, in all cases
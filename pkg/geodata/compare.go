@@ -0,0 +1,237 @@
+package geodata
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+
+	"github.com/ONSdigital/dp-geodata-api/pkg/table"
+	"github.com/ONSdigital/dp-geodata-api/pkg/timer"
+	"github.com/ONSdigital/dp-geodata-api/pkg/tracing"
+	"github.com/ONSdigital/dp-geodata-api/pkg/where"
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+type CompareArgs struct {
+	From     int
+	To       int
+	Geos     []string
+	Geotypes []string
+	Cols     []string
+}
+
+// Compare returns each area's metrics in the From and To census years, with the absolute
+// and percentage change between them, as CSV.
+//
+// Areas are identified by their To year geocodes.
+// Where boundaries have changed, From year metrics are mapped onto To year areas using
+// the best-fit geo_vintage_lookup table, summing areas which were merged.
+// Geocodes not in the lookup are assumed not to have changed.
+// Categories are matched by code, so nomis_category.year is not checked: a category
+// defined for one year can hold metrics for another.
+//
+func (app *Geodata) Compare(ctx context.Context, args CompareArgs) ([]byte, error) {
+	ctx, span := tracing.Start(ctx, "Geodata.Compare")
+	defer span.End()
+
+	query, values, err := CompareSQL(args)
+	if err != nil {
+		return nil, err
+	}
+	log.Info(ctx, "sql", log.Data{"query": query, "args": values})
+
+	t := timer.New("query")
+	t.Start()
+	rows, err := app.db.DB().QueryContext(ctx, query, values...)
+	t.Stop()
+	t.Log(ctx)
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+	defer rows.Close()
+
+	var body bytes.Buffer
+	cw := csv.NewWriter(&body)
+	cw.Write([]string{
+		table.ColGeographyCode,
+		"category_code",
+		fmt.Sprintf("value_%d", args.From),
+		fmt.Sprintf("value_%d", args.To),
+		"change",
+		"percent_change",
+	})
+
+	var nmetrics int
+	for rows.Next() {
+		nmetrics++
+		if app.maxMetrics > 0 && nmetrics > app.maxMetrics {
+			return nil, fmt.Errorf("%w: limit is %d", sentinel.ErrTooManyMetrics, app.maxMetrics)
+		}
+
+		var geo, cat string
+		var from, to sql.NullFloat64
+		if err := rows.Scan(&geo, &cat, &from, &to); err != nil {
+			return nil, err
+		}
+		cw.Write(compareRow(geo, cat, from, to))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, queryError(ctx, err)
+	}
+
+	cw.Flush()
+	return body.Bytes(), cw.Error()
+}
+
+// compareRow formats a single output row.
+// Cells for a missing value, and any change depending on it, are left empty, as is
+// the percentage change from zero.
+func compareRow(geo, cat string, from, to sql.NullFloat64) []string {
+	row := []string{geo, cat, "", "", "", ""}
+	if from.Valid {
+		row[2] = table.FormatValue(from.Float64)
+	}
+	if to.Valid {
+		row[3] = table.FormatValue(to.Float64)
+	}
+	if from.Valid && to.Valid {
+		row[4] = table.FormatValue(to.Float64 - from.Float64)
+		if from.Float64 != 0 {
+			row[5] = table.FormatValue(100 * (to.Float64 - from.Float64) / from.Float64)
+		}
+	}
+	return row
+}
+
+// CompareSQL returns the SQL for a comparison between two census years, along with the
+// values for its placeholders.
+//
+func CompareSQL(args CompareArgs) (string, []interface{}, error) {
+	if args.From == args.To {
+		return "", nil, fmt.Errorf("%w: from and to years must differ", sentinel.ErrInvalidParams)
+	}
+	if len(args.Geos) == 0 {
+		return "", nil, fmt.Errorf("%w: must specify rows", sentinel.ErrMissingParams)
+	}
+	if len(args.Cols) == 0 {
+		return "", nil, fmt.Errorf("%w: must specify cols", sentinel.ErrMissingParams)
+	}
+
+	geoset, err := where.ParseMultiArgs(args.Geos)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := ValidateAllToken(geoset); err != nil {
+		return "", nil, err
+	}
+	if wantAllRows(args.Geos) {
+		geoset = where.NewValueSet()
+	}
+
+	catset, err := where.ParseMultiArgs(args.Cols)
+	if err != nil {
+		return "", nil, err
+	}
+	include, catset, err := ExtractSpecialCols(catset)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(include) > 0 {
+		return "", nil, fmt.Errorf("%w: special columns are not supported by compare", sentinel.ErrInvalidParams)
+	}
+
+	var sqlArgs where.Args
+	from := sqlArgs.Add(args.From)
+	to := sqlArgs.Add(args.To)
+
+	// the same conditions apply to both years, but need their own placeholders
+	conditions := func(codeCol string) (string, error) {
+		geotypeConditions, err := geotypeSQL("geo_type.name", args.Geotypes, &sqlArgs)
+		if err != nil {
+			return "", err
+		}
+		var geoConditions string
+		if part := where.WherePart(codeCol, geoset, &sqlArgs); part != "" {
+			geoConditions = fmt.Sprintf("AND (\n%s)", part)
+		}
+		catConditions, err := categorySQL(catset, "", &sqlArgs)
+		if err != nil {
+			return "", err
+		}
+		return geotypeConditions + "\n" + geoConditions + "\n" + catConditions, nil
+	}
+
+	fromConditions, err := conditions("COALESCE(geo_vintage_lookup.to_code, geo.code)")
+	if err != nil {
+		return "", nil, err
+	}
+	toConditions, err := conditions("geo.code")
+	if err != nil {
+		return "", nil, err
+	}
+
+	template := `
+WITH from_metrics AS (
+	SELECT
+		COALESCE(geo_vintage_lookup.to_code, geo.code) AS code,
+		nomis_category.long_nomis_code AS category,
+		SUM(geo_metric.metric) AS value
+	FROM
+		geo
+		JOIN geo_type ON geo_type.id = geo.type_id
+		JOIN geo_metric ON geo_metric.geo_id = geo.id
+		JOIN data_ver ON data_ver.id = geo_metric.data_ver_id
+		JOIN nomis_category ON nomis_category.id = geo_metric.category_id
+		LEFT JOIN geo_vintage_lookup
+			ON geo_vintage_lookup.from_code = geo.code
+			AND geo_vintage_lookup.from_year = %[1]s
+			AND geo_vintage_lookup.to_year = %[2]s
+	WHERE geo.valid
+	AND data_ver.census_year = %[1]s
+	AND data_ver.ver_string = '2.2'
+	%[3]s
+	GROUP BY 1, 2
+), to_metrics AS (
+	SELECT
+		geo.code AS code,
+		nomis_category.long_nomis_code AS category,
+		geo_metric.metric AS value
+	FROM
+		geo,
+		geo_type,
+		geo_metric,
+		data_ver,
+		nomis_category
+	WHERE geo.valid
+	AND geo_type.id = geo.type_id
+	AND geo_metric.geo_id = geo.id
+	AND data_ver.id = geo_metric.data_ver_id
+	AND data_ver.census_year = %[2]s
+	AND data_ver.ver_string = '2.2'
+	AND nomis_category.id = geo_metric.category_id
+	%[4]s
+)
+SELECT
+	COALESCE(to_metrics.code, from_metrics.code) AS geography_code,
+	COALESCE(to_metrics.category, from_metrics.category) AS category_code,
+	from_metrics.value,
+	to_metrics.value
+FROM
+	from_metrics
+	FULL OUTER JOIN to_metrics
+		ON to_metrics.code = from_metrics.code
+		AND to_metrics.category = from_metrics.category
+ORDER BY 1 COLLATE "C", 2 COLLATE "C"
+`
+	query := fmt.Sprintf(
+		template,
+		from,
+		to,
+		fromConditions,
+		toConditions,
+	)
+	return query, sqlArgs.Values(), nil
+}
@@ -0,0 +1,157 @@
+package geodata
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+func TestCompareSQL_Errors(t *testing.T) {
+	var tests = map[string]struct {
+		args    CompareArgs
+		wantErr error
+	}{
+		"same year": {
+			args:    CompareArgs{From: 2011, To: 2011, Geos: []string{"ALL"}, Cols: []string{"QS101EW0001"}},
+			wantErr: sentinel.ErrInvalidParams,
+		},
+		"no rows": {
+			args:    CompareArgs{From: 2011, To: 2021, Cols: []string{"QS101EW0001"}},
+			wantErr: sentinel.ErrMissingParams,
+		},
+		"no cols": {
+			args:    CompareArgs{From: 2011, To: 2021, Geos: []string{"ALL"}},
+			wantErr: sentinel.ErrMissingParams,
+		},
+		"special col": {
+			args:    CompareArgs{From: 2011, To: 2021, Geos: []string{"ALL"}, Cols: []string{"geography_code"}},
+			wantErr: sentinel.ErrInvalidParams,
+		},
+		"ALL not alone": {
+			args:    CompareArgs{From: 2011, To: 2021, Geos: []string{"ALL,E01000001"}, Cols: []string{"QS101EW0001"}},
+			wantErr: sentinel.ErrInvalidParams,
+		},
+		"bad geotype": {
+			args:    CompareArgs{From: 2011, To: 2021, Geos: []string{"ALL"}, Geotypes: []string{"nope"}, Cols: []string{"QS101EW0001"}},
+			wantErr: sentinel.ErrInvalidParams,
+		},
+	}
+
+	for desc, test := range tests {
+		_, _, err := CompareSQL(test.args)
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("%s: got error %v, want %v", desc, err, test.wantErr)
+		}
+	}
+}
+
+func TestCompareSQL(t *testing.T) {
+	var tests = map[string]struct {
+		args     CompareArgs
+		wantSQL  []string
+		wantArgs []interface{}
+	}{
+		"rows": {
+			args: CompareArgs{From: 2011, To: 2021, Geos: []string{"E06000048"}, Cols: []string{"QS101EW0001"}},
+			wantSQL: []string{
+				"geo_vintage_lookup.from_year = $1 AND geo_vintage_lookup.to_year = $2",
+				"data_ver.census_year = $1",
+				"COALESCE(geo_vintage_lookup.to_code, geo.code) = ANY( $3 )",
+				"nomis_category.long_nomis_code = ANY( $4 )",
+				"data_ver.census_year = $2",
+				"geo.code = ANY( $5 )",
+				"nomis_category.long_nomis_code = ANY( $6 )",
+				"FULL OUTER JOIN to_metrics",
+			},
+			wantArgs: []interface{}{
+				2011, 2021,
+				[]string{"E06000048"}, []string{"QS101EW0001"},
+				[]string{"E06000048"}, []string{"QS101EW0001"},
+			},
+		},
+		"all rows with geotype": {
+			args: CompareArgs{From: 2011, To: 2021, Geos: []string{"ALL"}, Geotypes: []string{"lad"}, Cols: []string{"QS101EW0001"}},
+			wantSQL: []string{
+				"geo_type.name = ANY( $3 )",
+				"nomis_category.long_nomis_code = ANY( $4 )",
+				"geo_type.name = ANY( $5 )",
+				"nomis_category.long_nomis_code = ANY( $6 )",
+			},
+			wantArgs: []interface{}{
+				2011, 2021,
+				[]string{"LAD"}, []string{"QS101EW0001"},
+				[]string{"LAD"}, []string{"QS101EW0001"},
+			},
+		},
+	}
+
+	for desc, test := range tests {
+		sql, values, err := CompareSQL(test.args)
+		if err != nil {
+			t.Errorf("%s: %s", desc, err)
+			continue
+		}
+		flat := strings.Join(strings.Fields(sql), " ")
+		for _, want := range test.wantSQL {
+			if !strings.Contains(flat, want) {
+				t.Errorf("%s: SQL does not contain %q:\n%s", desc, want, flat)
+			}
+		}
+		if strings.Contains(flat, "geo.code = ANY") && wantAllRows(test.args.Geos) {
+			t.Errorf("%s: rows=ALL should not select codes:\n%s", desc, flat)
+		}
+		if !reflect.DeepEqual(values, test.wantArgs) {
+			t.Errorf("%s: got args %#v, want %#v", desc, values, test.wantArgs)
+		}
+	}
+}
+
+func TestCompareRow(t *testing.T) {
+	value := func(f float64) sql.NullFloat64 {
+		return sql.NullFloat64{Float64: f, Valid: true}
+	}
+	var missing sql.NullFloat64
+
+	var tests = map[string]struct {
+		from sql.NullFloat64
+		to   sql.NullFloat64
+		want []string
+	}{
+		"increase": {
+			from: value(200),
+			to:   value(250),
+			want: []string{"E1", "C1", "200", "250", "50", "25"},
+		},
+		"decrease": {
+			from: value(200),
+			to:   value(150),
+			want: []string{"E1", "C1", "200", "150", "-50", "-25"},
+		},
+		"from zero": {
+			from: value(0),
+			to:   value(10),
+			want: []string{"E1", "C1", "0", "10", "10", ""},
+		},
+		"missing from": {
+			from: missing,
+			to:   value(10),
+			want: []string{"E1", "C1", "", "10", "", ""},
+		},
+		"missing to": {
+			from: value(10),
+			to:   missing,
+			want: []string{"E1", "C1", "10", "", "", ""},
+		},
+	}
+
+	for desc, test := range tests {
+		got := compareRow("E1", "C1", test.from, test.to)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", desc, got, test.want)
+		}
+	}
+}
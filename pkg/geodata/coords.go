@@ -11,6 +11,8 @@ import (
 
 const maxRadius = 1000000 // largest "sane" Circle radius 1000km
 
+const maxNearest = 1000 // largest k for nearest neighbour queries
+
 // Defined UK bounding box for basic sanity checking.
 var ukbbox = geom.NewBounds(geom.XY).SetCoords(
 	geom.Coord{-7.57, 58.64}, // NW corner
@@ -0,0 +1,311 @@
+package geodata
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/dp-geodata-api/pkg/tracing"
+	"github.com/ONSdigital/dp-geodata-api/pkg/where"
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+// metresPerDegree is the length of a degree of latitude, near enough.
+const metresPerDegree = 111320
+
+// unknownRangeSize is assumed for ranges whose size cannot be worked out from their codes.
+const unknownRangeSize = 100
+
+// costStatsCheckInterval is how often data_ver is checked for new data, which needs
+// the CostStats reloaded.
+const costStatsCheckInterval = time.Minute
+
+// CostStats holds the table sizes used to estimate the cost of a query.
+type CostStats struct {
+	Areas      map[string]int // number of valid areas in each geotype
+	Categories map[string]int // number of categories in each census table
+}
+
+// costStats guards the CostStats loaded from the database.
+// They only change when new data is loaded, so are reloaded only when data_ver says
+// the data has changed.
+type costStats struct {
+	sync.Mutex
+	stats    *CostStats
+	modified time.Time // latest data_ver update when stats were loaded
+	checked  time.Time // when modified was last compared with data_ver
+}
+
+// Cost estimates the number of metrics a census query will return, before running it.
+// The estimate is meant for rate limiting, so it is cheap rather than accurate.
+func (app *Geodata) Cost(ctx context.Context, args CensusQuerySQLArgs) (int, error) {
+	ctx, span := tracing.Start(ctx, "Geodata.Cost")
+	defer span.End()
+
+	stats, err := app.costStats(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return EstimateCost(args, stats)
+}
+
+// costStats returns the CostStats, loading them the first time they are needed, and
+// again after new data is loaded.
+func (app *Geodata) costStats(ctx context.Context) (*CostStats, error) {
+	app.stats.Lock()
+	defer app.stats.Unlock()
+	now := time.Now()
+	if app.stats.stats != nil && now.Sub(app.stats.checked) < costStatsCheckInterval {
+		return app.stats.stats, nil
+	}
+
+	var modified sql.NullTime
+	err := app.db.DB().QueryRowContext(ctx, dataModifiedSQL).Scan(&modified)
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+	if app.stats.stats != nil && modified.Time.Equal(app.stats.modified) {
+		app.stats.checked = now
+		return app.stats.stats, nil
+	}
+
+	stats := &CostStats{}
+	if stats.Areas, err = app.counts(ctx, areaCountsSQL); err != nil {
+		return nil, err
+	}
+	if stats.Categories, err = app.counts(ctx, categoryCountsSQL); err != nil {
+		return nil, err
+	}
+	app.stats.stats = stats
+	app.stats.modified = modified.Time
+	app.stats.checked = now
+	return stats, nil
+}
+
+// counts runs query, which must return name, count pairs, and returns them as a map.
+func (app *Geodata) counts(ctx context.Context, query string) (map[string]int, error) {
+	rows, err := app.db.DB().QueryContext(ctx, query)
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var name string
+		var n int
+		if err := rows.Scan(&name, &n); err != nil {
+			return nil, err
+		}
+		counts[name] = n
+	}
+	return counts, queryError(ctx, rows.Err())
+}
+
+// dataModifiedSQL finds the latest update to any data version.
+const dataModifiedSQL = `
+SELECT
+	MAX(data_ver.updated_at)
+FROM
+	data_ver
+WHERE data_ver.deleted_at IS NULL
+`
+
+const areaCountsSQL = `
+SELECT
+	geo_type.name,
+	COUNT(*)
+FROM
+	geo,
+	geo_type
+WHERE geo.valid
+AND geo_type.id = geo.type_id
+GROUP BY 1
+`
+
+const categoryCountsSQL = `
+SELECT
+	nomis_desc.short_nomis_code,
+	COUNT(*)
+FROM
+	nomis_category,
+	nomis_desc
+WHERE nomis_category.nomis_desc_id = nomis_desc.id
+GROUP BY 1
+`
+
+// EstimateCost estimates the number of metrics a census query will return: the number
+// of areas selected times the number of categories.
+//
+// Areas selected by code are counted, and rows=ALL counts every area of the selected
+// geotypes.
+// bbox, radius and polygon selections are assumed to hold the same fraction of the
+// areas as they cover of the UK bounding box.
+// The estimate is at least 1, so every query costs something.
+//
+func EstimateCost(args CensusQuerySQLArgs, stats *CostStats) (int, error) {
+	areas, err := estimateAreas(args, stats)
+	if err != nil {
+		return 0, err
+	}
+	cats, err := estimateCategories(args, stats)
+	if err != nil {
+		return 0, err
+	}
+	if cats < 1 {
+		cats = 1
+	}
+	return areas * cats, nil
+}
+
+func estimateAreas(args CensusQuerySQLArgs, stats *CostStats) (int, error) {
+	var geotypes []string
+	if len(args.Geotypes) > 0 {
+		set, err := where.ParseMultiArgs(args.Geotypes)
+		if err != nil {
+			return 0, err
+		}
+		if set, err = MapGeotypes(set); err != nil {
+			return 0, err
+		}
+		geotypes = set.Singles
+	}
+	var total int
+	if geotypes == nil {
+		for _, n := range stats.Areas {
+			total += n
+		}
+	} else {
+		for _, geotype := range geotypes {
+			total += stats.Areas[geotype]
+		}
+	}
+
+	if wantAllRows(args.Geos) {
+		return max1(total), nil
+	}
+
+	var areas float64
+	if len(args.Geos) > 0 {
+		set, err := where.ParseMultiArgs(args.Geos)
+		if err != nil {
+			return 0, err
+		}
+		areas += float64(countValues(set))
+	}
+
+	// spatial selections, in square degrees
+	var spatial float64
+	if args.BBox != "" {
+		coords, err := parseCoords(args.BBox)
+		if err != nil {
+			return 0, err
+		}
+		if len(coords) != 4 {
+			return 0, fmt.Errorf("%w: valid bbox is 'lon,lat,lon,lat', received %q", sentinel.ErrInvalidParams, args.BBox)
+		}
+		spatial += math.Abs((coords[2] - coords[0]) * (coords[3] - coords[1]))
+	}
+	if args.Location != "" || args.Radius != 0 {
+		coords, err := parseRadius(args.Location, args.Radius)
+		if err != nil {
+			return 0, err
+		}
+		r := float64(args.Radius) / metresPerDegree
+		spatial += math.Pi * r * r / math.Cos(coords[1]*math.Pi/180)
+	}
+	if args.Polygon != "" {
+		coords, err := parseCoords(args.Polygon)
+		if err != nil {
+			return 0, err
+		}
+		spatial += polygonArea(coords)
+	}
+	areas += spatial / (ukbbox.Max(0) - ukbbox.Min(0)) / (ukbbox.Max(1) - ukbbox.Min(1)) * float64(total)
+
+	if areas > float64(total) {
+		areas = float64(total)
+	}
+	return max1(int(math.Ceil(areas))), nil
+}
+
+func estimateCategories(args CensusQuerySQLArgs, stats *CostStats) (int, error) {
+	var cats int
+	if len(args.Cols) > 0 {
+		set, err := where.ParseMultiArgs(args.Cols)
+		if err != nil {
+			return 0, err
+		}
+		if _, set, err = ExtractSpecialCols(set); err != nil {
+			return 0, err
+		}
+		cats += countValues(set)
+	}
+	if args.Censustable != "" {
+		cats += stats.Categories[args.Censustable]
+	}
+	if args.DivideBy != "" {
+		cats++
+	}
+	return cats, nil
+}
+
+// countValues counts the values in set.
+// Ranges of codes with the same prefix, such as E01000001...E01000010, are sized from
+// their numeric suffixes. Other ranges are assumed to hold unknownRangeSize values.
+func countValues(set *where.ValueSet) int {
+	n := len(set.Singles)
+	for _, r := range set.Ranges {
+		n += rangeSize(r.Low, r.High)
+	}
+	return n
+}
+
+func rangeSize(low, high string) int {
+	lowPrefix, lowNum, ok := splitSuffix(low)
+	if !ok {
+		return unknownRangeSize
+	}
+	highPrefix, highNum, ok := splitSuffix(high)
+	if !ok || lowPrefix != highPrefix || highNum < lowNum {
+		return unknownRangeSize
+	}
+	return highNum - lowNum + 1
+}
+
+// splitSuffix splits code into its prefix and the number at its end.
+func splitSuffix(code string) (string, int, bool) {
+	i := len(code)
+	for i > 0 && code[i-1] >= '0' && code[i-1] <= '9' {
+		i--
+	}
+	if i == len(code) {
+		return "", 0, false
+	}
+	n, err := strconv.Atoi(code[i:])
+	if err != nil {
+		return "", 0, false
+	}
+	return code[:i], n, true
+}
+
+// polygonArea returns the area of the polygon in the flat coordinate list, in square
+// degrees, using the shoelace formula.
+func polygonArea(coords []float64) float64 {
+	var sum float64
+	for i := 0; i+3 < len(coords); i += 2 {
+		sum += coords[i]*coords[i+3] - coords[i+2]*coords[i+1]
+	}
+	return math.Abs(sum) / 2
+}
+
+func max1(n int) int {
+	if n < 1 {
+		return 1
+	}
+	return n
+}
@@ -0,0 +1,105 @@
+package geodata
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+func TestEstimateCost(t *testing.T) {
+	stats := &CostStats{
+		Areas: map[string]int{
+			"LAD":  400,
+			"MSOA": 8000,
+			"OA":   200000,
+		},
+		Categories: map[string]int{
+			"QS101EW": 4,
+		},
+	}
+
+	var tests = map[string]struct {
+		args    CensusQuerySQLArgs
+		want    int
+		wantErr error
+	}{
+		"single metric": {
+			args: CensusQuerySQLArgs{Geos: []string{"E01000001"}, Cols: []string{"QS101EW0001"}},
+			want: 1,
+		},
+		"ranges": {
+			args: CensusQuerySQLArgs{Geos: []string{"E01000001...E01000010,E01000020"}, Cols: []string{"QS101EW0002...QS101EW0004"}},
+			want: 11 * 3,
+		},
+		"special cols are free": {
+			args: CensusQuerySQLArgs{Geos: []string{"E01000001"}, Cols: []string{"geography_code,QS101EW0001"}},
+			want: 1,
+		},
+		"censustable and divide_by": {
+			args: CensusQuerySQLArgs{Geos: []string{"E01000001"}, Censustable: "QS101EW", DivideBy: "QS101EW0001"},
+			want: 5,
+		},
+		"all rows": {
+			args: CensusQuerySQLArgs{Geos: []string{"ALL"}, Cols: []string{"QS101EW0001"}},
+			want: 208400,
+		},
+		"all rows of geotype": {
+			args: CensusQuerySQLArgs{Geos: []string{"ALL"}, Geotypes: []string{"lad,msoa"}, Cols: []string{"QS101EW0001"}},
+			want: 8400,
+		},
+		"bbox covering the UK": {
+			args: CensusQuerySQLArgs{BBox: "-7.57,58.64,1.76,49.91", Geotypes: []string{"LAD"}, Cols: []string{"QS101EW0001"}},
+			want: 400,
+		},
+		"bbox is capped": {
+			args: CensusQuerySQLArgs{BBox: "-20,70,20,40", Geotypes: []string{"LAD"}, Cols: []string{"QS101EW0001"}},
+			want: 400,
+		},
+		"small polygon": {
+			args: CensusQuerySQLArgs{Polygon: testPolygon, Geotypes: []string{"OA"}, Cols: []string{"QS101EW0001"}},
+			want: 3,
+		},
+		"radius": {
+			args: CensusQuerySQLArgs{Location: "0.1338,51.4635", Radius: 10000, Geotypes: []string{"MSOA"}, Cols: []string{"QS101EW0001"}},
+			want: 4,
+		},
+		"bad geotype": {
+			args:    CensusQuerySQLArgs{Geos: []string{"ALL"}, Geotypes: []string{"nope"}},
+			wantErr: sentinel.ErrInvalidParams,
+		},
+		"bad bbox": {
+			args:    CensusQuerySQLArgs{BBox: "1,2,3"},
+			wantErr: sentinel.ErrInvalidParams,
+		},
+	}
+
+	for desc, test := range tests {
+		got, err := EstimateCost(test.args, stats)
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("%s: got error %v, want %v", desc, err, test.wantErr)
+			continue
+		}
+		if err == nil && got != test.want {
+			t.Errorf("%s: got %d, want %d", desc, got, test.want)
+		}
+	}
+}
+
+func TestRangeSize(t *testing.T) {
+	var tests = []struct {
+		low, high string
+		want      int
+	}{
+		{"E01000001", "E01000010", 10},
+		{"QS101EW0002", "QS101EW0004", 3},
+		{"E01000010", "E01000001", unknownRangeSize},
+		{"E01000001", "W01000010", unknownRangeSize},
+		{"abc", "abd", unknownRangeSize},
+	}
+	for _, test := range tests {
+		if got := rangeSize(test.low, test.high); got != test.want {
+			t.Errorf("%s...%s: got %d, want %d", test.low, test.high, got, test.want)
+		}
+	}
+}
@@ -3,7 +3,13 @@ package geodata
 import (
 	"context"
 	"database/sql"
+	"fmt"
 
+	"github.com/ONSdigital/dp-geodata-api/pkg/lang"
+	"github.com/ONSdigital/dp-geodata-api/pkg/timer"
+	"github.com/ONSdigital/dp-geodata-api/pkg/tracing"
+	"github.com/ONSdigital/dp-geodata-api/pkg/where"
+	geom "github.com/twpayne/go-geom"
 	"github.com/twpayne/go-geom/encoding/geojson"
 	"github.com/twpayne/go-geom/encoding/wkb"
 )
@@ -17,13 +23,21 @@ type Resp struct {
 	GeoJSON *geojson.FeatureCollection `json:"geo_json"`
 }
 
-func (app *Geodata) Geo(ctx context.Context, year int, geocode string, geoname string) (*Resp, error) {
+// Geo returns the centroid, boundary and bounding box of the area with geocode, or
+// failing that the area called geoname.
+// Meta.Name is given in language, and a Welsh geoname may be either the English or
+// Welsh name.
+//
+func (app *Geodata) Geo(ctx context.Context, year int, geocode string, geoname string, language string) (*Resp, error) {
+	ctx, span := tracing.Start(ctx, "Geodata.Geo")
+	defer span.End()
+
 	queryString := `
 	SELECT
 		ST_AsBinary(geo.wkb_long_lat_geom),
 		ST_AsBinary(geo.wkb_geometry),
 		ST_AsBinary(ST_BoundingDiagonal(geo.wkb_geometry)),
-		geo.name,
+		` + nameSQL(language) + `,
 		geo.code,
 		geo_type.name
 	FROM
@@ -38,6 +52,12 @@ func (app *Geodata) Geo(ctx context.Context, year int, geocode string, geoname s
 			AND geo.type_id = geo_type.id
 			`
 		queryCondition = geocode
+	} else if language == lang.Welsh {
+		conditionString = `
+			WHERE (geo.name = $1 OR geo.welsh_name = $1)
+			AND geo.type_id = geo_type.id
+			`
+		queryCondition = geoname
 	} else {
 		conditionString = `
 			WHERE geo.name = $1
@@ -48,7 +68,7 @@ func (app *Geodata) Geo(ctx context.Context, year int, geocode string, geoname s
 	fullQueryString := queryString + conditionString
 	stmt, err := app.db.DB().PrepareContext(ctx, fullQueryString)
 	if err != nil {
-		return nil, err
+		return nil, queryError(ctx, err)
 	}
 	defer stmt.Close()
 
@@ -59,7 +79,7 @@ func (app *Geodata) Geo(ctx context.Context, year int, geocode string, geoname s
 		if err == sql.ErrNoRows {
 			return &Resp{}, nil
 		}
-		return nil, err
+		return nil, queryError(ctx, err)
 	}
 
 	// return no data if there is no geometry (this is the case for England and Wales, Regions, and other geotypes)
@@ -95,3 +115,147 @@ func (app *Geodata) Geo(ctx context.Context, year int, geocode string, geoname s
 
 	return r, nil
 }
+
+// boundaries fetches the boundary of each area in geocodes.
+// The result is keyed by geocode; areas without a boundary are not in the map.
+func (app *Geodata) boundaries(ctx context.Context, geocodes []string) (map[string]geom.T, error) {
+	result := map[string]geom.T{}
+	if len(geocodes) == 0 {
+		return result, nil
+	}
+
+	query := `
+SELECT
+	geo.code,
+	ST_AsBinary(geo.wkb_geometry)
+FROM
+	geo
+WHERE geo.valid
+AND geo.wkb_geometry IS NOT NULL
+AND geo.code = ANY( $1 )
+`
+
+	t := timer.New("boundaries")
+	t.Start()
+	rows, err := app.db.DB().QueryContext(ctx, query, geocodes)
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+	defer rows.Close()
+
+	var code string
+	var boundary []byte
+	for rows.Next() {
+		if err := rows.Scan(&code, &boundary); err != nil {
+			return nil, err
+		}
+		g, err := wkb.Unmarshal(boundary)
+		if err != nil {
+			return nil, err
+		}
+		result[code] = g
+	}
+	if err := rows.Err(); err != nil {
+		return nil, queryError(ctx, err)
+	}
+	t.Stop()
+	t.Log(ctx)
+
+	return result, nil
+}
+
+// nameSQL returns the SQL expression for an area's name in language.
+// Areas without a Welsh name keep their English name.
+func nameSQL(language string) string {
+	if language == lang.Welsh {
+		return "COALESCE(NULLIF(geo.welsh_name, ''), geo.name)"
+	}
+	return "geo.name"
+}
+
+// names fetches the name of each area in geocodes, in language.
+func (app *Geodata) names(ctx context.Context, geocodes []string, language string) (map[string]string, error) {
+	if len(geocodes) == 0 {
+		return map[string]string{}, nil
+	}
+	query := fmt.Sprintf(`
+SELECT
+	geo.code,
+	%s
+FROM
+	geo
+WHERE geo.valid
+AND geo.code = ANY( $1 )
+`,
+		nameSQL(language),
+	)
+	return app.queryNames(ctx, query, []interface{}{geocodes})
+}
+
+// AreaNamesSQL returns a query selecting the code and name of each area CensusQuerySQL
+// would select for the same args, along with the values for its placeholders.
+// Names are in args.Lang.
+// It is used to fill in geography_name before streaming results.
+//
+func AreaNamesSQL(args CensusQuerySQLArgs) (string, []interface{}, error) {
+	var sqlArgs where.Args
+
+	geoConditions, err := geoConditionsSQL(args.Geos, args.BBox, args.Location, args.Radius, args.Polygon, &sqlArgs)
+	if err != nil {
+		return "", nil, err
+	}
+	geotypeConditions, err := geotypeSQL("geo_type.name", args.Geotypes, &sqlArgs)
+	if err != nil {
+		return "", nil, err
+	}
+
+	template := `
+SELECT
+	geo.code,
+	%s
+FROM
+	geo,
+	geo_type
+WHERE geo.valid
+AND geo_type.id = geo.type_id
+	-- geotype conditions:
+%s
+	-- geo conditions:
+%s
+`
+	query := fmt.Sprintf(
+		template,
+		nameSQL(args.Lang),
+		geotypeConditions,
+		geoConditions,
+	)
+	return query, sqlArgs.Values(), nil
+}
+
+// queryNames runs query, which must select an area code and name, and returns the
+// names keyed by code.
+func (app *Geodata) queryNames(ctx context.Context, query string, values []interface{}) (map[string]string, error) {
+	t := timer.New("names")
+	t.Start()
+	defer func() {
+		t.Stop()
+		t.Log(ctx)
+	}()
+
+	rows, err := app.db.DB().QueryContext(ctx, query, values...)
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+	defer rows.Close()
+
+	result := map[string]string{}
+	for rows.Next() {
+		var code string
+		var name sql.NullString
+		if err := rows.Scan(&code, &name); err != nil {
+			return nil, err
+		}
+		result[code] = name.String
+	}
+	return result, queryError(ctx, rows.Err())
+}
@@ -0,0 +1,51 @@
+package geodata
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-geodata-api/pkg/lang"
+)
+
+func TestAreaNamesSQL(t *testing.T) {
+	var tests = map[string]struct {
+		args     CensusQuerySQLArgs
+		wantSQL  []string
+		wantArgs []interface{}
+	}{
+		"english rows": {
+			args: CensusQuerySQLArgs{Year: 2011, Geos: []string{"W06000015"}},
+			wantSQL: []string{
+				"SELECT geo.code, geo.name FROM",
+				"geo.code = ANY( $1 )",
+			},
+			wantArgs: []interface{}{[]string{"W06000015"}},
+		},
+		"welsh geotype": {
+			args: CensusQuerySQLArgs{Year: 2011, Geos: []string{"ALL"}, Geotypes: []string{"lad"}, Lang: lang.Welsh},
+			wantSQL: []string{
+				"COALESCE(NULLIF(geo.welsh_name, ''), geo.name)",
+				"geo_type.name = ANY( $1 )",
+			},
+			wantArgs: []interface{}{[]string{"LAD"}},
+		},
+	}
+
+	for desc, test := range tests {
+		sql, values, err := AreaNamesSQL(test.args)
+		if err != nil {
+			t.Errorf("%s: %s", desc, err)
+			continue
+		}
+		flat := strings.Join(strings.Fields(sql), " ")
+		for _, want := range test.wantSQL {
+			if !strings.Contains(flat, want) {
+				t.Errorf("%s: SQL does not contain %q:\n%s", desc, want, flat)
+			}
+		}
+		if !reflect.DeepEqual(values, test.wantArgs) {
+			t.Errorf("%s: got args %#v, want %#v", desc, values, test.wantArgs)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package geodata
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/ONSdigital/dp-geodata-api/cantabular"
@@ -10,6 +12,7 @@ import (
 	"github.com/ONSdigital/dp-geodata-api/pkg/database"
 	"github.com/ONSdigital/dp-geodata-api/pkg/table"
 	"github.com/ONSdigital/dp-geodata-api/pkg/timer"
+	"github.com/ONSdigital/dp-geodata-api/pkg/tracing"
 	"github.com/ONSdigital/dp-geodata-api/pkg/where"
 	"github.com/ONSdigital/dp-geodata-api/sentinel"
 	"github.com/ONSdigital/log.go/v2/log"
@@ -23,6 +26,7 @@ type Geodata struct {
 	db         *database.Database
 	cant       *cantabular.Client
 	maxMetrics int
+	stats      costStats
 }
 
 func New(db *database.Database, cant *cantabular.Client, maxMetrics int) (*Geodata, error) {
@@ -33,31 +37,42 @@ func New(db *database.Database, cant *cantabular.Client, maxMetrics int) (*Geoda
 	}, nil
 }
 
-func (app *Geodata) Query(ctx context.Context, year int, bbox, location string, radius int, polygon string, geotypes, rows, cols []string, censustable, divideby string) (string, error) {
-	return app.censusQuery(ctx, year, rows, bbox, location, radius, polygon, geotypes, cols, censustable, divideby)
+// MaxMetrics is the most metrics Query returns; 0 means no limit.
+func (app *Geodata) MaxMetrics() int {
+	return app.maxMetrics
 }
 
-// collectCells runs the query in sql and returns the results as a csv.
+func (app *Geodata) Query(ctx context.Context, year int, bbox, location string, radius int, polygon string, geotypes, rows, cols []string, censustable, divideby, language string, format table.Format) ([]byte, error) {
+	ctx, span := tracing.Start(ctx, "Geodata.Query")
+	defer span.End()
+
+	return app.censusQuery(ctx, year, rows, bbox, location, radius, polygon, geotypes, cols, censustable, divideby, language, format)
+}
+
+// collectCells runs the query in sql with placeholder values and returns the results in
+// the requested format.
 // sql must be a query against the geo_metric table selecting exactly
 // code, category and metric.
 //
-func (app *Geodata) collectCells(ctx context.Context, sql string, include []string, divideby string) (string, error) {
+func (app *Geodata) collectCells(ctx context.Context, sql string, values []interface{}, include []string, divideby, language string, format table.Format) ([]byte, error) {
 	// Allocate output table
 	//
 	tbl := table.New()
 
 	// Set up output buffer
 	//
-	var body strings.Builder
+	var body bytes.Buffer
 	body.Grow(1000000)
 
 	// Query the db.
 	//
 	t := timer.New("query")
 	t.Start()
-	rows, err := app.db.DB().QueryContext(ctx, sql)
+	qctx, span := tracing.Start(ctx, "db.query")
+	rows, err := app.db.DB().QueryContext(qctx, sql, values...)
+	tracing.End(span, err)
 	if err != nil {
-		return "", err
+		return nil, queryError(ctx, err)
 	}
 	t.Stop()
 	t.Log(ctx)
@@ -77,7 +92,7 @@ func (app *Geodata) collectCells(ctx context.Context, sql string, include []stri
 		nmetrics++
 		if app.maxMetrics > 0 {
 			if nmetrics > app.maxMetrics {
-				return "", fmt.Errorf("%w: limit is %d", sentinel.ErrTooManyMetrics, app.maxMetrics)
+				return nil, fmt.Errorf("%w: limit is %d", sentinel.ErrTooManyMetrics, app.maxMetrics)
 			}
 		}
 
@@ -90,7 +105,7 @@ func (app *Geodata) collectCells(ctx context.Context, sql string, include []stri
 		err := rows.Scan(&geo, &geotype, &cat, &value)
 		tscan.Stop()
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 
 		tbl.SetCell(geo, geotype, cat, value)
@@ -99,24 +114,49 @@ func (app *Geodata) collectCells(ctx context.Context, sql string, include []stri
 	tscan.Log(ctx)
 
 	if err := rows.Err(); err != nil {
-		return "", err
+		return nil, queryError(ctx, err)
 	}
 
 	tgen := timer.New("generate")
 	tgen.Start()
+	gctx, span := tracing.Start(ctx, "table.Generate")
 	if divideby != "" {
-		if err = tbl.DivideBy(divideby); err != nil {
-			return "", err
-		}
+		err = tbl.DivideBy(divideby)
+	}
+	if err == nil {
+		err = app.writeTable(gctx, tbl, &body, include, language, format)
 	}
-	err = tbl.Generate(&body, include)
+	tracing.End(span, err)
 	tgen.Stop()
 	tgen.Log(ctx)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return body.String(), nil
+	return body.Bytes(), nil
+}
+
+// writeTable writes tbl to w in the requested format.
+// GeoJSON needs the boundary of every area in the table, and the geography_name
+// column needs the name of every area in language; each costs an extra query.
+func (app *Geodata) writeTable(ctx context.Context, tbl *table.Table, w io.Writer, include []string, language string, format table.Format) error {
+	if table.WantsName(include) {
+		names, err := app.names(ctx, tbl.Geocodes(), language)
+		if err != nil {
+			return err
+		}
+		tbl.SetNames(names)
+	}
+
+	var boundaries map[string]geom.T
+	if format == table.FormatGeoJSON {
+		var err error
+		boundaries, err = app.boundaries(ctx, tbl.Geocodes())
+		if err != nil {
+			return err
+		}
+	}
+	return tbl.Write(w, format, include, boundaries)
 }
 
 type CensusQuerySQLArgs struct {
@@ -130,6 +170,7 @@ type CensusQuerySQLArgs struct {
 	Cols        []string
 	Censustable string
 	DivideBy    string
+	Lang        string // language of the geography_name column; English if empty
 }
 
 // censusQuery is the merged query which is the logical OR of the other specific queries.
@@ -140,9 +181,9 @@ type CensusQuerySQLArgs struct {
 // Although this query method is not complicated, it is too long.
 // Break it up in the fullness of time.
 //
-func (app *Geodata) censusQuery(ctx context.Context, year int, geos []string, bbox, location string, radius int, polygon string, geotypes, cols []string, censustable, divideby string) (string, error) {
+func (app *Geodata) censusQuery(ctx context.Context, year int, geos []string, bbox, location string, radius int, polygon string, geotypes, cols []string, censustable, divideby, language string, format table.Format) ([]byte, error) {
 
-	sql, include, err := CensusQuerySQL(
+	sql, values, include, err := CensusQuerySQL(
 		ctx,
 		CensusQuerySQLArgs{
 			Year:        year,
@@ -155,92 +196,53 @@ func (app *Geodata) censusQuery(ctx context.Context, year int, geos []string, bb
 			Cols:        cols,
 			Censustable: censustable,
 			DivideBy:    divideby,
+			Lang:        language,
 		},
 	)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	log.Info(ctx, "sql", log.Data{"query": sql})
+	log.Info(ctx, "sql", log.Data{"query": sql, "args": values})
 
-	return app.collectCells(ctx, sql, include, divideby)
+	return app.collectCells(ctx, sql, values, include, divideby, language, format)
 }
 
-func CensusQuerySQL(ctx context.Context, args CensusQuerySQLArgs) (sql string, include []string, err error) {
+// CensusQuerySQL returns the SQL for a census query, along with the values for its
+// placeholders.
+// include lists the special columns, like geography_code, to include in the output.
+//
+func CensusQuerySQL(ctx context.Context, args CensusQuerySQLArgs) (sql string, values []interface{}, include []string, err error) {
+	_, span := tracing.Start(ctx, "CensusQuerySQL")
+	defer func() { tracing.End(span, err) }()
+
 	// validate args
 	if err := validateCensusQuery(args); err != nil {
-		return sql, include, err
-	}
-
-	var geoConditions string
-	if !wantAllRows(args.Geos) {
-		// fetch conditions SQL
-		geoCondition, geoErr := geoSQL(args.Geos)
-		bboxCondition, bboxErr := bboxSQL(args.BBox)
-		radiusCondition, radiusErr := radiusSQL(args.Location, args.Radius)
-		polygonCondition, polygonErr := polygonSQL(args.Polygon)
-
-		// check errs, return on first found
-		for _, err := range []error{
-			geoErr,
-			bboxErr,
-			radiusErr,
-			polygonErr,
-		} {
-			if err != nil {
-				return sql, include, err
-			}
-		}
-
-		// collate join conditions with sql OR
-		var conditions []string
-		for _, condition := range []string{
-			geoCondition,
-			bboxCondition,
-			radiusCondition,
-			polygonCondition,
-		} {
-			if condition != "" {
-				conditions = append(conditions, condition)
-			}
-		}
-		geoConditions = fmt.Sprintf(
-			"AND (\n    %s)\n",
-			strings.Join(conditions, "    OR\n"),
-		)
+		return sql, values, include, err
 	}
 
-	// construct WHERE condition for geotypes
-	geotypeConditions, err := geotypeSQL("geo_type.name", args.Geotypes)
-	if err != nil {
-		return sql, include, err
-	}
+	var sqlArgs where.Args
 
-	// parse cols query strings into a ValueSet
-	catset, err := where.ParseMultiArgs(args.Cols)
+	// construct WHERE condition for geographies
+	geoConditions, err := geoConditionsSQL(args.Geos, args.BBox, args.Location, args.Radius, args.Polygon, &sqlArgs)
 	if err != nil {
-		return sql, include, err
+		return sql, values, include, err
 	}
 
-	// extract special column names from ValueSet
-	include, catset, err = ExtractSpecialCols(catset)
+	// construct WHERE condition for geotypes
+	geotypeConditions, err := geotypeSQL("geo_type.name", args.Geotypes, &sqlArgs)
 	if err != nil {
-		return sql, include, err
-	}
-
-	// ensure divideby is in catset
-	if args.DivideBy != "" {
-		catset.AddSingle(args.DivideBy)
+		return sql, values, include, err
 	}
 
 	// construct WHERE condition for categories
-	catConditions, err := categorySQL(catset, args.Censustable)
+	include, catConditions, err := categoryConditions(args, &sqlArgs)
 	if err != nil {
-		return sql, include, err
+		return sql, values, include, err
 	}
 
 	// construct additional conditions for censustable / short_nomis_code
-	censustableFromSQL, censustableAndSQL := censusTableFromAndSQL(args.Censustable)
+	censustableFromSQL, censustableAndSQL := censusTableFromAndSQL(args.Censustable, &sqlArgs)
 
 	// construct final SQL
 	template := `
@@ -265,7 +267,7 @@ AND geo_type.id = geo.type_id
 %s
 AND geo_metric.geo_id = geo.id
 AND data_ver.id = geo_metric.data_ver_id
-AND data_ver.census_year = %d
+AND data_ver.census_year = %s
 AND data_ver.ver_string = '2.2'
 AND nomis_category.id = geo_metric.category_id
 AND nomis_category.year = data_ver.census_year
@@ -278,10 +280,115 @@ AND nomis_category.year = data_ver.census_year
 		geotypeConditions,
 		geoConditions,
 		censustableAndSQL,
-		args.Year,
+		sqlArgs.Add(args.Year),
+		catConditions,
+	)
+	return sql, sqlArgs.Values(), include, nil
+}
+
+// geoConditionsSQL generates an AND where part which is the logical OR of the rows,
+// bbox, radius and polygon conditions, and any extra conditions.
+// It is empty if rows=ALL.
+func geoConditionsSQL(geos []string, bbox, location string, radius int, polygon string, sqlArgs *where.Args, extra ...string) (string, error) {
+	if wantAllRows(geos) {
+		return "", nil
+	}
+
+	// fetch conditions SQL
+	geoCondition, geoErr := geoSQL(geos, sqlArgs)
+	bboxCondition, bboxErr := bboxSQL(bbox, sqlArgs)
+	radiusCondition, radiusErr := radiusSQL(location, radius, sqlArgs)
+	polygonCondition, polygonErr := polygonSQL(polygon, sqlArgs)
+
+	// check errs, return on first found
+	for _, err := range []error{
+		geoErr,
+		bboxErr,
+		radiusErr,
+		polygonErr,
+	} {
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// collate join conditions with sql OR
+	var conditions []string
+	for _, condition := range append([]string{
+		geoCondition,
+		bboxCondition,
+		radiusCondition,
+		polygonCondition,
+	}, extra...) {
+		if condition != "" {
+			conditions = append(conditions, condition)
+		}
+	}
+	return fmt.Sprintf(
+		"AND (\n    %s)\n",
+		strings.Join(conditions, "    OR\n"),
+	), nil
+}
+
+// CategoryCodesSQL returns a query selecting the long_nomis_code of each category that
+// CensusQuerySQL would select for the same args, ordered by code, along with the values
+// for its placeholders.
+// It is used to find the column headings before streaming results.
+//
+func CategoryCodesSQL(args CensusQuerySQLArgs) (string, []interface{}, error) {
+	var sqlArgs where.Args
+
+	_, catConditions, err := categoryConditions(args, &sqlArgs)
+	if err != nil {
+		return "", nil, err
+	}
+
+	censustableFromSQL, censustableAndSQL := censusTableFromAndSQL(args.Censustable, &sqlArgs)
+
+	template := `
+SELECT
+    nomis_category.long_nomis_code
+FROM
+    nomis_category
+	%s
+WHERE nomis_category.year = %s
+%s
+    -- category conditions:
+%s
+ORDER BY nomis_category.long_nomis_code COLLATE "C"
+`
+	sql := fmt.Sprintf(
+		template,
+		censustableFromSQL,
+		sqlArgs.Add(args.Year),
+		censustableAndSQL,
 		catConditions,
 	)
-	return sql, include, nil
+	return sql, sqlArgs.Values(), nil
+}
+
+// categoryConditions parses args.Cols into the list of special columns to include in the
+// output, and an AND where part selecting the remaining categories (plus divideby).
+func categoryConditions(args CensusQuerySQLArgs, sqlArgs *where.Args) (include []string, conditions string, err error) {
+	// parse cols query strings into a ValueSet
+	catset, err := where.ParseMultiArgs(args.Cols)
+	if err != nil {
+		return include, conditions, err
+	}
+
+	// extract special column names from ValueSet
+	include, catset, err = ExtractSpecialCols(catset)
+	if err != nil {
+		return include, conditions, err
+	}
+
+	// ensure divideby is in catset
+	if args.DivideBy != "" {
+		catset.AddSingle(args.DivideBy)
+	}
+
+	conditions, err = categorySQL(catset, args.Censustable, sqlArgs)
+	return include, conditions, err
 }
 
 func validateCensusQuery(args CensusQuerySQLArgs) error {
@@ -345,15 +452,15 @@ func isAll(token string) bool {
 	return strings.EqualFold(token, allRowsToken)
 }
 
-func geoSQL(geos []string) (string, error) {
+func geoSQL(geos []string, sqlArgs *where.Args) (string, error) {
 	set, err := where.ParseMultiArgs(geos)
 	if err != nil {
 		return "", err
 	}
-	return where.WherePart("geo.code", set), nil
+	return where.WherePart("geo.code", set, sqlArgs), nil
 }
 
-func bboxSQL(bbox string) (string, error) {
+func bboxSQL(bbox string, sqlArgs *where.Args) (string, error) {
 	if bbox == "" {
 		return "", nil
 	}
@@ -373,69 +480,100 @@ func bboxSQL(bbox string) (string, error) {
 	}
 
 	sql := fmt.Sprintf(`
-geo.wkb_geometry && ST_GeomFromText(
-	'MULTIPOINT(%f %f, %f %f)',
+geo.wkb_geometry && ST_SetSRID(
+	ST_Collect(
+		ST_MakePoint(%s, %s),
+		ST_MakePoint(%s, %s)
+	),
 	4326
 )
 `,
-		coords[0],
-		coords[1],
-		coords[2],
-		coords[3],
+		sqlArgs.Add(coords[0]),
+		sqlArgs.Add(coords[1]),
+		sqlArgs.Add(coords[2]),
+		sqlArgs.Add(coords[3]),
 	)
 	return sql, nil
 }
 
-func radiusSQL(location string, radius int) (string, error) {
+func radiusSQL(location string, radius int, sqlArgs *where.Args) (string, error) {
 	if location == "" && radius == 0 {
 		return "", nil
 	}
 
+	coords, err := parseRadius(location, radius)
+	if err != nil {
+		return "", err
+	}
+
+	sql := fmt.Sprintf(`
+ST_DWithin(
+	geo.wkb_long_lat_geom::geography,
+	ST_SetSRID(
+		ST_Point(%s, %s),
+		4326
+	)::geography,
+	%s
+)
+`,
+		sqlArgs.Add(coords[0]),
+		sqlArgs.Add(coords[1]),
+		sqlArgs.Add(radius),
+	)
+	return sql, nil
+}
+
+// parseRadius validates a location and radius pair, returning the location's coordinates.
+func parseRadius(location string, radius int) ([]float64, error) {
 	if location == "" || radius == 0 {
-		return "", fmt.Errorf("%w: radius queries require both location (%s) and radius (%d)", sentinel.ErrInvalidParams, location, radius)
+		return nil, fmt.Errorf("%w: radius queries require both location (%s) and radius (%d)", sentinel.ErrInvalidParams, location, radius)
 	}
 
 	coords, err := parseCoords(location)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	if len(coords) != 2 {
-		return "", fmt.Errorf("%w: location must be a single point", sentinel.ErrInvalidParams)
+		return nil, fmt.Errorf("%w: location must be a single point", sentinel.ErrInvalidParams)
 	}
 	if err := checkValidCoords(coords); err != nil {
-		return "", err
+		return nil, err
 	}
 	// A circle "overlaps" the UK bounding box if its location point is within the UK bounding box.
 	// This isn't correct, but is useful as a basic sanity check.
 	if err := CheckOverlapsUK(coords); err != nil {
-		return "", err
+		return nil, err
 	}
 	if radius < 1 || radius > maxRadius {
-		return "", fmt.Errorf("%w: radius must be 1..%d: %d", sentinel.ErrInvalidParams, maxRadius, radius)
+		return nil, fmt.Errorf("%w: radius must be 1..%d: %d", sentinel.ErrInvalidParams, maxRadius, radius)
+	}
+	return coords, nil
+}
+
+func polygonSQL(polygon string, sqlArgs *where.Args) (string, error) {
+	if polygon == "" {
+		return "", nil
+	}
+
+	linestring, err := parsePolygon(polygon)
+	if err != nil {
+		return "", err
 	}
 
 	sql := fmt.Sprintf(`
-ST_DWithin(
-	geo.wkb_long_lat_geom::geography,
-	ST_SetSRID(
-		ST_Point(%f, %f),
-		4326
-	)::geography,
-	%d
+ST_COVERS(
+	%s,
+	geo.wkb_geometry
 )
 `,
-		coords[0],
-		coords[1],
-		radius,
+		polygonExpr(linestring, sqlArgs),
 	)
 	return sql, nil
 }
 
-func polygonSQL(polygon string) (string, error) {
-	if polygon == "" {
-		return "", nil
-	}
-
+// parsePolygon validates a closed polygon given as a list of lon,lat pairs, returning
+// it as a WKT LINESTRING.
+func parsePolygon(polygon string) (string, error) {
 	coords, err := parseCoords(polygon)
 	if err != nil {
 		return "", err
@@ -456,39 +594,37 @@ func polygonSQL(polygon string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	return "LINESTRING (" + linestring + ")", nil
+}
 
-	sql := fmt.Sprintf(`
-ST_COVERS(
-	ST_Polygon(
-		'LINESTRING (%s)'::geometry,
+// polygonExpr returns an SQL expression for the polygon whose outline is linestring.
+func polygonExpr(linestring string, sqlArgs *where.Args) string {
+	return fmt.Sprintf(`ST_Polygon(
+		ST_GeomFromText(%s),
 		4326
-	),
-	geo.wkb_geometry
-)
-`,
-		linestring,
+	)`,
+		sqlArgs.Add(linestring),
 	)
-	return sql, nil
 }
 
-func censusTableFromAndSQL(censustable string) (string, string) {
+func censusTableFromAndSQL(censustable string, sqlArgs *where.Args) (string, string) {
 	var fromSQL string
 	var andSQL string
 	if censustable != "" {
 		fromSQL = ", nomis_desc"
 		andSQL = fmt.Sprintf(
-			`AND nomis_desc.short_nomis_code = '%s'`,
-			censustable,
+			`AND nomis_desc.short_nomis_code = %s`,
+			sqlArgs.Add(censustable),
 		)
 	}
 	return fromSQL, andSQL
 }
 
-func categorySQL(set *where.ValueSet, censusTable string) (string, error) {
+func categorySQL(set *where.ValueSet, censusTable string, sqlArgs *where.Args) (string, error) {
 	var conditions []string
 
 	// get sql for selecting named categories
-	namedCatSQL := where.WherePart("nomis_category.long_nomis_code", set)
+	namedCatSQL := where.WherePart("nomis_category.long_nomis_code", set, sqlArgs)
 	if namedCatSQL != "" {
 		conditions = append(conditions, namedCatSQL)
 	}
@@ -540,6 +676,7 @@ func ExtractSpecialCols(set *where.ValueSet) ([]string, *where.ValueSet, error)
 func isSpecialCol(col string) bool {
 	specials := map[string]bool{
 		table.ColGeographyCode: true,
+		table.ColGeographyName: true,
 		table.ColGeotype:       true,
 		table.ColGeocodes:      true,
 	}
@@ -547,7 +684,7 @@ func isSpecialCol(col string) bool {
 }
 
 // geotypeSQL generates an AND where part for geotypes.
-func geotypeSQL(col string, args []string) (string, error) {
+func geotypeSQL(col string, args []string, sqlArgs *where.Args) (string, error) {
 	set, err := where.ParseMultiArgs(args)
 	if err != nil {
 		return "", err
@@ -556,7 +693,7 @@ func geotypeSQL(col string, args []string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	body := where.WherePart(col, set)
+	body := where.WherePart(col, set, sqlArgs)
 
 	if body == "" {
 		return "", nil
@@ -23,6 +23,7 @@ func TestGetCensusQuery(t *testing.T) {
 		desc        string
 		args        geodata.CensusQuerySQLArgs
 		wantSQL     string
+		wantArgs    []interface{}
 		wantInclude []string
 		wantErr     error
 	}{
@@ -55,16 +56,17 @@ AND geo_type.id = geo.type_id
  -- geotype conditions:
  -- geo conditions:
 AND (
-    geo.code IN ( 'E01000001' )
+    geo.code = ANY( $1 )
 )
 AND geo_metric.geo_id = geo.id
 AND data_ver.id = geo_metric.data_ver_id
-AND data_ver.census_year = 2011
+AND data_ver.census_year = $2
 AND data_ver.ver_string = '2.2'
 AND nomis_category.id = geo_metric.category_id
 AND nomis_category.year = data_ver.census_year
  -- category conditions:
 `,
+			wantArgs: []interface{}{[]string{"E01000001"}, 2011},
 		},
 		// Bounding Box
 		{
@@ -90,19 +92,23 @@ AND geo_type.id = geo.type_id
  -- geotype conditions:
  -- geo conditions:
 AND (
-geo.wkb_geometry && ST_GeomFromText(
- 'MULTIPOINT(-0.370947 51.362478, 0.176877 51.673778)',
+geo.wkb_geometry && ST_SetSRID(
+ ST_Collect(
+ ST_MakePoint($1, $2),
+ ST_MakePoint($3, $4)
+ ),
  4326
 )
 )
 AND geo_metric.geo_id = geo.id
 AND data_ver.id = geo_metric.data_ver_id
-AND data_ver.census_year = 2011
+AND data_ver.census_year = $5
 AND data_ver.ver_string = '2.2'
 AND nomis_category.id = geo_metric.category_id
 AND nomis_category.year = data_ver.census_year
  -- category conditions:
 `,
+			wantArgs: []interface{}{-0.370947083400182, 51.3624781092781, 0.17687729439413147, 51.673778133460246, 2011},
 		},
 		{
 			desc:    "bbox error - non-numeric data",
@@ -144,19 +150,20 @@ AND geo_type.id = geo.type_id
  -- geotype conditions:
  -- geo conditions:
 AND (
- geo.code IN ( 'E01000001' )
+ geo.code = ANY( $1 )
 )
 AND geo_metric.geo_id = geo.id
 AND data_ver.id = geo_metric.data_ver_id
-AND data_ver.census_year = 2011
+AND data_ver.census_year = $3
 AND data_ver.ver_string = '2.2'
 AND nomis_category.id = geo_metric.category_id
 AND nomis_category.year = data_ver.census_year
  -- category conditions:
 AND (
- nomis_category.long_nomis_code IN ( 'QS119EW0002' )
+ nomis_category.long_nomis_code = ANY( $2 )
 )
 			`,
+			wantArgs: []interface{}{[]string{"E01000001"}, []string{"QS119EW0002"}, 2011},
 		},
 		{
 			desc: "censustable condition with single geography",
@@ -183,12 +190,12 @@ AND geo_type.id = geo.type_id
  -- geotype conditions:
  -- geo conditions:
 AND (
- geo.code IN ( 'E01000001' )
+ geo.code = ANY( $1 )
 )
-AND nomis_desc.short_nomis_code = 'QS101EW'
+AND nomis_desc.short_nomis_code = $2
 AND geo_metric.geo_id = geo.id
 AND data_ver.id = geo_metric.data_ver_id
-AND data_ver.census_year = 2011
+AND data_ver.census_year = $3
 AND data_ver.ver_string = '2.2'
 AND nomis_category.id = geo_metric.category_id
 AND nomis_category.year = data_ver.census_year
@@ -197,6 +204,7 @@ AND (
  nomis_category.nomis_desc_id = nomis_desc.id
 )
  `,
+			wantArgs: []interface{}{[]string{"E01000001"}, "QS101EW", 2011},
 		},
 		{
 			desc: "censustable condition with single col",
@@ -224,22 +232,23 @@ AND geo_type.id = geo.type_id
  -- geotype conditions:
  -- geo conditions:
 AND (
- geo.code IN ( 'E01000001' )
+ geo.code = ANY( $1 )
 )
-AND nomis_desc.short_nomis_code = 'QS101EW'
+AND nomis_desc.short_nomis_code = $3
 AND geo_metric.geo_id = geo.id
 AND data_ver.id = geo_metric.data_ver_id
-AND data_ver.census_year = 2011
+AND data_ver.census_year = $4
 AND data_ver.ver_string = '2.2'
 AND nomis_category.id = geo_metric.category_id
 AND nomis_category.year = data_ver.census_year
  -- category conditions:
 AND (
- nomis_category.long_nomis_code IN ( 'QS119EW0002' )
+ nomis_category.long_nomis_code = ANY( $2 )
  OR
  nomis_category.nomis_desc_id = nomis_desc.id
 )
 `,
+			wantArgs: []interface{}{[]string{"E01000001"}, []string{"QS119EW0002"}, "QS101EW", 2011},
 		},
 		{
 			desc: "censustable condition with multiple col",
@@ -271,22 +280,23 @@ AND geo_type.id = geo.type_id
  -- geotype conditions:
  -- geo conditions:
 AND (
- geo.code IN ( 'E01000001' )
+ geo.code = ANY( $1 )
 )
-AND nomis_desc.short_nomis_code = 'QS101EW'
+AND nomis_desc.short_nomis_code = $3
 AND geo_metric.geo_id = geo.id
 AND data_ver.id = geo_metric.data_ver_id
-AND data_ver.census_year = 2011
+AND data_ver.census_year = $4
 AND data_ver.ver_string = '2.2'
 AND nomis_category.id = geo_metric.category_id
 AND nomis_category.year = data_ver.census_year
  -- category conditions:
 AND (
- nomis_category.long_nomis_code IN ( 'QS119EW0001', 'QS119EW0002', 'QS119EW0003' )
+ nomis_category.long_nomis_code = ANY( $2 )
  OR
  nomis_category.nomis_desc_id = nomis_desc.id
 )
 `,
+			wantArgs: []interface{}{[]string{"E01000001"}, []string{"QS119EW0001", "QS119EW0002", "QS119EW0003"}, "QS101EW", 2011},
 		},
 		{
 			desc: "censustable condition with ranged col",
@@ -314,22 +324,23 @@ AND geo_type.id = geo.type_id
  -- geotype conditions:
  -- geo conditions:
 AND (
- geo.code IN ( 'E01000001' )
+ geo.code = ANY( $1 )
 )
-AND nomis_desc.short_nomis_code = 'QS101EW'
+AND nomis_desc.short_nomis_code = $4
 AND geo_metric.geo_id = geo.id
 AND data_ver.id = geo_metric.data_ver_id
-AND data_ver.census_year = 2011
+AND data_ver.census_year = $5
 AND data_ver.ver_string = '2.2'
 AND nomis_category.id = geo_metric.category_id
 AND nomis_category.year = data_ver.census_year
  -- category conditions:
 AND (
- nomis_category.long_nomis_code BETWEEN 'QS119EW0001' AND 'QS119EW0004'
+ nomis_category.long_nomis_code BETWEEN $2 AND $3
  OR
  nomis_category.nomis_desc_id = nomis_desc.id
 )
 `,
+			wantArgs: []interface{}{[]string{"E01000001"}, "QS119EW0001", "QS119EW0004", "QS101EW", 2011},
 		},
 		{
 			desc: "censustable condition with multiple col and range col",
@@ -362,24 +373,25 @@ AND geo_type.id = geo.type_id
  -- geotype conditions:
  -- geo conditions:
 AND (
- geo.code IN ( 'E01000001' )
+ geo.code = ANY( $1 )
 )
-AND nomis_desc.short_nomis_code = 'QS101EW'
+AND nomis_desc.short_nomis_code = $5
 AND geo_metric.geo_id = geo.id
 AND data_ver.id = geo_metric.data_ver_id
-AND data_ver.census_year = 2011
+AND data_ver.census_year = $6
 AND data_ver.ver_string = '2.2'
 AND nomis_category.id = geo_metric.category_id
 AND nomis_category.year = data_ver.census_year
  -- category conditions:
 AND (
- nomis_category.long_nomis_code IN ( 'QS119EW0001', 'QS119EW0002', 'QS119EW0003' )
+ nomis_category.long_nomis_code = ANY( $2 )
  OR
- nomis_category.long_nomis_code BETWEEN 'QS117EW0001' AND 'QS117EW0003'
+ nomis_category.long_nomis_code BETWEEN $3 AND $4
  OR
  nomis_category.nomis_desc_id = nomis_desc.id
 )
 `,
+			wantArgs: []interface{}{[]string{"E01000001"}, []string{"QS119EW0001", "QS119EW0002", "QS119EW0003"}, "QS117EW0001", "QS117EW0003", "QS101EW", 2011},
 		},
 		{
 			desc:    "all rows, too many tokens",
@@ -410,22 +422,26 @@ AND geo_type.id = geo.type_id
  -- geo conditions:
 AND geo_metric.geo_id = geo.id
 AND data_ver.id = geo_metric.data_ver_id
-AND data_ver.census_year = 2011
+AND data_ver.census_year = $1
 AND data_ver.ver_string = '2.2'
 AND nomis_category.id = geo_metric.category_id
 AND nomis_category.year = data_ver.census_year
  -- category conditions:
 `,
+			wantArgs: []interface{}{2011},
 		},
 	}
 	for _, test := range tests {
 		ctx := context.Background()
-		gotSQL, gotInclude, gotErr := geodata.CensusQuerySQL(ctx, test.args)
+		gotSQL, gotArgs, gotInclude, gotErr := geodata.CensusQuerySQL(ctx, test.args)
 		normedGotSql := normSQL(gotSQL)
 		normedWantSql := normSQL(test.wantSQL)
 		if !reflect.DeepEqual(normedGotSql, normedWantSql) {
 			t.Errorf("%s: returned SQL differs from expected:  %s", test.desc, diff.Diff(normedWantSql, normedGotSql))
 		}
+		if !reflect.DeepEqual(gotArgs, test.wantArgs) {
+			t.Errorf("%s: got args %#v, wanted %#v", test.desc, gotArgs, test.wantArgs)
+		}
 		if !reflect.DeepEqual(gotInclude, test.wantInclude) {
 			t.Errorf("%s: got these geography column values - '%s', wanted '%s'", test.desc, gotInclude, test.wantInclude)
 		}
@@ -439,6 +455,86 @@ AND nomis_category.year = data_ver.census_year
 	}
 }
 
+func TestCategoryCodesSQL(t *testing.T) {
+	var tests = []struct {
+		desc     string
+		args     geodata.CensusQuerySQLArgs
+		wantSQL  string
+		wantArgs []interface{}
+		wantErr  error
+	}{
+		{
+			desc: "cols and divideby",
+			args: geodata.CensusQuerySQLArgs{
+				Year:     2011,
+				Cols:     []string{"geography_code", "QS101EW0002"},
+				DivideBy: "QS101EW0001",
+			},
+			wantSQL: `
+SELECT
+ nomis_category.long_nomis_code
+FROM
+ nomis_category
+WHERE nomis_category.year = $2
+ -- category conditions:
+AND (
+    nomis_category.long_nomis_code = ANY( $1 )
+)
+ORDER BY nomis_category.long_nomis_code COLLATE "C"
+`,
+			wantArgs: []interface{}{[]string{"QS101EW0002", "QS101EW0001"}, 2011},
+		},
+		{
+			desc: "censustable",
+			args: geodata.CensusQuerySQLArgs{
+				Year:        2011,
+				Censustable: "QS101EW",
+			},
+			wantSQL: `
+SELECT
+ nomis_category.long_nomis_code
+FROM
+ nomis_category
+ , nomis_desc
+WHERE nomis_category.year = $2
+AND nomis_desc.short_nomis_code = $1
+ -- category conditions:
+AND (
+ nomis_category.nomis_desc_id = nomis_desc.id
+)
+ORDER BY nomis_category.long_nomis_code COLLATE "C"
+`,
+			wantArgs: []interface{}{"QS101EW", 2011},
+		},
+		{
+			desc:    "special column in range",
+			args:    geodata.CensusQuerySQLArgs{Year: 2011, Cols: []string{"geotype...QS101EW0001"}},
+			wantErr: sentinel.ErrInvalidParams,
+		},
+	}
+	for _, test := range tests {
+		gotSQL, gotArgs, gotErr := geodata.CategoryCodesSQL(test.args)
+		if test.wantErr != nil {
+			if !errors.Is(gotErr, test.wantErr) {
+				t.Errorf("%s: got this error = '%s', wanted '%s'", test.desc, gotErr, test.wantErr)
+			}
+			continue
+		}
+		if gotErr != nil {
+			t.Errorf("%s: got this error - '%s', wanted nil", test.desc, gotErr)
+			continue
+		}
+		normedGotSql := normSQL(gotSQL)
+		normedWantSql := normSQL(test.wantSQL)
+		if normedGotSql != normedWantSql {
+			t.Errorf("%s: returned SQL differs from expected:  %s", test.desc, diff.Diff(normedWantSql, normedGotSql))
+		}
+		if !reflect.DeepEqual(gotArgs, test.wantArgs) {
+			t.Errorf("%s: got args %#v, wanted %#v", test.desc, gotArgs, test.wantArgs)
+		}
+	}
+}
+
 // normalise sql to single spaces and newlines only, with no blank lines
 func normSQL(sql string) string {
 	// replace all whitespace except newlines with single space
@@ -0,0 +1,179 @@
+package geodata
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/ONSdigital/dp-geodata-api/model"
+	"github.com/ONSdigital/dp-geodata-api/pkg/timer"
+	"github.com/ONSdigital/dp-geodata-api/pkg/tracing"
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+// Area identifies a single geography.
+type Area struct {
+	Code    string `json:"code"`
+	Name    string `json:"name"`
+	Geotype string `json:"geotype"`
+}
+
+type ParentsResp struct {
+	Meta    Area   `json:"meta"`
+	Parents []Area `json:"parents"`
+}
+
+type ChildrenResp struct {
+	Meta     Area   `json:"meta"`
+	Children []Area `json:"children"`
+}
+
+// Parents returns every area containing the area with the given geocode, largest first.
+func (app *Geodata) Parents(ctx context.Context, year int, geocode string) (*ParentsResp, error) {
+	ctx, span := tracing.Start(ctx, "Geodata.Parents")
+	defer span.End()
+
+	area, err := app.area(ctx, geocode)
+	if err != nil {
+		return nil, err
+	}
+
+	parents, err := app.related(ctx, parentsSQL, geocode)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ParentsResp{
+		Meta:    *area,
+		Parents: parents,
+	}, nil
+}
+
+// Children returns the areas of the given geotype contained in the area with the given geocode.
+// If geotype is empty, the next geotype down is used, eg LSOAs for an MSOA.
+func (app *Geodata) Children(ctx context.Context, year int, geocode, geotype string) (*ChildrenResp, error) {
+	ctx, span := tracing.Start(ctx, "Geodata.Children")
+	defer span.End()
+
+	area, err := app.area(ctx, geocode)
+	if err != nil {
+		return nil, err
+	}
+
+	if geotype == "" {
+		geotype, err = childGeotype(area.Geotype)
+	} else {
+		geotype, err = FixGeotype(geotype)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	children, err := app.related(ctx, childrenSQL, geocode, geotype)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ChildrenResp{
+		Meta:     *area,
+		Children: children,
+	}, nil
+}
+
+// childGeotype returns the geotype one level below geotype.
+func childGeotype(geotype string) (string, error) {
+	geotypes := model.GetGeoTypeValues() // largest first
+	for i, gt := range geotypes {
+		if gt == geotype && i+1 < len(geotypes) {
+			return geotypes[i+1], nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s has no smaller geotype", sentinel.ErrInvalidParams, geotype)
+}
+
+// area looks up a single area by geocode.
+func (app *Geodata) area(ctx context.Context, geocode string) (*Area, error) {
+	query := `
+SELECT
+	geo.code,
+	geo.name,
+	geo_type.name
+FROM
+	geo,
+	geo_type
+WHERE geo.valid
+AND geo_type.id = geo.type_id
+AND geo.code = $1
+`
+	var area Area
+	err := app.db.DB().QueryRowContext(ctx, query, geocode).Scan(&area.Code, &area.Name, &area.Geotype)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: geocode %s", sentinel.ErrNotFound, geocode)
+	}
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+	return &area, nil
+}
+
+// related runs a parentsSQL or childrenSQL query.
+func (app *Geodata) related(ctx context.Context, query string, values ...interface{}) ([]Area, error) {
+	t := timer.New("hierarchy")
+	t.Start()
+	rows, err := app.db.DB().QueryContext(ctx, query, values...)
+	t.Stop()
+	t.Log(ctx)
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+	defer rows.Close()
+
+	areas := []Area{}
+	for rows.Next() {
+		var area Area
+		if err := rows.Scan(&area.Code, &area.Name, &area.Geotype); err != nil {
+			return nil, err
+		}
+		areas = append(areas, area)
+	}
+	return areas, queryError(ctx, rows.Err())
+}
+
+const parentsSQL = `
+SELECT
+	parent.code,
+	parent.name,
+	parent_type.name
+FROM
+	geo AS child,
+	geo_hierarchy,
+	geo AS parent,
+	geo_type AS parent_type
+WHERE child.valid
+AND child.code = $1
+AND geo_hierarchy.child_id = child.id
+AND parent.id = geo_hierarchy.parent_id
+AND parent.valid
+AND parent_type.id = parent.type_id
+ORDER BY parent.type_id, parent.code
+`
+
+const childrenSQL = `
+SELECT
+	child.code,
+	child.name,
+	child_type.name
+FROM
+	geo AS parent,
+	geo_hierarchy,
+	geo AS child,
+	geo_type AS child_type
+WHERE parent.valid
+AND parent.code = $1
+AND geo_hierarchy.parent_id = parent.id
+AND child.id = geo_hierarchy.child_id
+AND child.valid
+AND child_type.id = child.type_id
+AND child_type.name = $2
+ORDER BY child.code
+`
@@ -0,0 +1,34 @@
+package geodata
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+func TestChildGeotype(t *testing.T) {
+	var tests = []struct {
+		geotype string
+		want    string
+		wantErr error
+	}{
+		{"EW", "Country", nil},
+		{"LAD", "MSOA", nil},
+		{"MSOA", "LSOA", nil},
+		{"LSOA", "OA", nil},
+		{"OA", "", sentinel.ErrInvalidParams},
+		{"lad", "", sentinel.ErrInvalidParams},
+	}
+
+	for _, test := range tests {
+		got, err := childGeotype(test.geotype)
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("%s: got error %v, want %v", test.geotype, err, test.wantErr)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: got %q, want %q", test.geotype, got, test.want)
+		}
+	}
+}
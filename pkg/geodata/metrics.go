@@ -9,13 +9,16 @@ import (
 	"github.com/ONSdigital/dp-geodata-api/cantabular"
 	"github.com/ONSdigital/dp-geodata-api/pkg/table"
 	"github.com/ONSdigital/dp-geodata-api/pkg/timer"
+	"github.com/ONSdigital/dp-geodata-api/pkg/tracing"
 	"github.com/ONSdigital/dp-geodata-api/pkg/where"
 	"github.com/ONSdigital/dp-geodata-api/sentinel"
-	"github.com/lib/pq"
 )
 
 // Retrieve metrics from postgres.
 func (app *Geodata) PGMetrics(ctx context.Context, year int, geocodes []string, catset *where.ValueSet, include []string, censustable string) ([]byte, error) {
+	ctx, span := tracing.Start(ctx, "Geodata.PGMetrics")
+	defer span.End()
+
 	tbl := table.New()
 
 	var body bytes.Buffer
@@ -29,16 +32,16 @@ func (app *Geodata) PGMetrics(ctx context.Context, year int, geocodes []string,
 		return body.Bytes(), nil
 	}
 
-	sql, include, err := app.metricsSQL(ctx, year, geocodes, catset, include, censustable)
+	sql, values, include, err := app.metricsSQL(ctx, year, geocodes, catset, include, censustable)
 	if err != nil {
 		return nil, err
 	}
 
 	t := timer.New("query")
 	t.Start()
-	rows, err := app.db.DB().QueryContext(ctx, sql)
+	rows, err := app.db.DB().QueryContext(ctx, sql, values...)
 	if err != nil {
-		return nil, err
+		return nil, queryError(ctx, err)
 	}
 	t.Stop()
 	t.Log(ctx)
@@ -80,7 +83,7 @@ func (app *Geodata) PGMetrics(ctx context.Context, year int, geocodes []string,
 	tscan.Log(ctx)
 
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return nil, queryError(ctx, err)
 	}
 
 	tgen := timer.New("generate")
@@ -95,22 +98,23 @@ func (app *Geodata) PGMetrics(ctx context.Context, year int, geocodes []string,
 	return body.Bytes(), nil
 }
 
-// XXX mv geoCondition into a generic function in where package, or parse geocodes as a valueset
-func (app *Geodata) metricsSQL(ctx context.Context, year int, geocodes []string, catset *where.ValueSet, include []string, censustable string) (string, []string, error) {
-	// construct AND geo.code IN (...)
+func (app *Geodata) metricsSQL(ctx context.Context, year int, geocodes []string, catset *where.ValueSet, include []string, censustable string) (string, []interface{}, []string, error) {
+	var sqlArgs where.Args
+
+	// construct AND geo.code = ANY(...)
 	geoCondition := fmt.Sprintf(
-		"AND geo.code IN (%s)",
-		quoteCodes(geocodes),
+		"AND geo.code = ANY( %s )",
+		sqlArgs.Add(geocodes),
 	)
 
 	// construct WHERE condition for categories
-	catConditions, err := categorySQL(catset, censustable)
+	catConditions, err := categorySQL(catset, censustable, &sqlArgs)
 	if err != nil {
-		return "", nil, err
+		return "", nil, nil, err
 	}
 
 	// construct additional conditions for censustable / short_nomis_code
-	censustableFromSQL, censustableAndSQL := censusTableFromAndSQL(censustable)
+	censustableFromSQL, censustableAndSQL := censusTableFromAndSQL(censustable, &sqlArgs)
 
 	// construct SQL
 	template := `
@@ -134,7 +138,7 @@ AND geo_type.id = geo.type_id
 %s
 AND geo_metric.geo_id = geo.id
 AND data_ver.id = geo_metric.data_ver_id
-AND data_ver.census_year = %d
+AND data_ver.census_year = %s
 AND data_ver.ver_string = '2.2'
 AND nomis_category.id = geo_metric.category_id
 AND nomis_category.year = data_ver.census_year
@@ -147,23 +151,18 @@ AND nomis_category.year = data_ver.census_year
 		censustableFromSQL,
 		geoCondition,
 		censustableAndSQL,
-		year,
+		sqlArgs.Add(year),
 		catConditions,
 	)
 
-	return sql, include, nil
-}
-
-func quoteCodes(geocodes []string) string {
-	var quoted []string
-	for _, code := range geocodes {
-		quoted = append(quoted, pq.QuoteLiteral(code))
-	}
-	return strings.Join(quoted, ",")
+	return sql, sqlArgs.Values(), include, nil
 }
 
 // Retrieve metrics from Cantabular.
 func (app *Geodata) CantabularMetrics(ctx context.Context, geocodes []string, catset *where.ValueSet, geotype string) ([]byte, error) {
+	ctx, span := tracing.Start(ctx, "Geodata.CantabularMetrics")
+	defer span.End()
+
 	if app.cant == nil {
 		return nil, fmt.Errorf("%w: cantabular not enabled", sentinel.ErrNotSupported)
 	}
@@ -0,0 +1,49 @@
+package geodata
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/ONSdigital/dp-geodata-api/pkg/tracing"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// LastModified returns when the data for the given census years was last changed,
+// according to data_ver.updated_at.
+// The time is zero if none of the years have any data.
+func (app *Geodata) LastModified(ctx context.Context, years ...int) (time.Time, error) {
+	ctx, span := tracing.Start(ctx, "Geodata.LastModified")
+	defer span.End()
+
+	values := []interface{}{toInt64s(years)}
+	log.Info(ctx, "sql", log.Data{"query": LastModifiedSQL, "args": values})
+
+	var modified sql.NullTime
+	err := app.db.DB().QueryRowContext(ctx, LastModifiedSQL, values...).Scan(&modified)
+	if err != nil {
+		return time.Time{}, queryError(ctx, err)
+	}
+	if !modified.Valid {
+		return time.Time{}, nil
+	}
+	return modified.Time.UTC(), nil
+}
+
+// LastModifiedSQL finds the latest update to any data version in a set of census years.
+const LastModifiedSQL = `
+SELECT
+	MAX(data_ver.updated_at)
+FROM
+	data_ver
+WHERE data_ver.census_year = ANY( $1 )
+AND data_ver.deleted_at IS NULL
+`
+
+func toInt64s(ints []int) []int64 {
+	int64s := make([]int64, 0, len(ints))
+	for _, i := range ints {
+		int64s = append(int64s, int64(i))
+	}
+	return int64s
+}
@@ -3,15 +3,25 @@ package geodata
 import (
 	"context"
 	"fmt"
-	"strings"
 
 	"github.com/ONSdigital/dp-geodata-api/pkg/timer"
+	"github.com/ONSdigital/dp-geodata-api/pkg/tracing"
+	"github.com/ONSdigital/dp-geodata-api/pkg/where"
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
 )
 
 // Proposed replacement for Query.
 // This version separates selecting geocodes from selecting metrics.
-func (app *Geodata) Query2(ctx context.Context, year int, bbox, location string, radius int, polygon string, geotypes, geos []string) ([]string, error) {
-	err := validateCensusQuery(
+//
+// As well as the conditions understood by Query, near and k select the k areas nearest
+// to the lon,lat pair near, and adjacent selects the areas sharing a boundary with the
+// area whose geocode is adjacent.
+//
+func (app *Geodata) Query2(ctx context.Context, year int, bbox, location string, radius int, polygon, near string, k int, adjacent string, geotypes, geos []string) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "Geodata.Query2")
+	defer span.End()
+
+	err := validateQuery2(
 		CensusQuerySQLArgs{
 			Year:     year,
 			Geos:     geos,
@@ -21,21 +31,30 @@ func (app *Geodata) Query2(ctx context.Context, year int, bbox, location string,
 			Polygon:  polygon,
 			Geotypes: geotypes,
 		},
+		near,
+		adjacent,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	sql, err := geocodesSQL(year, bbox, location, radius, polygon, geotypes, geos)
+	// otherwise an unknown adjacent geocode just selects nothing
+	if adjacent != "" {
+		if _, err := app.area(ctx, adjacent); err != nil {
+			return nil, err
+		}
+	}
+
+	sql, values, err := geocodesSQL(year, bbox, location, radius, polygon, near, k, adjacent, geotypes, geos)
 	if err != nil {
 		return nil, err
 	}
 
 	t := timer.New("query")
 	t.Start()
-	rows, err := app.db.DB().QueryContext(ctx, sql)
+	rows, err := app.db.DB().QueryContext(ctx, sql, values...)
 	if err != nil {
-		return nil, err
+		return nil, queryError(ctx, err)
 	}
 	t.Stop()
 	t.Log(ctx)
@@ -67,55 +86,49 @@ func (app *Geodata) Query2(ctx context.Context, year int, bbox, location string,
 	tscan.Log(ctx)
 
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return nil, queryError(ctx, err)
 	}
 
 	return result, nil
 }
 
-func geocodesSQL(year int, bbox, location string, radius int, polygon string, geotypes, geos []string) (string, error) {
-	var geoConditions string
-	if !wantAllRows(geos) {
-		// fetch conditions SQL
-		geoCondition, geoErr := geoSQL(geos)
-		bboxCondition, bboxErr := bboxSQL(bbox)
-		radiusCondition, radiusErr := radiusSQL(location, radius)
-		polygonCondition, polygonErr := polygonSQL(polygon)
-
-		// check errs, return on first found
-		for _, err := range []error{
-			geoErr,
-			bboxErr,
-			radiusErr,
-			polygonErr,
-		} {
-			if err != nil {
-				return "", err
-			}
-		}
+// validateQuery2 is validateCensusQuery, except near and adjacent also count as
+// conditions.
+func validateQuery2(args CensusQuerySQLArgs, near, adjacent string) error {
+	if near == "" && adjacent == "" {
+		return validateCensusQuery(args)
+	}
 
-		// collate join conditions with sql OR
-		var conditions []string
-		for _, condition := range []string{
-			geoCondition,
-			bboxCondition,
-			radiusCondition,
-			polygonCondition,
-		} {
-			if condition != "" {
-				conditions = append(conditions, condition)
-			}
-		}
-		geoConditions = fmt.Sprintf(
-			"AND (\n    %s)\n",
-			strings.Join(conditions, "    OR\n"),
-		)
+	set, err := where.ParseMultiArgs(args.Geos)
+	if err != nil {
+		return err
+	}
+	return ValidateAllToken(set)
+}
+
+func geocodesSQL(year int, bbox, location string, radius int, polygon, near string, k int, adjacent string, geotypes, geos []string) (string, []interface{}, error) {
+	var sqlArgs where.Args
+
+	// construct near and adjacent conditions, which only Query2 understands
+	if wantAllRows(geos) && (near != "" || k != 0 || adjacent != "") {
+		return "", nil, fmt.Errorf("%w: near, k and adjacent cannot be used with rows=ALL", sentinel.ErrInvalidParams)
+	}
+	nearCondition, err := nearSQL(near, k, geotypes, &sqlArgs)
+	if err != nil {
+		return "", nil, err
+	}
+	adjacentCondition := adjacentSQL(adjacent, &sqlArgs)
+
+	// construct WHERE condition for geographies
+	geoConditions, err := geoConditionsSQL(geos, bbox, location, radius, polygon, &sqlArgs, nearCondition, adjacentCondition)
+	if err != nil {
+		return "", nil, err
 	}
 
 	// construct WHERE condition for geotypes
-	geotypeConditions, err := geotypeSQL("geo_type.name", geotypes)
+	geotypeConditions, err := geotypeSQL("geo_type.name", geotypes, &sqlArgs)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	// construct SQL
@@ -138,5 +151,94 @@ AND geo_type.id = geo.type_id
 		geotypeConditions,
 		geoConditions,
 	)
+	return sql, sqlArgs.Values(), nil
+}
+
+// nearSQL generates a condition selecting the k areas of the given geotypes whose
+// centroids are nearest to the lon,lat pair near.
+// The geotypes have to be applied inside the subquery, otherwise the nearest areas
+// would be mostly of the wrong type.
+func nearSQL(near string, k int, geotypes []string, sqlArgs *where.Args) (string, error) {
+	if near == "" && k == 0 {
+		return "", nil
+	}
+
+	if near == "" || k == 0 {
+		return "", fmt.Errorf("%w: nearest neighbour queries require both near (%s) and k (%d)", sentinel.ErrInvalidParams, near, k)
+	}
+	if len(geotypes) == 0 {
+		return "", fmt.Errorf("%w: nearest neighbour queries require a geotype", sentinel.ErrMissingParams)
+	}
+	if k < 1 || k > maxNearest {
+		return "", fmt.Errorf("%w: k must be 1..%d: %d", sentinel.ErrInvalidParams, maxNearest, k)
+	}
+
+	coords, err := parseCoords(near)
+	if err != nil {
+		return "", err
+	}
+	if len(coords) != 2 {
+		return "", fmt.Errorf("%w: near must be a single point", sentinel.ErrInvalidParams)
+	}
+	if err := checkValidCoords(coords); err != nil {
+		return "", err
+	}
+	if err := CheckOverlapsUK(coords); err != nil {
+		return "", err
+	}
+
+	geotypeConditions, err := geotypeSQL("near_type.name", geotypes, sqlArgs)
+	if err != nil {
+		return "", err
+	}
+
+	sql := fmt.Sprintf(`
+geo.id IN (
+	SELECT
+		near.id
+	FROM
+		geo AS near,
+		geo_type AS near_type
+	WHERE near.valid
+	AND near_type.id = near.type_id
+	%s
+	ORDER BY near.wkb_long_lat_geom <-> ST_SetSRID(
+		ST_Point(%s, %s),
+		4326
+	)
+	LIMIT %s
+)
+`,
+		geotypeConditions,
+		sqlArgs.Add(coords[0]),
+		sqlArgs.Add(coords[1]),
+		sqlArgs.Add(k),
+	)
 	return sql, nil
 }
+
+// adjacentSQL generates a condition selecting the areas which share a boundary with
+// the area whose geocode is adjacent.
+// Areas which contain or are contained by the adjacent area do not touch it, so are
+// not selected.
+func adjacentSQL(adjacent string, sqlArgs *where.Args) string {
+	if adjacent == "" {
+		return ""
+	}
+
+	return fmt.Sprintf(`
+ST_Touches(
+	geo.wkb_geometry,
+	(
+		SELECT
+			adjacent.wkb_geometry
+		FROM
+			geo AS adjacent
+		WHERE adjacent.valid
+		AND adjacent.code = %s
+	)
+)
+`,
+		sqlArgs.Add(adjacent),
+	)
+}
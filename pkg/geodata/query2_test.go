@@ -0,0 +1,167 @@
+package geodata
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+func TestGeocodesSQL_NearAdjacent(t *testing.T) {
+	var tests = []struct {
+		desc     string
+		near     string
+		k        int
+		adjacent string
+		geotypes []string
+		geos     []string
+		wantSQL  []string
+		wantArgs []interface{}
+		wantErr  error
+	}{
+		{
+			desc:     "near",
+			near:     "-0.1,51.5",
+			k:        5,
+			geotypes: []string{"msoa"},
+			wantSQL: []string{
+				"geo.id IN ( SELECT near.id",
+				"near_type.name = ANY( $1 )",
+				"ORDER BY near.wkb_long_lat_geom <-> ST_SetSRID( ST_Point($2, $3), 4326 ) LIMIT $4",
+				"geo_type.name = ANY( $5 )",
+			},
+			wantArgs: []interface{}{[]string{"MSOA"}, -0.1, 51.5, 5, []string{"MSOA"}},
+		},
+		{
+			desc:     "adjacent",
+			adjacent: "E02000001",
+			wantSQL: []string{
+				"ST_Touches( geo.wkb_geometry,",
+				"adjacent.code = $1",
+			},
+			wantArgs: []interface{}{"E02000001"},
+		},
+		{
+			desc:     "adjacent OR rows",
+			adjacent: "E02000001",
+			geos:     []string{"E02000002"},
+			wantSQL: []string{
+				"adjacent.code = $1",
+				"geo.code = ANY( $2 )",
+				"OR",
+			},
+			wantArgs: []interface{}{"E02000001", []string{"E02000002"}},
+		},
+		{
+			desc:     "rows=ALL with near",
+			near:     "-0.1,51.5",
+			k:        5,
+			geotypes: []string{"MSOA"},
+			geos:     []string{"ALL"},
+			wantErr:  sentinel.ErrInvalidParams,
+		},
+		{
+			desc:     "rows=ALL with adjacent",
+			adjacent: "E02000001",
+			geos:     []string{"ALL"},
+			wantErr:  sentinel.ErrInvalidParams,
+		},
+		{
+			desc:     "near without k",
+			near:     "-0.1,51.5",
+			geotypes: []string{"MSOA"},
+			wantErr:  sentinel.ErrInvalidParams,
+		},
+		{
+			desc:     "k without near",
+			k:        5,
+			geotypes: []string{"MSOA"},
+			wantErr:  sentinel.ErrInvalidParams,
+		},
+		{
+			desc:    "near without geotype",
+			near:    "-0.1,51.5",
+			k:       5,
+			wantErr: sentinel.ErrMissingParams,
+		},
+		{
+			desc:     "k too large",
+			near:     "-0.1,51.5",
+			k:        maxNearest + 1,
+			geotypes: []string{"MSOA"},
+			wantErr:  sentinel.ErrInvalidParams,
+		},
+		{
+			desc:     "near not a point",
+			near:     "-0.1,51.5,-0.2,51.6",
+			k:        5,
+			geotypes: []string{"MSOA"},
+			wantErr:  sentinel.ErrInvalidParams,
+		},
+		{
+			desc:     "near outside UK",
+			near:     "100,10",
+			k:        5,
+			geotypes: []string{"MSOA"},
+			wantErr:  sentinel.ErrInvalidParams,
+		},
+	}
+
+	for _, test := range tests {
+		sql, values, err := geocodesSQL(2011, "", "", 0, "", test.near, test.k, test.adjacent, test.geotypes, test.geos)
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("%s: got error %v, want %v", test.desc, err, test.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		flat := strings.Join(strings.Fields(sql), " ")
+		for _, want := range test.wantSQL {
+			if !strings.Contains(flat, want) {
+				t.Errorf("%s: SQL does not contain %q:\n%s", test.desc, want, flat)
+			}
+		}
+		if !reflect.DeepEqual(values, test.wantArgs) {
+			t.Errorf("%s: got args %#v, want %#v", test.desc, values, test.wantArgs)
+		}
+	}
+}
+
+func TestValidateQuery2(t *testing.T) {
+	var tests = []struct {
+		desc     string
+		args     CensusQuerySQLArgs
+		near     string
+		adjacent string
+		wantErr  error
+	}{
+		{
+			desc:    "no conditions",
+			wantErr: sentinel.ErrMissingParams,
+		},
+		{
+			desc: "near only",
+			near: "-0.1,51.5",
+		},
+		{
+			desc:     "adjacent only",
+			adjacent: "E02000001",
+		},
+		{
+			desc:     "ALL must be alone",
+			args:     CensusQuerySQLArgs{Geos: []string{"ALL,E01000001"}},
+			adjacent: "E02000001",
+			wantErr:  sentinel.ErrInvalidParams,
+		},
+	}
+
+	for _, test := range tests {
+		err := validateQuery2(test.args, test.near, test.adjacent)
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("%s: got error %v, want %v", test.desc, err, test.wantErr)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package geodata
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/ONSdigital/dp-geodata-api/pkg/timer"
+	"github.com/ONSdigital/dp-geodata-api/pkg/tracing"
+	"github.com/ONSdigital/dp-geodata-api/pkg/where"
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+const (
+	defaultSearchLimit = 10
+	maxSearchLimit     = 100
+)
+
+// SearchResult is a candidate area returned by Search.
+type SearchResult struct {
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	WelshName string    `json:"welsh_name,omitempty"`
+	Geotype   string    `json:"geotype"`
+	BBox      []float64 `json:"bbox,omitempty"` // minx, miny, maxx, maxy as in geoLookup.json
+	Score     float64   `json:"score"`
+}
+
+// Search finds the areas whose English or Welsh names best match q, best match first.
+// Matching uses pg_trgm word similarity, so q can be part of a name, or misspelt.
+// geotypes restricts the results to those geotypes, and limit to that many results.
+func (app *Geodata) Search(ctx context.Context, year int, q string, geotypes []string, limit int) ([]SearchResult, error) {
+	ctx, span := tracing.Start(ctx, "Geodata.Search")
+	defer span.End()
+
+	query, values, err := SearchSQL(q, geotypes, limit)
+	if err != nil {
+		return nil, err
+	}
+	log.Info(ctx, "sql", log.Data{"query": query, "args": values})
+	return app.searchResults(ctx, query, values)
+}
+
+// SearchCode returns the area with the given geocode, and the areas containing it, as
+// search results, smallest first, for lookups which resolve to a geocode, such as
+// postcodes.
+// geotypes and limit apply as in Search.
+// The result is empty if there is no such area.
+func (app *Geodata) SearchCode(ctx context.Context, year int, geocode string, geotypes []string, limit int) ([]SearchResult, error) {
+	ctx, span := tracing.Start(ctx, "Geodata.SearchCode")
+	defer span.End()
+
+	query, values, err := SearchCodeSQL(geocode, geotypes, limit)
+	if err != nil {
+		return nil, err
+	}
+	log.Info(ctx, "sql", log.Data{"query": query, "args": values})
+	return app.searchResults(ctx, query, values)
+}
+
+func (app *Geodata) searchResults(ctx context.Context, query string, values []interface{}) ([]SearchResult, error) {
+	t := timer.New("search")
+	t.Start()
+	rows, err := app.db.DB().QueryContext(ctx, query, values...)
+	t.Stop()
+	t.Log(ctx)
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+	defer rows.Close()
+
+	results := []SearchResult{}
+	for rows.Next() {
+		var result SearchResult
+		var welsh sql.NullString
+		var xmin, ymin, xmax, ymax sql.NullFloat64
+		err := rows.Scan(
+			&result.Code,
+			&result.Name,
+			&welsh,
+			&result.Geotype,
+			&xmin,
+			&ymin,
+			&xmax,
+			&ymax,
+			&result.Score,
+		)
+		if err != nil {
+			return nil, err
+		}
+		result.WelshName = welsh.String
+		if xmin.Valid && ymin.Valid && xmax.Valid && ymax.Valid {
+			result.BBox = []float64{xmin.Float64, ymin.Float64, xmax.Float64, ymax.Float64}
+		}
+		results = append(results, result)
+	}
+	return results, queryError(ctx, rows.Err())
+}
+
+// SearchSQL returns the SQL for a place name search, along with the values for its
+// placeholders.
+//
+func SearchSQL(q string, geotypes []string, limit int) (string, []interface{}, error) {
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return "", nil, fmt.Errorf("%w: q required", sentinel.ErrMissingParams)
+	}
+	limit, err := searchLimit(limit)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var sqlArgs where.Args
+	term := sqlArgs.Add(q)
+
+	geotypeConditions, err := geotypeSQL("geo_type.name", geotypes, &sqlArgs)
+	if err != nil {
+		return "", nil, err
+	}
+
+	template := `
+SELECT
+	%[1]s,
+	GREATEST(
+		word_similarity(%[2]s, geo.name),
+		word_similarity(%[2]s, COALESCE(geo.welsh_name, ''))
+	) AS score
+FROM
+	geo,
+	geo_type
+WHERE geo.valid
+AND geo_type.id = geo.type_id
+AND (
+	%[2]s <%% geo.name
+	OR
+	%[2]s <%% geo.welsh_name
+)
+	-- geotype conditions:
+%[3]s
+ORDER BY
+	score DESC,
+	similarity(%[2]s, geo.name) DESC,
+	geo.type_id,
+	geo.code
+LIMIT %[4]s
+`
+	query := fmt.Sprintf(
+		template,
+		searchCols,
+		term,
+		geotypeConditions,
+		sqlArgs.Add(limit),
+	)
+	return query, sqlArgs.Values(), nil
+}
+
+// SearchCodeSQL returns the SQL for SearchCode, along with the values for its
+// placeholders.
+func SearchCodeSQL(geocode string, geotypes []string, limit int) (string, []interface{}, error) {
+	limit, err := searchLimit(limit)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var sqlArgs where.Args
+	code := sqlArgs.Add(geocode)
+
+	geotypeConditions, err := geotypeSQL("geo_type.name", geotypes, &sqlArgs)
+	if err != nil {
+		return "", nil, err
+	}
+
+	template := `
+SELECT
+	%[1]s,
+	1 AS score
+FROM
+	geo,
+	geo_type
+WHERE geo.valid
+AND geo_type.id = geo.type_id
+AND (
+	geo.code = %[2]s
+	OR
+	geo.id IN (
+		SELECT
+			geo_hierarchy.parent_id
+		FROM
+			geo AS child,
+			geo_hierarchy
+		WHERE child.valid
+		AND child.code = %[2]s
+		AND geo_hierarchy.child_id = child.id
+	)
+)
+	-- geotype conditions:
+%[3]s
+ORDER BY
+	geo.type_id DESC,
+	geo.code
+LIMIT %[4]s
+`
+	query := fmt.Sprintf(
+		template,
+		searchCols,
+		code,
+		geotypeConditions,
+		sqlArgs.Add(limit),
+	)
+	return query, sqlArgs.Values(), nil
+}
+
+// searchLimit checks limit, returning the default if it is 0.
+func searchLimit(limit int) (int, error) {
+	if limit == 0 {
+		return defaultSearchLimit, nil
+	}
+	if limit < 1 || limit > maxSearchLimit {
+		return 0, fmt.Errorf("%w: limit must be 1..%d: %d", sentinel.ErrInvalidParams, maxSearchLimit, limit)
+	}
+	return limit, nil
+}
+
+// searchCols are the columns selected for each SearchResult, apart from score.
+const searchCols = `geo.code,
+	geo.name,
+	geo.welsh_name,
+	geo_type.name,
+	ST_XMin(geo.wkb_geometry),
+	ST_YMin(geo.wkb_geometry),
+	ST_XMax(geo.wkb_geometry),
+	ST_YMax(geo.wkb_geometry)`
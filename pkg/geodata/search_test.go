@@ -0,0 +1,123 @@
+package geodata
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+func TestSearchSQL(t *testing.T) {
+	var tests = map[string]struct {
+		q        string
+		geotypes []string
+		limit    int
+		wantSQL  []string
+		wantArgs []interface{}
+		wantErr  error
+	}{
+		"empty": {
+			q:       "  ",
+			wantErr: sentinel.ErrMissingParams,
+		},
+		"limit too large": {
+			q:       "Newcastle",
+			limit:   maxSearchLimit + 1,
+			wantErr: sentinel.ErrInvalidParams,
+		},
+		"bad geotype": {
+			q:        "Newcastle",
+			geotypes: []string{"nope"},
+			wantErr:  sentinel.ErrInvalidParams,
+		},
+		"default limit": {
+			q: " Middlesborough ",
+			wantSQL: []string{
+				"word_similarity($1, geo.name)",
+				"word_similarity($1, COALESCE(geo.welsh_name, ''))",
+				"$1 <% geo.name OR $1 <% geo.welsh_name",
+				"LIMIT $2",
+			},
+			wantArgs: []interface{}{"Middlesborough", defaultSearchLimit},
+		},
+		"geotypes": {
+			q:        "Newcastle",
+			geotypes: []string{"lad,msoa"},
+			limit:    5,
+			wantSQL: []string{
+				"geo_type.name = ANY( $2 )",
+				"LIMIT $3",
+			},
+			wantArgs: []interface{}{"Newcastle", []string{"LAD", "MSOA"}, 5},
+		},
+	}
+
+	for desc, test := range tests {
+		sql, values, err := SearchSQL(test.q, test.geotypes, test.limit)
+		checkSQL(t, desc, sql, values, err, test.wantSQL, test.wantArgs, test.wantErr)
+	}
+}
+
+func TestSearchCodeSQL(t *testing.T) {
+	var tests = map[string]struct {
+		geotypes []string
+		limit    int
+		wantSQL  []string
+		wantArgs []interface{}
+		wantErr  error
+	}{
+		"limit too large": {
+			limit:   maxSearchLimit + 1,
+			wantErr: sentinel.ErrInvalidParams,
+		},
+		"bad geotype": {
+			geotypes: []string{"nope"},
+			wantErr:  sentinel.ErrInvalidParams,
+		},
+		"default limit": {
+			wantSQL: []string{
+				"geo.code = $1 OR geo.id IN (",
+				"child.code = $1",
+				"LIMIT $2",
+			},
+			wantArgs: []interface{}{"E02000001", defaultSearchLimit},
+		},
+		"geotypes": {
+			geotypes: []string{"lad"},
+			limit:    1,
+			wantSQL: []string{
+				"geo_type.name = ANY( $2 )",
+				"LIMIT $3",
+			},
+			wantArgs: []interface{}{"E02000001", []string{"LAD"}, 1},
+		},
+	}
+
+	for desc, test := range tests {
+		sql, values, err := SearchCodeSQL("E02000001", test.geotypes, test.limit)
+		checkSQL(t, desc, sql, values, err, test.wantSQL, test.wantArgs, test.wantErr)
+	}
+}
+
+// checkSQL checks the results of SearchSQL or SearchCodeSQL.
+func checkSQL(t *testing.T, desc, sql string, values []interface{}, err error, wantSQL []string, wantArgs []interface{}, wantErr error) {
+	t.Helper()
+	if !errors.Is(err, wantErr) {
+		t.Errorf("%s: got error %v, want %v", desc, err, wantErr)
+		return
+	}
+	if err != nil {
+		return
+	}
+	flat := strings.Join(strings.Fields(sql), " ")
+	for _, want := range wantSQL {
+		if !strings.Contains(flat, want) {
+			t.Errorf("%s: SQL does not contain %q:\n%s", desc, want, flat)
+		}
+	}
+	if !reflect.DeepEqual(values, wantArgs) {
+		t.Errorf("%s: got args %#v, want %#v", desc, values, wantArgs)
+	}
+}
@@ -0,0 +1,112 @@
+package geodata
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-geodata-api/pkg/where"
+)
+
+// hostile is user input that would break out of a quoted SQL literal.
+const hostile = `X'); DROP TABLE geo; --`
+
+// assertNoInput fails if hostile made it into the SQL text, or if it didn't make it
+// into the arguments.
+func assertNoInput(t *testing.T, desc, sql string, values []interface{}) {
+	t.Helper()
+	if strings.Contains(sql, "DROP TABLE") {
+		t.Errorf("%s: user input in SQL text:\n%s", desc, sql)
+	}
+	if !strings.Contains(fmt.Sprint(values...), hostile) {
+		t.Errorf("%s: user input not in args: %#v", desc, values)
+	}
+}
+
+func TestSQLHasNoUserInput(t *testing.T) {
+	ctx := context.Background()
+
+	var tests = map[string]CensusQuerySQLArgs{
+		"rows":        {Year: 2011, Geos: []string{hostile}},
+		"row range":   {Year: 2011, Geos: []string{"E01000001..." + hostile}},
+		"cols":        {Year: 2011, Geos: []string{"ALL"}, Cols: []string{hostile}},
+		"col range":   {Year: 2011, Geos: []string{"ALL"}, Cols: []string{"QS101EW0001..." + hostile}},
+		"censustable": {Year: 2011, Geos: []string{"ALL"}, Censustable: hostile},
+		"divideby":    {Year: 2011, Geos: []string{"ALL"}, DivideBy: hostile},
+	}
+
+	for desc, args := range tests {
+		sql, values, _, err := CensusQuerySQL(ctx, args)
+		if err != nil {
+			t.Errorf("%s: %s", desc, err)
+			continue
+		}
+		assertNoInput(t, "CensusQuerySQL "+desc, sql, values)
+
+		if len(args.Cols) > 0 || args.Censustable != "" || args.DivideBy != "" {
+			sql, values, err = CategoryCodesSQL(args)
+			if err != nil {
+				t.Errorf("%s: %s", desc, err)
+				continue
+			}
+			assertNoInput(t, "CategoryCodesSQL "+desc, sql, values)
+		}
+	}
+
+	sql, values, err := geocodesSQL(2011, "", "", 0, "", "", 0, "", nil, []string{hostile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertNoInput(t, "geocodesSQL", sql, values)
+
+	sql, values, err = geocodesSQL(2011, "", "", 0, "", "", 0, hostile, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertNoInput(t, "geocodesSQL adjacent", sql, values)
+
+	params := &CkmeansParams{year: 2011, geotypes: []string{"LAD"}}
+	if err := params.selectArea(AreaArgs{Geos: []string{hostile}}); err != nil {
+		t.Fatal(err)
+	}
+	sql, values = params.metricsQuery("LAD", "QS101EW0001")
+	assertNoInput(t, "ckmeans area", sql, values)
+	if !strings.Contains(sql, fmt.Sprintf("$%d", len(values))) || strings.Contains(sql, fmt.Sprintf("$%d", len(values)+1)) {
+		t.Errorf("ckmeans area: placeholders don't match %d values:\n%s", len(values), sql)
+	}
+
+	app := &Geodata{}
+	catset := where.NewValueSet()
+	catset.AddSingle(hostile)
+	sql, values, _, err = app.metricsSQL(ctx, 2011, []string{hostile}, catset, nil, hostile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertNoInput(t, "metricsSQL", sql, values)
+}
+
+// Coordinates are parsed as numbers before they get near the SQL, but they still
+// go in as arguments so the query text is the same for every bbox, radius and polygon.
+func TestSQLCoordsAreArgs(t *testing.T) {
+	args := CensusQuerySQLArgs{
+		Year:     2011,
+		BBox:     "-0.37,51.36,0.17,51.67",
+		Location: "-0.1,51.5",
+		Radius:   1000,
+		Polygon:  "-0.2,51.4,-0.1,51.4,-0.1,51.5,-0.2,51.4",
+	}
+	sql, values, _, err := CensusQuerySQL(context.Background(), args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, literal := range []string{"51.36", "1000", "LINESTRING"} {
+		if strings.Contains(sql, literal) {
+			t.Errorf("%s in SQL text:\n%s", literal, sql)
+		}
+	}
+	// 4 bbox coords, 2 location coords, radius, polygon, year
+	if len(values) != 9 {
+		t.Errorf("%d args, want 9: %#v", len(values), values)
+	}
+}
@@ -0,0 +1,154 @@
+package geodata
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ONSdigital/dp-geodata-api/pkg/classify"
+	"github.com/ONSdigital/dp-geodata-api/pkg/tracing"
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+// defaultPercentiles are returned by Stats when no percentiles are asked for.
+var defaultPercentiles = []float64{10, 25, 75, 90}
+
+const (
+	defaultBins = 10  // histogram bins returned by Stats if not given
+	maxBins     = 100 // most histogram bins Stats returns
+)
+
+type StatsArgs struct {
+	Year        int
+	Cats        []string
+	Geotypes    []string
+	DivideBy    string
+	Percentiles []float64 // defaultPercentiles if empty
+	Bins        int       // defaultBins if 0
+}
+
+// Stats returns descriptive statistics of the metrics in each geotype-catcode
+// combination, keyed by catcode then geotype.
+//
+// As with CKmeans, when DivideBy is not empty the statistics are of the ratio of each
+// category to DivideBy.
+// The weighted mean weights each area by the totals category of the category's table,
+// eg QS101EW0001 for QS101EW0002, and is left out if any area has no total.
+// Combinations with no metrics are left out.
+func (app *Geodata) Stats(ctx context.Context, args StatsArgs) (map[string]map[string]classify.Description, error) {
+	ctx, span := tracing.Start(ctx, "Geodata.Stats")
+	defer span.End()
+
+	percentiles := args.Percentiles
+	if len(percentiles) == 0 {
+		percentiles = defaultPercentiles
+	}
+	bins := args.Bins
+	if bins == 0 {
+		bins = defaultBins
+	}
+	if bins < 1 || bins > maxBins {
+		return nil, fmt.Errorf("%w: bins must be 1..%d: %d", sentinel.ErrInvalidParams, maxBins, bins)
+	}
+
+	params, err := app.newCkmeansParams(args.Year, args.Cats, args.Geotypes, 0, args.DivideBy, "")
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]map[string]classify.Description{}
+	values := []float64{}
+	weights := []float64{}
+	geocodes := []string{}
+	metrics := map[string]float64{}
+	denominator := map[string]float64{}
+	for _, geotype := range params.geotypes {
+		if params.divideBy != "" {
+			if err := params.loadMetrics(ctx, geotype, params.divideBy, denominator); err != nil {
+				return nil, err
+			}
+			if len(denominator) == 0 {
+				continue
+			}
+		}
+
+		totals := map[string]map[string]float64{} // by totals category
+		for _, catcode := range params.catcodes {
+			if err := params.loadMetrics(ctx, geotype, catcode, metrics); err != nil {
+				return nil, err
+			}
+			if len(metrics) == 0 {
+				continue
+			}
+
+			values = values[:0] // reuse existing slices
+			geocodes = geocodes[:0]
+			if params.divideBy == "" {
+				for geocode, value := range metrics {
+					values = append(values, value)
+					geocodes = append(geocodes, geocode)
+				}
+			} else {
+				values, geocodes, err = ratios(values, geocodes, metrics, denominator)
+				if err != nil {
+					return nil, fmt.Errorf("%s %s: %w", geotype, catcode, err)
+				}
+			}
+
+			totalsCode := totalsCategory(catcode)
+			population, ok := totals[totalsCode]
+			if !ok {
+				population = map[string]float64{}
+				if err := params.loadMetrics(ctx, geotype, totalsCode, population); err != nil {
+					return nil, err
+				}
+				totals[totalsCode] = population
+			}
+			weights = populationWeights(weights[:0], geocodes, population)
+
+			desc, err := classify.Describe(values, weights, percentiles, bins)
+			if err != nil {
+				return nil, err
+			}
+			if result[catcode] == nil {
+				result[catcode] = map[string]classify.Description{}
+			}
+			result[catcode][geotype] = desc
+		}
+	}
+	return result, nil
+}
+
+// StatsCost estimates the number of metrics Stats will load, for rate limiting.
+func (app *Geodata) StatsCost(ctx context.Context, args StatsArgs) (int, error) {
+	ctx, span := tracing.Start(ctx, "Geodata.StatsCost")
+	defer span.End()
+
+	params, err := app.newCkmeansParams(args.Year, args.Cats, args.Geotypes, 0, args.DivideBy, "")
+	if err != nil {
+		return 0, err
+	}
+	return app.loadCost(ctx, params, AreaArgs{}, func(string) int {
+		cats := len(params.catcodes)
+		if params.divideBy != "" {
+			cats++
+		}
+		totals := map[string]bool{}
+		for _, catcode := range params.catcodes {
+			totals[totalsCategory(catcode)] = true
+		}
+		return cats + len(totals)
+	})
+}
+
+// populationWeights appends the population of each geocode to weights.
+// It returns nil if any geocode has no population.
+func populationWeights(weights []float64, geocodes []string, population map[string]float64) []float64 {
+	for _, geocode := range geocodes {
+		p, ok := population[geocode]
+		if !ok {
+			return nil
+		}
+		weights = append(weights, p)
+	}
+	return weights
+}
@@ -0,0 +1,116 @@
+//go:build comptest
+// +build comptest
+
+package geodata
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"testing"
+
+	"github.com/ONSdigital/dp-geodata-api/comptests"
+	"github.com/ONSdigital/dp-geodata-api/pkg/database"
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+func TestStats(t *testing.T) {
+	// GIVEN the database is setup
+	dsn := comptests.DefaultDSN
+	db, err := database.Open("pgx", dsn)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	func() {
+		db.DB().Exec("BEGIN")
+		defer db.DB().Exec("ROLLBACK")
+
+		// AND GIVEN a category and its table's totals
+		metrics := map[string]map[string][]float64{
+			"LAD": {
+				"QS101EW0001": {10, 10, 20, 20},
+				"QS101EW0002": {1, 3, 4, 8},
+			},
+		}
+		ckmeansTestSetup(t, db, metrics)
+
+		app, err := New(db, nil, 100)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// WHEN we ask for stats of the metrics
+		result, err := app.Stats(
+			context.Background(),
+			StatsArgs{
+				Year:        2011,
+				Cats:        []string{"QS101EW0002"},
+				Geotypes:    []string{"LAD"},
+				Percentiles: []float64{50},
+				Bins:        2,
+			},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// THEN the weighted mean is weighted by the totals
+		got, ok := result["QS101EW0002"]["LAD"]
+		if !ok {
+			t.Fatalf("no QS101EW0002 LAD stats in %v", result)
+		}
+		if got.Count != 4 || got.Mean != 4 || got.Median != 3.5 || got.Percentiles["50"] != 3.5 {
+			t.Errorf("got %+v", got)
+		}
+		if got.WeightedMean == nil || math.Abs(*got.WeightedMean-280.0/60) > 1e-9 {
+			t.Errorf("weighted mean: got %v, want %g", got.WeightedMean, 280.0/60)
+		}
+		if len(got.Histogram.Counts) != 2 || got.Histogram.Counts[0] != 3 || got.Histogram.Counts[1] != 1 {
+			t.Errorf("histogram: got %+v", got.Histogram)
+		}
+
+		// AND WHEN we ask for stats of ratios
+		result, err = app.Stats(
+			context.Background(),
+			StatsArgs{
+				Year:     2011,
+				Cats:     []string{"QS101EW0002"},
+				Geotypes: []string{"LAD"},
+				DivideBy: "QS101EW0001",
+			},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// THEN the weighted mean is the ratio of the sums
+		got = result["QS101EW0002"]["LAD"]
+		if math.Abs(got.Mean-0.25) > 1e-9 || math.Abs(got.Median-0.25) > 1e-9 {
+			t.Errorf("got %+v", got)
+		}
+		if got.WeightedMean == nil || math.Abs(*got.WeightedMean-16.0/60) > 1e-9 {
+			t.Errorf("weighted mean: got %v, want %g", got.WeightedMean, 16.0/60)
+		}
+		if len(got.Percentiles) != len(defaultPercentiles) || len(got.Histogram.Counts) != defaultBins {
+			t.Errorf("defaults: got %+v", got)
+		}
+
+		// AND WHEN there are too many bins
+		_, err = app.Stats(
+			context.Background(),
+			StatsArgs{
+				Year:     2011,
+				Cats:     []string{"QS101EW0002"},
+				Geotypes: []string{"LAD"},
+				Bins:     maxBins + 1,
+			},
+		)
+
+		// THEN we get an invalid params error
+		if !errors.Is(err, sentinel.ErrInvalidParams) {
+			t.Errorf("too many bins: got %v, want %v", err, sentinel.ErrInvalidParams)
+		}
+	}()
+}
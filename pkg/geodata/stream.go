@@ -0,0 +1,164 @@
+package geodata
+
+import (
+	"context"
+	"io"
+
+	"github.com/ONSdigital/dp-geodata-api/pkg/table"
+	"github.com/ONSdigital/dp-geodata-api/pkg/timer"
+	"github.com/ONSdigital/dp-geodata-api/pkg/tracing"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// StreamQuery runs the same query as Query, but writes CSV to w one geography at a time
+// instead of building the whole table in memory first.
+//
+// Nothing is written to w until the first geography is complete, so errors in the
+// query itself are returned before any output.
+// An error returned after that leaves w holding a partial table.
+//
+// Since the table is never held in memory, StreamQuery is not subject to the
+// MaxMetrics limit; callers should limit it by estimated Cost instead.
+//
+func (app *Geodata) StreamQuery(ctx context.Context, w io.Writer, year int, bbox, location string, radius int, polygon string, geotypes, rows, cols []string, censustable, divideby, language string) error {
+	ctx, span := tracing.Start(ctx, "Geodata.StreamQuery")
+	defer span.End()
+
+	return app.stream(ctx, w, CensusQuerySQLArgs{
+		Year:        year,
+		Geos:        rows,
+		BBox:        bbox,
+		Location:    location,
+		Radius:      radius,
+		Polygon:     polygon,
+		Geotypes:    geotypes,
+		Cols:        cols,
+		Censustable: censustable,
+		DivideBy:    divideby,
+		Lang:        language,
+	})
+}
+
+// Export is like StreamQuery, but takes its arguments as CensusQuerySQLArgs.
+// It is meant for background jobs, where the size of the result doesn't tie up a request.
+func (app *Geodata) Export(ctx context.Context, w io.Writer, args CensusQuerySQLArgs) error {
+	ctx, span := tracing.Start(ctx, "Geodata.Export")
+	defer span.End()
+
+	return app.stream(ctx, w, args)
+}
+
+// stream writes the results of the query described by args to w as CSV.
+func (app *Geodata) stream(ctx context.Context, w io.Writer, args CensusQuerySQLArgs) error {
+	sql, values, include, err := CensusQuerySQL(ctx, args)
+	if err != nil {
+		return err
+	}
+	// rows must arrive grouped by geography, in the same order table.Generate uses
+	sql += `ORDER BY geo.code COLLATE "C"` + "\n"
+
+	catsql, catvalues, err := CategoryCodesSQL(args)
+	if err != nil {
+		return err
+	}
+
+	catcodes, err := app.categoryCodes(ctx, catsql, catvalues)
+	if err != nil {
+		return err
+	}
+
+	stream := table.NewStream(w, include, catcodes, args.DivideBy)
+
+	// rows are written as they arrive, so every name has to be known up front
+	if table.WantsName(include) {
+		namesql, namevalues, err := AreaNamesSQL(args)
+		if err != nil {
+			return err
+		}
+		names, err := app.queryNames(ctx, namesql, namevalues)
+		if err != nil {
+			return err
+		}
+		stream.SetNames(names)
+	}
+
+	log.Info(ctx, "sql", log.Data{"query": sql, "args": values})
+
+	return app.streamCells(ctx, sql, values, stream)
+}
+
+// categoryCodes runs sql, which must select a single column of category codes.
+func (app *Geodata) categoryCodes(ctx context.Context, sql string, values []interface{}) ([]string, error) {
+	t := timer.New("categories")
+	t.Start()
+	defer func() {
+		t.Stop()
+		t.Log(ctx)
+	}()
+
+	rows, err := app.db.DB().QueryContext(ctx, sql, values...)
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+	defer rows.Close()
+
+	var catcodes []string
+	for rows.Next() {
+		var catcode string
+		if err := rows.Scan(&catcode); err != nil {
+			return nil, err
+		}
+		catcodes = append(catcodes, catcode)
+	}
+	return catcodes, queryError(ctx, rows.Err())
+}
+
+// streamCells is like collectCells, but feeds each cell to stream as it arrives.
+// sql must be ordered by geography code.
+//
+func (app *Geodata) streamCells(ctx context.Context, sql string, values []interface{}, stream *table.Stream) error {
+	t := timer.New("query")
+	t.Start()
+	rows, err := app.db.DB().QueryContext(ctx, sql, values...)
+	if err != nil {
+		return queryError(ctx, err)
+	}
+	t.Stop()
+	t.Log(ctx)
+	defer rows.Close()
+
+	tnext := timer.New("next")
+	tscan := timer.New("scan")
+	for {
+		tnext.Start()
+		ok := rows.Next()
+		tnext.Stop()
+		if !ok {
+			break
+		}
+
+		var geo string
+		var geotype string
+		var cat string
+		var value float64
+
+		tscan.Start()
+		err := rows.Scan(&geo, &geotype, &cat, &value)
+		tscan.Stop()
+		if err != nil {
+			return err
+		}
+
+		if err := stream.SetCell(geo, geotype, cat, value); err != nil {
+			return err
+		}
+	}
+	tnext.Log(ctx)
+	tscan.Log(ctx)
+
+	if err := rows.Err(); err != nil {
+		return queryError(ctx, err)
+	}
+
+	return stream.Close()
+}
@@ -0,0 +1,20 @@
+package geodata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+// queryError turns an error from a query which ran past ctx's deadline into
+// sentinel.ErrQueryTimeout, so it can be told apart from other database errors.
+// The driver cancels the query in Postgres when ctx is done, so a query abandoned
+// by its client, or one which times out, does not keep running.
+func queryError(ctx context.Context, err error) error {
+	if err == nil || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	return fmt.Errorf("%w: %s", sentinel.ErrQueryTimeout, err)
+}
@@ -0,0 +1,31 @@
+package geodata
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+func TestQueryError(t *testing.T) {
+	expired, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	cancelled, cancel2 := context.WithCancel(context.Background())
+	cancel2()
+
+	dbErr := errors.New("timeout: context deadline exceeded")
+
+	if err := queryError(expired, nil); err != nil {
+		t.Errorf("nil error: got %v", err)
+	}
+	if err := queryError(expired, dbErr); !errors.Is(err, sentinel.ErrQueryTimeout) {
+		t.Errorf("past deadline: got %v, want ErrQueryTimeout", err)
+	}
+	if err := queryError(cancelled, dbErr); err != dbErr {
+		t.Errorf("cancelled: got %v, want the original error", err)
+	}
+	if err := queryError(context.Background(), dbErr); err != dbErr {
+		t.Errorf("no deadline: got %v, want the original error", err)
+	}
+}
@@ -0,0 +1,38 @@
+// The lang package names the languages responses can be given in.
+//
+// Area, table and category names are held in English, and in Welsh where a Welsh
+// name has been loaded.
+// A Welsh response falls back to the English name wherever there is no Welsh one.
+//
+package lang
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+const (
+	English = "en"
+	Welsh   = "cy"
+)
+
+// Parse maps a case-insensitive language code, as given in a lang= query string,
+// to English or Welsh.
+func Parse(s string) (string, error) {
+	for _, l := range []string{English, Welsh} {
+		if strings.EqualFold(s, l) {
+			return l, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q is not a supported language", sentinel.ErrInvalidParams, s)
+}
+
+// Name returns welsh if lang is Welsh and there is a Welsh name, otherwise english.
+func Name(lang, english, welsh string) string {
+	if lang == Welsh && welsh != "" {
+		return welsh
+	}
+	return english
+}
@@ -0,0 +1,53 @@
+package lang
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+func TestParse(t *testing.T) {
+	var tests = map[string]struct {
+		s       string
+		want    string
+		wantErr error
+	}{
+		"english":     {s: "en", want: English},
+		"welsh":       {s: "cy", want: Welsh},
+		"upper case":  {s: "CY", want: Welsh},
+		"unsupported": {s: "fr", wantErr: sentinel.ErrInvalidParams},
+		"region":      {s: "cy-GB", wantErr: sentinel.ErrInvalidParams},
+	}
+
+	for desc, test := range tests {
+		got, err := Parse(test.s)
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("%s: got error %v, want %v", desc, err, test.wantErr)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: got %q, want %q", desc, got, test.want)
+		}
+	}
+}
+
+func TestName(t *testing.T) {
+	var tests = map[string]struct {
+		lang  string
+		welsh string
+		want  string
+	}{
+		"english":          {lang: English, welsh: "Caerdydd", want: "Cardiff"},
+		"welsh":            {lang: Welsh, welsh: "Caerdydd", want: "Caerdydd"},
+		"no welsh name":    {lang: Welsh, want: "Cardiff"},
+		"default language": {welsh: "Caerdydd", want: "Cardiff"},
+	}
+
+	for desc, test := range tests {
+		got := Name(test.lang, "Cardiff", test.welsh)
+		if got != test.want {
+			t.Errorf("%s: got %q, want %q", desc, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Package metrics holds the service's Prometheus metrics.
+//
+// Requests are labelled with their endpoint and geotype by putting Labels in their
+// context, so that timers and row counts deep inside a request can be labelled
+// without passing labels around.
+//
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "geodata"
+
+// Registry holds every metric served by Handler.
+var Registry = prometheus.NewRegistry()
+
+var (
+	requestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time taken to respond to requests, by endpoint and status code.",
+			Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+		},
+		[]string{"endpoint", "code"},
+	)
+
+	timerDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "timer_duration_seconds",
+			Help:      "Time recorded by each pkg/timer timer, such as query, next, scan and generate.",
+			Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		},
+		[]string{"timer", "endpoint", "geotype"},
+	)
+
+	rowsScanned = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rows_scanned_total",
+			Help:      "Rows scanned from database query results.",
+		},
+		[]string{"endpoint", "geotype"},
+	)
+
+	cacheRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_requests_total",
+			Help:      "Response cache lookups, by result: hit or miss.",
+		},
+		[]string{"result"},
+	)
+
+	errorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "errors_total",
+			Help:      "Errors sent to clients, by sentinel error and status code.",
+		},
+		[]string{"error", "code"},
+	)
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requestDuration,
+		timerDuration,
+		rowsScanned,
+		cacheRequests,
+		errorsTotal,
+	)
+}
+
+// Handler serves the metrics in Registry.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// RegisterDB adds db's connection pool stats to Registry.
+// Registering the same database twice is not an error.
+func RegisterDB(name string, db *sql.DB) error {
+	err := Registry.Register(collectors.NewDBStatsCollector(db, name))
+	var already prometheus.AlreadyRegisteredError
+	if errors.As(err, &already) {
+		return nil
+	}
+	return err
+}
+
+// Labels describe the request a measurement belongs to.
+type Labels struct {
+	Endpoint string // first segment of the path, eg "query"
+	Geotype  string // geotypes asked for, eg "LAD", or empty
+}
+
+type labelsKey struct{}
+
+// WithLabels returns a copy of ctx carrying labels.
+func WithLabels(ctx context.Context, labels Labels) context.Context {
+	return context.WithValue(ctx, labelsKey{}, labels)
+}
+
+// LabelsFrom returns the Labels in ctx, which are empty if there are none.
+func LabelsFrom(ctx context.Context) Labels {
+	labels, _ := ctx.Value(labelsKey{}).(Labels)
+	return labels
+}
+
+// ObserveRequest records how long a request to endpoint took, and its status code.
+func ObserveRequest(endpoint string, code int, d time.Duration) {
+	requestDuration.WithLabelValues(endpoint, strconv.Itoa(code)).Observe(d.Seconds())
+}
+
+// ObserveTimer records the time accumulated by the named timer.
+func ObserveTimer(ctx context.Context, timer string, d time.Duration) {
+	labels := LabelsFrom(ctx)
+	timerDuration.WithLabelValues(timer, labels.Endpoint, labels.Geotype).Observe(d.Seconds())
+}
+
+// AddRows counts n rows scanned from a query result.
+func AddRows(ctx context.Context, n int) {
+	labels := LabelsFrom(ctx)
+	rowsScanned.WithLabelValues(labels.Endpoint, labels.Geotype).Add(float64(n))
+}
+
+// CacheHit counts a response found in the cache.
+func CacheHit() {
+	cacheRequests.WithLabelValues("hit").Inc()
+}
+
+// CacheMiss counts a response not found in the cache.
+func CacheMiss() {
+	cacheRequests.WithLabelValues("miss").Inc()
+}
+
+// CountError counts an error sent to a client with status code.
+// name is the sentinel error, eg "invalid parameter".
+func CountError(name string, code int) {
+	errorsTotal.WithLabelValues(name, strconv.Itoa(code)).Inc()
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type stubDriver struct{}
+
+func (stubDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("not a real driver")
+}
+
+func init() {
+	sql.Register("stub", stubDriver{})
+}
+
+func TestLabels(t *testing.T) {
+	ctx := WithLabels(context.Background(), Labels{Endpoint: "query", Geotype: "LAD"})
+
+	before := testutil.ToFloat64(rowsScanned.WithLabelValues("query", "LAD"))
+	AddRows(ctx, 10)
+	if got := testutil.ToFloat64(rowsScanned.WithLabelValues("query", "LAD")) - before; got != 10 {
+		t.Errorf("rows scanned: got %g, want 10", got)
+	}
+
+	if got := LabelsFrom(context.Background()); got != (Labels{}) {
+		t.Errorf("no labels: got %+v", got)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	ObserveTimer(WithLabels(context.Background(), Labels{Endpoint: "ckmeans"}), "query", time.Second)
+	CacheHit()
+	CountError("invalid parameter", http.StatusBadRequest)
+
+	// sql.Open does not connect, so the pool stats are all zero
+	db, err := sql.Open("stub", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := RegisterDB("test", db); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterDB("test", db); err != nil {
+		t.Errorf("registering twice: %s", err)
+	}
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`geodata_timer_duration_seconds_count{endpoint="ckmeans",geotype="",timer="query"}`,
+		`geodata_cache_requests_total{result="hit"}`,
+		`geodata_errors_total{code="400",error="invalid parameter"}`,
+		`go_sql_open_connections{db_name="test"}`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics do not contain %s", want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Package ratelimit limits how much work each client can ask for, using a token bucket
+// per client.
+//
+// Each bucket holds up to burst tokens, and refills at rate tokens per second.
+// A request costing n tokens is allowed if its client's bucket holds n tokens, and the
+// tokens are taken. Otherwise it is refused, and the client is told how long to wait.
+// Requests costing more than burst tokens are allowed only from a full bucket, which is
+// left in debt, so the client waits for the whole cost to be refilled before its next
+// request.
+//
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+// pruneInterval is how often full buckets are forgotten.
+const pruneInterval = time.Minute
+
+// Error is returned when a request is refused.
+// It wraps sentinel.ErrRateLimited.
+type Error struct {
+	RetryAfter time.Duration // how long until the request would be allowed
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: retry after %s", sentinel.ErrRateLimited, e.RetryAfter)
+}
+
+func (e *Error) Unwrap() error {
+	return sentinel.ErrRateLimited
+}
+
+// Limiter holds a token bucket for each client.
+type Limiter struct {
+	rate    float64
+	burst   float64
+	now     func() time.Time
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	pruned  time.Time
+}
+
+type bucket struct {
+	tokens float64
+	at     time.Time // when tokens was last updated
+}
+
+// New returns a Limiter refilling at rate tokens per second, holding up to burst tokens.
+func New(rate float64, burst int) *Limiter {
+	return &Limiter{
+		rate:    rate,
+		burst:   float64(burst),
+		now:     time.Now,
+		buckets: map[string]*bucket{},
+	}
+}
+
+// Take takes cost tokens from client's bucket.
+// If there are not enough, nothing is taken, and an *Error says how long to wait.
+// A cost larger than the bucket needs a full bucket, and leaves it below zero.
+func (l *Limiter) Take(client string, cost int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{tokens: l.burst, at: now}
+		l.buckets[client] = b
+	}
+	b.tokens = l.refill(b, now)
+	b.at = now
+
+	need := math.Min(float64(cost), l.burst)
+	if b.tokens < need {
+		wait := (need - b.tokens) / l.rate
+		return &Error{RetryAfter: time.Duration(wait * float64(time.Second))}
+	}
+	b.tokens -= float64(cost)
+	return nil
+}
+
+// refill returns the tokens in b at now.
+func (l *Limiter) refill(b *bucket, now time.Time) float64 {
+	tokens := b.tokens + now.Sub(b.at).Seconds()*l.rate
+	return math.Min(tokens, l.burst)
+}
+
+// prune forgets buckets which have refilled, since they are the same as new ones.
+func (l *Limiter) prune(now time.Time) {
+	if now.Sub(l.pruned) < pruneInterval {
+		return
+	}
+	l.pruned = now
+	for client, b := range l.buckets {
+		if l.refill(b, now) >= l.burst {
+			delete(l.buckets, client)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+func TestTake(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := New(10, 100)
+	l.now = func() time.Time { return now }
+
+	if err := l.Take("a", 60); err != nil {
+		t.Fatalf("first take: %s", err)
+	}
+
+	// 40 left, so 60 more needs 2s of refill
+	err := l.Take("a", 60)
+	var rerr *Error
+	if !errors.As(err, &rerr) {
+		t.Fatalf("second take: got %v, want *Error", err)
+	}
+	if !errors.Is(err, sentinel.ErrRateLimited) {
+		t.Errorf("error does not wrap ErrRateLimited")
+	}
+	if rerr.RetryAfter != 2*time.Second {
+		t.Errorf("RetryAfter %s, want 2s", rerr.RetryAfter)
+	}
+
+	// other clients have their own buckets
+	if err := l.Take("b", 100); err != nil {
+		t.Errorf("other client: %s", err)
+	}
+
+	now = now.Add(2 * time.Second)
+	if err := l.Take("a", 60); err != nil {
+		t.Errorf("after refill: %s", err)
+	}
+
+	// costs larger than the bucket wait for it to fill
+	now = now.Add(5 * time.Second)
+	err = l.Take("a", 1000)
+	if !errors.As(err, &rerr) {
+		t.Fatalf("large cost with part-full bucket: got %v, want *Error", err)
+	}
+	if rerr.RetryAfter != 5*time.Second {
+		t.Errorf("large cost: RetryAfter %s, want 5s", rerr.RetryAfter)
+	}
+
+	// and then leave it in debt until the whole cost is refilled
+	now = now.Add(5 * time.Second)
+	if err := l.Take("a", 1000); err != nil {
+		t.Fatalf("large cost with full bucket: %s", err)
+	}
+	err = l.Take("a", 10)
+	if !errors.As(err, &rerr) {
+		t.Fatalf("after large cost: got %v, want *Error", err)
+	}
+	if rerr.RetryAfter != 91*time.Second {
+		t.Errorf("after large cost: RetryAfter %s, want 1m31s", rerr.RetryAfter)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := New(1, 10)
+	l.now = func() time.Time { return now }
+
+	l.Take("a", 10)
+	now = now.Add(pruneInterval)
+	l.Take("b", 1)
+	if _, ok := l.buckets["a"]; ok {
+		t.Errorf("refilled bucket not pruned")
+	}
+	if _, ok := l.buckets["b"]; !ok {
+		t.Errorf("bucket in use was pruned")
+	}
+}
@@ -0,0 +1,138 @@
+package table
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+	geom "github.com/twpayne/go-geom"
+	"github.com/twpayne/go-geom/encoding/geojson"
+)
+
+// Format names one of the encodings a Table can be written in.
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatJSON    Format = "json"
+	FormatGeoJSON Format = "geojson"
+	FormatParquet Format = "parquet"
+)
+
+// ParseFormat maps a case-insensitive format name, as given in a format= query string,
+// to a Format.
+func ParseFormat(s string) (Format, error) {
+	for _, f := range []Format{FormatCSV, FormatJSON, FormatGeoJSON, FormatParquet} {
+		if strings.EqualFold(s, string(f)) {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q is not a supported format", sentinel.ErrInvalidParams, s)
+}
+
+// Write produces the table on w in the given format.
+//
+// boundaries is only used by FormatGeoJSON, where it supplies the geometry for each
+// geocode. Areas missing from boundaries get a null geometry.
+//
+func (tbl *Table) Write(w io.Writer, format Format, include []string, boundaries map[string]geom.T) error {
+	switch format {
+	case FormatCSV:
+		return tbl.Generate(w, include)
+	case FormatJSON:
+		return tbl.GenerateJSON(w, include)
+	case FormatGeoJSON:
+		return tbl.GenerateGeoJSON(w, include, boundaries)
+	case FormatParquet:
+		return tbl.GenerateParquet(w, include)
+	}
+	return fmt.Errorf("%w: %q is not a supported format", sentinel.ErrInvalidParams, format)
+}
+
+// GenerateJSON produces a JSON array on w, with one object per row of the table.
+// Each object has the same keys as the CSV column headings generated by Generate,
+// in the same order.
+//
+func (tbl *Table) GenerateJSON(w io.Writer, include []string) error {
+	geocodes := tbl.Geocodes()
+	catcodes := tbl.sortedCatcodes()
+	includeGeocode, includeName, includeGeotype := wantCols(include)
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("[")
+	for i, geocode := range geocodes {
+		if i > 0 {
+			bw.WriteString(",")
+		}
+
+		a := tbl.areas[Geocode(geocode)]
+		var fields []string
+		if includeGeocode {
+			fields = append(fields, jsonField(ColGeographyCode, quoteJSON(geocode)))
+		}
+		if includeName {
+			fields = append(fields, jsonField(ColGeographyName, quoteJSON(tbl.names[geocode])))
+		}
+		if includeGeotype {
+			fields = append(fields, jsonField(ColGeotype, quoteJSON(string(a.geotype))))
+		}
+		for _, catcode := range catcodes {
+			fields = append(fields, jsonField(catcode, FormatValue(a.metrics[Catcode(catcode)])))
+		}
+		bw.WriteString("\n{" + strings.Join(fields, ",") + "}")
+	}
+	bw.WriteString("\n]\n")
+	return bw.Flush()
+}
+
+// GenerateGeoJSON produces a GeoJSON FeatureCollection on w, with one Feature per row
+// of the table.
+// The Feature id is the geography code, the geometry comes from boundaries, and the
+// properties hold the same columns as Generate.
+//
+func (tbl *Table) GenerateGeoJSON(w io.Writer, include []string, boundaries map[string]geom.T) error {
+	catcodes := tbl.sortedCatcodes()
+	includeGeocode, includeName, includeGeotype := wantCols(include)
+
+	fc := &geojson.FeatureCollection{}
+	for _, geocode := range tbl.Geocodes() {
+		a := tbl.areas[Geocode(geocode)]
+		props := map[string]interface{}{}
+		if includeGeocode {
+			props[ColGeographyCode] = geocode
+		}
+		if includeName {
+			props[ColGeographyName] = tbl.names[geocode]
+		}
+		if includeGeotype {
+			props[ColGeotype] = string(a.geotype)
+		}
+		for _, catcode := range catcodes {
+			props[catcode] = json.Number(FormatValue(a.metrics[Catcode(catcode)]))
+		}
+		fc.Features = append(fc.Features, &geojson.Feature{
+			ID:         geocode,
+			Geometry:   boundaries[geocode],
+			Properties: props,
+		})
+	}
+
+	buf, err := json.Marshal(fc)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(buf, '\n'))
+	return err
+}
+
+func jsonField(key, value string) string {
+	return quoteJSON(key) + ":" + value
+}
+
+func quoteJSON(s string) string {
+	b, _ := json.Marshal(s) // cannot fail on a string
+	return string(b)
+}
@@ -0,0 +1,174 @@
+package table_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-geodata-api/pkg/table"
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+	geom "github.com/twpayne/go-geom"
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+var formatInput = []row{
+	{"geo2", "type", "cat1", 7.8},
+	{"geo1", "type", "cat2", 0.1},
+	{"geo1", "type", "cat1", 45.6},
+	{"geo2", "type", "cat2", 1000000},
+}
+
+func newFormatTable() *table.Table {
+	tbl := table.New()
+	for _, r := range formatInput {
+		tbl.SetCell(r.geo, r.geotype, r.cat, r.val)
+	}
+	return tbl
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, s := range []string{"csv", "JSON", "GeoJSON", "parquet"} {
+		if _, err := table.ParseFormat(s); err != nil {
+			t.Errorf("%s: %s", s, err)
+		}
+	}
+	if _, err := table.ParseFormat("xlsx"); !errors.Is(err, sentinel.ErrInvalidParams) {
+		t.Errorf("xlsx: got %v, want %s", err, sentinel.ErrInvalidParams)
+	}
+}
+
+func TestGenerateJSON(t *testing.T) {
+	var tests = []struct {
+		desc    string
+		input   []row
+		include []string
+		names   map[string]string
+		want    string
+	}{
+		{
+			desc: "no input",
+			want: "[\n]\n",
+		},
+		{
+			desc:    "geocode, geotype and categories",
+			input:   formatInput,
+			include: []string{table.ColGeographyCode, table.ColGeotype},
+			want: `[
+{"geography_code":"geo1","geotype":"type","cat1":45.6,"cat2":0.1},
+{"geography_code":"geo2","geotype":"type","cat1":7.8,"cat2":1000000}
+]
+`,
+		},
+		{
+			desc:    "geography names",
+			input:   formatInput,
+			include: []string{table.ColGeographyCode, table.ColGeographyName},
+			names:   map[string]string{"geo1": "Caerdydd", "geo2": "Abertawe"},
+			want: `[
+{"geography_code":"geo1","geography_name":"Caerdydd","cat1":45.6,"cat2":0.1},
+{"geography_code":"geo2","geography_name":"Abertawe","cat1":7.8,"cat2":1000000}
+]
+`,
+		},
+		{
+			desc:  "categories only",
+			input: formatInput[:1],
+			want:  "[\n{\"cat1\":7.8}\n]\n",
+		},
+	}
+
+	for _, test := range tests {
+		tbl := table.New()
+		for _, r := range test.input {
+			tbl.SetCell(r.geo, r.geotype, r.cat, r.val)
+		}
+		tbl.SetNames(test.names)
+		var buf strings.Builder
+		if err := tbl.GenerateJSON(&buf, test.include); err != nil {
+			t.Errorf("%s: %s", test.desc, err)
+			continue
+		}
+		if buf.String() != test.want {
+			t.Errorf("%s:\n%s\nwant:\n%s\n", test.desc, buf.String(), test.want)
+		}
+		if !json.Valid([]byte(buf.String())) {
+			t.Errorf("%s: invalid JSON", test.desc)
+		}
+	}
+}
+
+func TestGenerateGeoJSON(t *testing.T) {
+	tbl := newFormatTable()
+	boundaries := map[string]geom.T{
+		"geo1": geom.NewPoint(geom.XY).MustSetCoords(geom.Coord{-1, 51}),
+	}
+
+	var buf bytes.Buffer
+	if err := tbl.GenerateGeoJSON(&buf, []string{table.ColGeotype}, boundaries); err != nil {
+		t.Fatal(err)
+	}
+
+	var got struct {
+		Type     string `json:"type"`
+		Features []struct {
+			ID         string                 `json:"id"`
+			Geometry   map[string]interface{} `json:"geometry"`
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Type != "FeatureCollection" {
+		t.Errorf("type %q, want FeatureCollection", got.Type)
+	}
+	if len(got.Features) != 2 {
+		t.Fatalf("%d features, want 2", len(got.Features))
+	}
+	f := got.Features[0]
+	if f.ID != "geo1" || f.Geometry["type"] != "Point" {
+		t.Errorf("first feature: %+v", f)
+	}
+	if f.Properties["geotype"] != "type" || f.Properties["cat1"] != 45.6 {
+		t.Errorf("first feature properties: %+v", f.Properties)
+	}
+	if _, ok := f.Properties[table.ColGeographyCode]; ok {
+		t.Errorf("geography_code not asked for, but in properties")
+	}
+	if got.Features[1].Geometry != nil {
+		t.Errorf("feature without boundary should have null geometry: %+v", got.Features[1].Geometry)
+	}
+}
+
+func TestGenerateParquet(t *testing.T) {
+	tbl := newFormatTable()
+
+	var buf bytes.Buffer
+	if err := tbl.GenerateParquet(&buf, []string{table.ColGeographyCode}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("PAR1")) {
+		t.Fatalf("output is not a parquet file")
+	}
+
+	pf, err := buffer.NewBufferFile(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr, err := reader.NewParquetColumnReader(pf, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr.ReadStop()
+
+	if n := pr.GetNumRows(); n != 2 {
+		t.Errorf("%d rows, want 2", n)
+	}
+	if n := len(pr.SchemaHandler.ValueColumns); n != 3 {
+		t.Errorf("%d columns, want 3", n)
+	}
+}
@@ -0,0 +1,65 @@
+package table
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/xitongsys/parquet-go-source/writerfile"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// GenerateParquet produces a Parquet file on w.
+// It has the same columns as Generate: geography_code, geography_name and geotype are UTF8 strings
+// and each category is a DOUBLE.
+//
+func (tbl *Table) GenerateParquet(w io.Writer, include []string) error {
+	catcodes := tbl.sortedCatcodes()
+	includeGeocode, includeName, includeGeotype := wantCols(include)
+
+	// build the schema, one column per line of metadata
+	var schema []string
+	if includeGeocode {
+		schema = append(schema, fmt.Sprintf("name=%s, type=BYTE_ARRAY, convertedtype=UTF8", ColGeographyCode))
+	}
+	if includeName {
+		schema = append(schema, fmt.Sprintf("name=%s, type=BYTE_ARRAY, convertedtype=UTF8", ColGeographyName))
+	}
+	if includeGeotype {
+		schema = append(schema, fmt.Sprintf("name=%s, type=BYTE_ARRAY, convertedtype=UTF8", ColGeotype))
+	}
+	for _, catcode := range catcodes {
+		schema = append(schema, fmt.Sprintf("name=%s, type=DOUBLE", catcode))
+	}
+
+	pw, err := writer.NewCSVWriter(schema, writerfile.NewWriterFile(w), 1)
+	if err != nil {
+		return err
+	}
+	pw.CompressionType = parquet.CompressionCodec_SNAPPY
+
+	// pre-allocate slice to hold column values
+	row := make([]interface{}, 0, len(schema))
+
+	for _, geocode := range tbl.Geocodes() {
+		a := tbl.areas[Geocode(geocode)]
+		row = row[:0]
+		if includeGeocode {
+			row = append(row, geocode)
+		}
+		if includeName {
+			row = append(row, tbl.names[geocode])
+		}
+		if includeGeotype {
+			row = append(row, string(a.geotype))
+		}
+		for _, catcode := range catcodes {
+			row = append(row, a.metrics[Catcode(catcode)])
+		}
+		if err := pw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	return pw.WriteStop()
+}
@@ -0,0 +1,168 @@
+package table
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+// A Stream writes a CSV table one row at a time, without holding the whole table in memory.
+//
+// Cells must be fed to SetCell ordered by geocode (byte order, as with sort.Strings).
+// Each row is written to w as soon as a cell for the next geocode arrives,
+// so the caller needs to know the full set of category codes up front.
+//
+// The output is the same as Generate would produce for a Table holding the same cells.
+//
+type Stream struct {
+	w              io.Writer
+	cw             *csv.Writer
+	catcodes       []string // output columns, sorted, without divideby
+	divideby       Catcode
+	includeGeocode bool
+	includeName    bool
+	includeGeotype bool
+	names          map[string]string // geography_name for each geocode
+	started        bool              // true once column headings have been written
+	geocode        Geocode
+	area           area // the row being collected
+	row            []string
+}
+
+// NewStream sets up a Stream writing to w.
+// catcodes are the category columns that will appear in the output.
+// If divideby is not empty, it must be one of catcodes; each value on a row is divided
+// by the divideby value on that row, and the divideby column is dropped.
+// If w has a Flush() error method, such as an HTTP response writer, it is flushed after
+// each row, so the reader gets each geography as soon as it is complete.
+//
+func NewStream(w io.Writer, include, catcodes []string, divideby string) *Stream {
+	includeGeocode, includeName, includeGeotype := wantCols(include)
+
+	var cols []string
+	for _, catcode := range catcodes {
+		if catcode != divideby {
+			cols = append(cols, catcode)
+		}
+	}
+	sort.Strings(cols)
+
+	return &Stream{
+		w:              w,
+		cw:             csv.NewWriter(w),
+		catcodes:       cols,
+		divideby:       Catcode(divideby),
+		includeGeocode: includeGeocode,
+		includeName:    includeName,
+		includeGeotype: includeGeotype,
+		row:            make([]string, 0, len(cols)+3),
+	}
+}
+
+// SetNames supplies the geography_name for each geocode.
+// Unlike cells, names must all be known before the first row is written.
+func (s *Stream) SetNames(names map[string]string) {
+	s.names = names
+}
+
+// SetCell sets the value of a cell on the current row.
+// A new geocode finishes the current row and writes it out.
+func (s *Stream) SetCell(geocode, geotype, catcode string, value float64) error {
+	if Geocode(geocode) != s.geocode {
+		if s.area.metrics != nil {
+			if geocode < string(s.geocode) {
+				return fmt.Errorf("stream: geocode %s after %s: cells must be ordered by geocode", geocode, s.geocode)
+			}
+			if err := s.flushRow(); err != nil {
+				return err
+			}
+		}
+		s.geocode = Geocode(geocode)
+		s.area = area{
+			geotype: Geotype(geotype),
+			metrics: map[Catcode]float64{},
+		}
+	}
+	s.area.metrics[Catcode(catcode)] = value
+	return nil
+}
+
+// Close writes out the last row.
+// If no cells were seen, only the column headings are written.
+// It doesn't close the underlying writer.
+func (s *Stream) Close() error {
+	if s.area.metrics != nil {
+		if err := s.flushRow(); err != nil {
+			return err
+		}
+		s.area.metrics = nil
+	}
+	if !s.started {
+		s.writeHeadings()
+	}
+	s.cw.Flush()
+	return s.cw.Error()
+}
+
+// writeHeadings writes the column heading row.
+func (s *Stream) writeHeadings() {
+	colnames := []string{}
+	if s.includeGeocode {
+		colnames = append(colnames, ColGeographyCode)
+	}
+	if s.includeName {
+		colnames = append(colnames, ColGeographyName)
+	}
+	if s.includeGeotype {
+		colnames = append(colnames, ColGeotype)
+	}
+	colnames = append(colnames, s.catcodes...)
+	s.cw.Write(colnames)
+	s.started = true
+}
+
+// flushRow writes the current row and flushes it to the underlying writer.
+func (s *Stream) flushRow() error {
+	denom := 1.0
+	if s.divideby != "" {
+		denom = s.area.metrics[s.divideby]
+		if denom == 0 {
+			return fmt.Errorf("%w: %s %s is zero", sentinel.ErrInvalidParams, s.geocode, s.divideby)
+		}
+	}
+
+	if !s.started {
+		s.writeHeadings()
+	}
+
+	s.row = s.row[:0]
+	if s.includeGeocode {
+		s.row = append(s.row, string(s.geocode))
+	}
+	if s.includeName {
+		s.row = append(s.row, s.names[string(s.geocode)])
+	}
+	if s.includeGeotype {
+		s.row = append(s.row, string(s.area.geotype))
+	}
+	for _, catcode := range s.catcodes {
+		s.row = append(s.row, FormatValue(s.area.metrics[Catcode(catcode)]/denom))
+	}
+	s.cw.Write(s.row)
+	s.cw.Flush()
+	if err := s.cw.Error(); err != nil {
+		return err
+	}
+	if f, ok := s.w.(flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// A flusher is a writer which buffers, such as a compressed HTTP response.
+type flusher interface {
+	Flush() error
+}
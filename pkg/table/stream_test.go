@@ -0,0 +1,135 @@
+package table_test
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-geodata-api/pkg/table"
+	"github.com/ONSdigital/dp-geodata-api/sentinel"
+)
+
+// sortedInput is formatInput ordered by geocode, as the streaming query returns it.
+func sortedInput() []row {
+	input := append([]row(nil), formatInput...)
+	sort.SliceStable(input, func(i, j int) bool {
+		return input[i].geo < input[j].geo
+	})
+	return input
+}
+
+func TestStream(t *testing.T) {
+	var tests = []struct {
+		desc     string
+		input    []row
+		include  []string
+		names    map[string]string
+		divideby string
+	}{
+		{
+			desc:    "no input",
+			include: []string{table.ColGeographyCode},
+		},
+		{
+			desc:    "geocode and geotype",
+			input:   sortedInput(),
+			include: []string{table.ColGeographyCode, table.ColGeotype},
+		},
+		{
+			desc:    "geography names",
+			input:   sortedInput(),
+			include: []string{table.ColGeographyCode, table.ColGeographyName},
+			names:   map[string]string{"geo1": "Caerdydd"},
+		},
+		{
+			desc:     "divideby",
+			input:    sortedInput(),
+			include:  []string{table.ColGeographyCode},
+			divideby: "cat1",
+		},
+	}
+
+	for _, test := range tests {
+		// what the buffered table would produce
+		tbl := table.New()
+		for _, r := range test.input {
+			tbl.SetCell(r.geo, r.geotype, r.cat, r.val)
+		}
+		tbl.SetNames(test.names)
+		if test.divideby != "" {
+			if err := tbl.DivideBy(test.divideby); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var want strings.Builder
+		if err := tbl.Generate(&want, test.include); err != nil {
+			t.Fatal(err)
+		}
+
+		var got strings.Builder
+		s := table.NewStream(&got, test.include, []string{"cat2", "cat1"}, test.divideby)
+		s.SetNames(test.names)
+		for _, r := range test.input {
+			if err := s.SetCell(r.geo, r.geotype, r.cat, r.val); err != nil {
+				t.Fatalf("%s: %s", test.desc, err)
+			}
+		}
+		if err := s.Close(); err != nil {
+			t.Fatalf("%s: %s", test.desc, err)
+		}
+
+		if test.input == nil {
+			want.Reset()
+			want.WriteString("geography_code,cat1,cat2\n")
+		}
+		if got.String() != want.String() {
+			t.Errorf("%s:\n%s\nwant:\n%s\n", test.desc, got.String(), want.String())
+		}
+	}
+}
+
+// A flushRecorder records what had been written at each Flush.
+type flushRecorder struct {
+	strings.Builder
+	flushed []string
+}
+
+func (fr *flushRecorder) Flush() error {
+	fr.flushed = append(fr.flushed, fr.String())
+	return nil
+}
+
+func TestStreamFlushesEachRow(t *testing.T) {
+	var got flushRecorder
+	s := table.NewStream(&got, []string{table.ColGeographyCode}, []string{"cat1", "cat2"}, "")
+	for _, r := range sortedInput() {
+		if err := s.SetCell(r.geo, r.geotype, r.cat, r.val); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// geo1 is complete once geo2 cells arrive; geo2 is not written until Close
+	want := "geography_code,cat1,cat2\ngeo1,45.6,0.1\n"
+	if got.String() != want {
+		t.Errorf("before Close:\n%s\nwant:\n%s\n", got.String(), want)
+	}
+	if len(got.flushed) != 1 || got.flushed[0] != want {
+		t.Errorf("flushed %q, want %q", got.flushed, []string{want})
+	}
+}
+
+func TestStreamErrors(t *testing.T) {
+	var sb strings.Builder
+	s := table.NewStream(&sb, nil, []string{"cat1"}, "")
+	s.SetCell("geo2", "type", "cat1", 1)
+	if err := s.SetCell("geo1", "type", "cat1", 1); err == nil {
+		t.Errorf("out of order geocodes: expected error")
+	}
+
+	s = table.NewStream(&sb, nil, []string{"cat1", "cat2"}, "cat2")
+	s.SetCell("geo1", "type", "cat1", 1)
+	if err := s.Close(); !errors.Is(err, sentinel.ErrInvalidParams) {
+		t.Errorf("zero divideby: got %v, want %s", err, sentinel.ErrInvalidParams)
+	}
+}
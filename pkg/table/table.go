@@ -28,6 +28,7 @@ import (
 
 const (
 	ColGeographyCode = "geography_code"
+	ColGeographyName = "geography_name"
 	ColGeotype       = "geotype"
 	ColGeocodes      = "geocode" // XXX temporary
 )
@@ -37,9 +38,10 @@ type Geotype string // eg "LSOA", "LAD", ...
 type Catcode string // eg "QS412EW0001"
 
 type Table struct {
-	geocodes map[Geocode]bool // geography codes seen
-	catcodes map[Catcode]bool // category codes seen
-	areas    map[Geocode]area // areas indexed by geography code
+	geocodes map[Geocode]bool  // geography codes seen
+	catcodes map[Catcode]bool  // category codes seen
+	areas    map[Geocode]area  // areas indexed by geography code
+	names    map[string]string // geography_name for each geocode
 }
 
 type area struct {
@@ -79,6 +81,11 @@ func (tbl *Table) SetCell(geocode, geotype, catcode string, value float64) {
 	a.metrics[Catcode(catcode)] = value
 }
 
+// SetNames supplies the geography_name for each geocode.
+func (tbl *Table) SetNames(names map[string]string) {
+	tbl.names = names
+}
+
 // DivideBy divides the values in each column by the corresponding value in the divideby column.
 func (tbl *Table) DivideBy(divideby string) error {
 	for geocode, area := range tbl.areas {
@@ -98,39 +105,69 @@ func (tbl *Table) DivideBy(divideby string) error {
 	return nil
 }
 
-// Generate produces a CSV version of the table on w.
-// It doesn't close w.
-//
-// include is a list of non-category columns to include in the output table.
-// Currently supported values are "geography_code" and "geotype".
-//
-func (tbl *Table) Generate(w io.Writer, include []string) error {
-	// sort the geography codes we have seen
+// Geocodes returns the geography codes seen so far, sorted.
+func (tbl *Table) Geocodes() []string {
 	geocodes := sort.StringSlice{}
 	for geo := range tbl.geocodes {
 		geocodes = append(geocodes, string(geo))
 	}
 	geocodes.Sort()
+	return geocodes
+}
 
-	// sort the category codes we have seen
+// sortedCatcodes returns the category codes seen so far, sorted.
+func (tbl *Table) sortedCatcodes() []string {
 	catcodes := sort.StringSlice{}
 	for cat := range tbl.catcodes {
 		catcodes = append(catcodes, string(cat))
 	}
 	catcodes.Sort()
+	return catcodes
+}
 
-	// note which non-category columns we want to include
-	// XXX make it an error on unrecognized columns
-	includeGeocode := false
-	includeGeotype := false
+// wantCols notes which non-category columns are named in include.
+// XXX make it an error on unrecognized columns
+func wantCols(include []string) (includeGeocode, includeName, includeGeotype bool) {
 	for _, col := range include {
 		switch col {
 		case ColGeographyCode:
 			includeGeocode = true
+		case ColGeographyName:
+			includeName = true
 		case ColGeotype:
 			includeGeotype = true
 		}
 	}
+	return includeGeocode, includeName, includeGeotype
+}
+
+// WantsName is true if include asks for the geography_name column, so the caller
+// needs to look up area names.
+func WantsName(include []string) bool {
+	_, includeName, _ := wantCols(include)
+	return includeName
+}
+
+// FormatValue formats a metric the same way in every output format, and in the CSV
+// built outside this package by /aggregate and /compare.
+//
+// Precision may need to be increased if numbers are printed as exponents,
+// or if decimals are rounded
+// See the "specific numeric formatting tests" in table_test.go.
+func FormatValue(value float64) string {
+	return fmt.Sprintf("%.13g", value)
+}
+
+// Generate produces a CSV version of the table on w.
+// It doesn't close w.
+//
+// include is a list of non-category columns to include in the output table.
+// Currently supported values are "geography_code", "geography_name" and "geotype".
+//
+func (tbl *Table) Generate(w io.Writer, include []string) error {
+	geocodes := tbl.Geocodes()
+	catcodes := tbl.sortedCatcodes()
+	includeGeocode, includeName, includeGeotype := wantCols(include)
 
 	// set up csv output on w
 	cw := csv.NewWriter(w)
@@ -140,6 +177,9 @@ func (tbl *Table) Generate(w io.Writer, include []string) error {
 	if includeGeocode {
 		colnames = append(colnames, ColGeographyCode)
 	}
+	if includeName {
+		colnames = append(colnames, ColGeographyName)
+	}
 	if includeGeotype {
 		colnames = append(colnames, ColGeotype)
 	}
@@ -155,15 +195,15 @@ func (tbl *Table) Generate(w io.Writer, include []string) error {
 		if includeGeocode {
 			row = append(row, geocode)
 		}
+		if includeName {
+			row = append(row, tbl.names[geocode])
+		}
 		if includeGeotype {
 			row = append(row, string(tbl.areas[Geocode(geocode)].geotype))
 		}
 
 		for _, catcode := range catcodes {
-			// Precision may need to be increased if numbers are printed as exponents,
-			// or if decimals are rounded
-			// See the "specific numeric formatting tests" in table_test.go.
-			row = append(row, fmt.Sprintf("%.13g", tbl.areas[Geocode(geocode)].metrics[Catcode(catcode)]))
+			row = append(row, FormatValue(tbl.areas[Geocode(geocode)].metrics[Catcode(catcode)]))
 		}
 
 		cw.Write(row)
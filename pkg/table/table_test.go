@@ -192,6 +192,23 @@ func TestGenerate(t *testing.T) {
 	}
 }
 
+func TestGenerateNames(t *testing.T) {
+	tbl := table.New()
+	tbl.SetCell("W06000015", "LAD", "cat", 1)
+	tbl.SetCell("W06000016", "LAD", "cat", 2)
+	tbl.SetNames(map[string]string{"W06000015": "Caerdydd"})
+
+	var buf strings.Builder
+	if err := tbl.Generate(&buf, []string{table.ColGeotype, table.ColGeographyName, table.ColGeographyCode}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "geography_code,geography_name,geotype,cat\nW06000015,Caerdydd,LAD,1\nW06000016,,LAD,2\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s\n", buf.String(), want)
+	}
+}
+
 func Test_DivideBy_Error(t *testing.T) {
 	inputZeroDenom := []row{
 		{"geo", "type", "cat2", 1},
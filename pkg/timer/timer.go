@@ -4,13 +4,21 @@ import (
 	"context"
 	"time"
 
+	"github.com/ONSdigital/dp-geodata-api/pkg/metrics"
 	"github.com/ONSdigital/log.go/v2/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// scanNote names the timer which is started and stopped around each row scanned, so
+// its laps count rows.
+const scanNote = "scan"
+
 type Timer struct {
 	note  string
 	accum time.Duration
 	start time.Time
+	laps  int
 }
 
 func New(note string) *Timer {
@@ -25,8 +33,21 @@ func (t *Timer) Start() {
 
 func (t *Timer) Stop() {
 	t.accum += time.Since(t.start)
+	t.laps++
 }
 
+// Log logs the accumulated time, and records it in the timer's Prometheus histogram,
+// labelled by the endpoint and geotype in ctx.
+// It is also added as an event to the span in ctx, if any.
 func (t *Timer) Log(ctx context.Context) {
 	log.Info(ctx, "timer", log.Data{"note": t.note, "elapsed": t.accum})
+	trace.SpanFromContext(ctx).AddEvent("timer", trace.WithAttributes(
+		attribute.String("timer.note", t.note),
+		attribute.Int64("timer.elapsed_us", t.accum.Microseconds()),
+		attribute.Int("timer.laps", t.laps),
+	))
+	metrics.ObserveTimer(ctx, t.note, t.accum)
+	if t.note == scanNote {
+		metrics.AddRows(ctx, t.laps)
+	}
 }
@@ -0,0 +1,130 @@
+// Package tracing sets up OpenTelemetry tracing, and has helpers for creating spans.
+//
+// Spans are created through the global tracer provider, which does nothing until
+// Setup installs an exporter, so instrumented code costs little when tracing is off.
+// Trace context is propagated in W3C traceparent and tracestate headers, both from
+// incoming requests and to outgoing ones.
+//
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentation names the tracer used for the service's own spans.
+const instrumentation = "github.com/ONSdigital/dp-geodata-api"
+
+// Exporters understood by Setup.
+const (
+	ExporterNone   = ""
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Setup installs the W3C trace context propagator and a tracer provider sending
+// spans to exporter, identifying them as coming from service.
+//
+// ExporterStdout pretty prints spans to stdout, for local use.
+// ExporterOTLP sends spans over OTLP/HTTP to endpoint, such as http://localhost:4318;
+// if endpoint is empty, the standard OTEL_EXPORTER_OTLP_* variables are used.
+// ExporterNone leaves tracing off, though trace context is still passed on.
+//
+// The returned function flushes any spans not yet exported, and stops the provider.
+func Setup(ctx context.Context, exporter, endpoint, service string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exp sdktrace.SpanExporter
+	var err error
+	switch exporter {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exp, err = newStdoutExporter(os.Stdout)
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(endpoint))
+		}
+		exp, err = otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q: want %q or %q", exporter, ExporterStdout, ExporterOTLP)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(service))),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+func newStdoutExporter(w io.Writer) (sdktrace.SpanExporter, error) {
+	return stdouttrace.New(stdouttrace.WithWriter(w), stdouttrace.WithPrettyPrint())
+}
+
+// Start starts a span called name, as a child of any span in ctx.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentation).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err, if any, on span, and ends it.
+func End(span trace.Span, err error) {
+	Error(span, err)
+	span.End()
+}
+
+// Error records err, if any, on span, and marks it as failed.
+func Error(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// Middleware starts a server span for each request, continuing any trace the
+// client started.
+// Spans are named after the method alone, since paths hold parameters like years and
+// would give every request its own name; the path is an attribute instead.
+func Middleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := otel.Tracer(instrumentation).Start(
+			ctx,
+			r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+				semconv.URLQuery(r.URL.RawQuery),
+			),
+		)
+		defer span.End()
+		h.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Inject adds the trace context in ctx to the headers of an outgoing request.
+func Inject(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
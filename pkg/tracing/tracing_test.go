@@ -0,0 +1,114 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// record installs a tracer provider which keeps spans in memory.
+func record(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	if _, err := Setup(context.Background(), ExporterNone, "", "test"); err != nil {
+		t.Fatal(err)
+	}
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return sr
+}
+
+func TestSetup(t *testing.T) {
+	if _, err := Setup(context.Background(), "zipkin", "", "test"); err == nil {
+		t.Error("unknown exporter: expected error")
+	}
+
+	shutdown, err := Setup(context.Background(), ExporterNone, "", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestStdoutExporter(t *testing.T) {
+	var buf bytes.Buffer
+	exp, err := newStdoutExporter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	_, span := tp.Tracer("test").Start(context.Background(), "Geodata.Query")
+	span.End()
+	if err := tp.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"Name": "Geodata.Query"`) {
+		t.Errorf("span not written: %s", buf.String())
+	}
+}
+
+func TestEnd(t *testing.T) {
+	sr := record(t)
+
+	_, span := Start(context.Background(), "ok")
+	End(span, nil)
+	_, span = Start(context.Background(), "failed")
+	End(span, errors.New("boom"))
+
+	spans := sr.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	if got := spans[0].Status().Code; got != codes.Unset {
+		t.Errorf("ok span status: got %v", got)
+	}
+	if got := spans[1].Status(); got.Code != codes.Error || got.Description != "boom" {
+		t.Errorf("failed span status: got %+v", got)
+	}
+}
+
+func TestPropagation(t *testing.T) {
+	sr := record(t)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	var outgoing http.Header
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := Start(r.Context(), "respond")
+		defer span.End()
+		outgoing = http.Header{}
+		Inject(ctx, outgoing)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/query/2011?rows=E01000001", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := sr.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	for _, span := range spans {
+		if got := span.SpanContext().TraceID().String(); got != traceID {
+			t.Errorf("%s: trace id %s, want %s", span.Name(), got, traceID)
+		}
+	}
+	if got := spans[1].Name(); got != http.MethodGet {
+		t.Errorf("server span name: got %q", got)
+	}
+	if got := outgoing.Get("traceparent"); !strings.Contains(got, traceID) {
+		t.Errorf("outgoing traceparent %q does not carry trace %s", got, traceID)
+	}
+}
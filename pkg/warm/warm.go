@@ -0,0 +1,224 @@
+// The warm package fills the response cache by replaying requests through the API
+// in-process, so the first users after a deploy don't pay for a cold cache.
+//
+// Request URIs come from a file of plain URIs, or of request log events; see ReadURIs.
+// A Warmer replays them with bounded concurrency, and its Checker keeps the healthcheck
+// at WARNING until it is done, so the instance is not reported ready while warming.
+//
+package warm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/dp-healthcheck/healthcheck"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// A Warmer replays requests through a handler.
+type Warmer struct {
+	handler   http.Handler
+	workers   int
+	header    http.Header // added to every request, eg Authorization
+	encodings []string    // each URI is requested once with each Accept-Encoding
+
+	mu     sync.Mutex // protects the fields below
+	total  int
+	done   int
+	failed int
+	ended  bool
+}
+
+// New sets up a Warmer to send requests to handler, workers at a time.
+// header is added to every request.
+// If encodings are given, each URI is requested once with each of them as its
+// Accept-Encoding, so compressed responses are cached too.
+func New(handler http.Handler, workers int, header http.Header, encodings ...string) *Warmer {
+	if workers < 1 {
+		workers = 1
+	}
+	if len(encodings) == 0 {
+		encodings = []string{""}
+	}
+	return &Warmer{
+		handler:   handler,
+		workers:   workers,
+		header:    header,
+		encodings: encodings,
+	}
+}
+
+// Run requests each of uris in turn, and returns when they have all completed or ctx
+// is done.
+// Failed requests are logged and counted, but don't stop the run.
+func (wm *Warmer) Run(ctx context.Context, uris []string) {
+	wm.mu.Lock()
+	wm.total = len(uris)
+	wm.mu.Unlock()
+
+	start := time.Now()
+	log.Info(ctx, "cache warming started", log.Data{"uris": len(uris), "workers": wm.workers})
+
+	queue := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < wm.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for uri := range queue {
+				err := wm.warm(ctx, uri)
+				wm.mu.Lock()
+				wm.done++
+				if err != nil {
+					wm.failed++
+				}
+				wm.mu.Unlock()
+				if err != nil {
+					log.Warn(ctx, "cannot warm cache", log.Data{"uri": uri, "message": err.Error()})
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, uri := range uris {
+		select {
+		case queue <- uri:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	wm.mu.Lock()
+	wm.ended = true
+	data := log.Data{"uris": wm.total, "done": wm.done, "failed": wm.failed, "elapsed": time.Since(start).String()}
+	wm.mu.Unlock()
+	log.Info(ctx, "cache warming finished", data)
+}
+
+// warm requests uri once for each encoding.
+// Handler panics, such as aborted streams, are returned as errors.
+func (wm *Warmer) warm(ctx context.Context, uri string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+
+	for _, encoding := range wm.encodings {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+		if err != nil {
+			return err
+		}
+		req.RequestURI = uri
+		for k, v := range wm.header {
+			req.Header[k] = v
+		}
+		if encoding != "" {
+			req.Header.Set("Accept-Encoding", encoding)
+		}
+
+		w := &discard{header: http.Header{}}
+		wm.handler.ServeHTTP(w, req)
+		if w.status >= http.StatusBadRequest {
+			return fmt.Errorf("status %d", w.status)
+		}
+	}
+	return nil
+}
+
+// Checker reports WARNING until Run has finished, and then OK.
+func (wm *Warmer) Checker(ctx context.Context, state *healthcheck.CheckState) error {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+
+	if !wm.ended {
+		state.Update(healthcheck.StatusWarning, fmt.Sprintf("warming cache: %d of %d requests done", wm.done, wm.total), 0)
+		return nil
+	}
+	state.Update(healthcheck.StatusOK, fmt.Sprintf("cache warmed: %d requests, %d failed", wm.done, wm.failed), 0)
+	return nil
+}
+
+// ReadURIs reads request URIs from r, one per line, in the order they first appear.
+//
+// Lines are either plain URIs, such as /v1/geodata/query/2011?rows=ALL&cols=QS101EW0001,
+// or JSON request log events, which name the request in their http.path and http.query.
+// Log events for methods other than GET are skipped, as are blank lines and lines
+// starting with #.
+//
+func ReadURIs(r io.Reader) ([]string, error) {
+	var uris []string
+	seen := map[string]bool{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		uri := line
+		if strings.HasPrefix(line, "{") {
+			var event struct {
+				HTTP *struct {
+					Method string `json:"method"`
+					Path   string `json:"path"`
+					Query  string `json:"query"`
+				} `json:"http"`
+			}
+			if err := json.Unmarshal([]byte(line), &event); err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			if event.HTTP == nil || event.HTTP.Path == "" || (event.HTTP.Method != "" && event.HTTP.Method != http.MethodGet) {
+				continue
+			}
+			uri = event.HTTP.Path
+			if event.HTTP.Query != "" {
+				uri += "?" + event.HTTP.Query
+			}
+		}
+
+		if !strings.HasPrefix(uri, "/") {
+			return nil, fmt.Errorf("line %d: not a request URI: %q", n, uri)
+		}
+		if !seen[uri] {
+			seen[uri] = true
+			uris = append(uris, uri)
+		}
+	}
+	return uris, scanner.Err()
+}
+
+// discard is a ResponseWriter that only keeps the status.
+type discard struct {
+	header http.Header
+	status int
+}
+
+func (d *discard) Header() http.Header {
+	return d.header
+}
+
+func (d *discard) Write(p []byte) (int, error) {
+	if d.status == 0 {
+		d.status = http.StatusOK
+	}
+	return len(p), nil
+}
+
+func (d *discard) WriteHeader(status int) {
+	if d.status == 0 {
+		d.status = status
+	}
+}
@@ -0,0 +1,117 @@
+package warm
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-healthcheck/healthcheck"
+)
+
+func TestReadURIs(t *testing.T) {
+	input := `
+# popular queries
+/v1/geodata/query/2011?rows=ALL&cols=QS101EW0001
+{"event":"http request received","http":{"method":"GET","path":"/v1/geodata/metadata/2011","query":"lang=cy"}}
+{"event":"http request received","http":{"method":"POST","path":"/v1/geodata/exports"}}
+{"event":"something else"}
+/v1/geodata/query/2011?rows=ALL&cols=QS101EW0001
+{"http":{"path":"/v1/geodata/geo/2011"}}
+`
+	got, err := ReadURIs(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"/v1/geodata/query/2011?rows=ALL&cols=QS101EW0001",
+		"/v1/geodata/metadata/2011?lang=cy",
+		"/v1/geodata/geo/2011",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	for _, bad := range []string{"query/2011", `{"http":`} {
+		if _, err := ReadURIs(strings.NewReader(bad)); err == nil {
+			t.Errorf("%q: expected error", bad)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	const workers = 3
+
+	var mu sync.Mutex
+	var requests []string
+	var running, maxRunning int32
+	release := make(chan struct{})
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		mu.Lock()
+		if n > maxRunning {
+			maxRunning = n
+		}
+		requests = append(requests, r.RequestURI+" "+r.Header.Get("Accept-Encoding")+" "+r.Header.Get("Authorization"))
+		mu.Unlock()
+		<-release
+
+		switch r.URL.Path {
+		case "/bad":
+			w.WriteHeader(http.StatusBadRequest)
+		case "/panic":
+			panic(http.ErrAbortHandler)
+		default:
+			w.Write([]byte("ok"))
+		}
+	})
+
+	header := http.Header{}
+	header.Set("Authorization", "token")
+	wm := New(handler, workers, header, "br", "gzip")
+
+	uris := []string{"/a", "/b", "/c", "/d", "/bad", "/panic"}
+	done := make(chan struct{})
+	go func() {
+		wm.Run(context.Background(), uris)
+		close(done)
+	}()
+
+	// still warming
+	state := healthcheck.NewCheckState("cache warming")
+	time.Sleep(10 * time.Millisecond)
+	wm.Checker(context.Background(), state)
+	if state.Status() != healthcheck.StatusWarning {
+		t.Errorf("while warming: status %s, want %s", state.Status(), healthcheck.StatusWarning)
+	}
+
+	close(release)
+	<-done
+
+	wm.Checker(context.Background(), state)
+	if state.Status() != healthcheck.StatusOK {
+		t.Errorf("after warming: status %s, want %s", state.Status(), healthcheck.StatusOK)
+	}
+	if !strings.Contains(state.Message(), "2 failed") {
+		t.Errorf("message %q should count failures", state.Message())
+	}
+
+	if maxRunning > workers {
+		t.Errorf("%d requests at once, want at most %d", maxRunning, workers)
+	}
+	// good URIs are requested with each encoding; failures stop at the first
+	if len(requests) != 4*2+2 {
+		t.Errorf("got %d requests, want %d: %q", len(requests), 4*2+2, requests)
+	}
+	for _, req := range requests {
+		if !strings.HasSuffix(req, " token") {
+			t.Errorf("request %q missing Authorization", req)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package where
+
+import "fmt"
+
+// Args collects the values for the placeholders in a parameterised query.
+// Each call to Add returns the placeholder ($1, $2, ...) to put in the SQL text in
+// place of the value, so user input never becomes part of the SQL itself.
+//
+// The zero value is ready to use.
+//
+type Args struct {
+	values []interface{}
+}
+
+// Add appends v to the argument list and returns its placeholder.
+func (a *Args) Add(v interface{}) string {
+	a.values = append(a.values, v)
+	return fmt.Sprintf("$%d", len(a.values))
+}
+
+// Values returns the arguments to pass to QueryContext along with the SQL.
+func (a *Args) Values() []interface{} {
+	return a.values
+}
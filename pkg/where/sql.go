@@ -3,35 +3,33 @@ package where
 import (
 	"fmt"
 	"strings"
-
-	"github.com/lib/pq"
 )
 
 // WherePart returns the part of the where clause between parens, as in:
 // (
-//	   geography_code IN ( ... )
+//	   geography_code = ANY( $1 )
 //     OR
-//     geography_code BETWEEN (low, high)
+//     geography_code BETWEEN $2 AND $3
 //	   ...
 // )
 //
 // col is the name of the column we are matching (eg, "geography_code" or "category_code").
 // set is a ValueSet which contains the single value and ranges returned by ParseMultiArgs.
+// The values themselves are added to args; only placeholders appear in the returned SQL.
+// All the single values go in one array argument, so the SQL is the same no matter
+// how many there are.
 //
 // If set has no single values or ranges, an empty string will be returned.
 //
-func WherePart(col string, set *ValueSet) string {
+func WherePart(col string, set *ValueSet, args *Args) string {
 	var conditions []string
 
 	if len(set.Singles) > 0 {
-		var values []string
-		for _, single := range set.Singles {
-			values = append(values, pq.QuoteLiteral(single))
-		}
+		singles := append([]string(nil), set.Singles...)
 		condition := fmt.Sprintf(
-			"    %s IN ( %s )\n",
+			"    %s = ANY( %s )\n",
 			col,
-			strings.Join(values, ", "),
+			args.Add(singles),
 		)
 		conditions = append(conditions, condition)
 	}
@@ -40,8 +38,8 @@ func WherePart(col string, set *ValueSet) string {
 		condition := fmt.Sprintf(
 			"    %s BETWEEN %s AND %s\n",
 			col,
-			pq.QuoteLiteral(vrange.Low),
-			pq.QuoteLiteral(vrange.High),
+			args.Add(vrange.Low),
+			args.Add(vrange.High),
 		)
 		conditions = append(conditions, condition)
 	}
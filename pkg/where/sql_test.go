@@ -1,44 +1,58 @@
 package where
 
 import (
+	"reflect"
 	"testing"
 )
 
 func TestWherePart_OK(t *testing.T) {
 	var tests = []struct {
-		desc string
-		args []string
-		want string
+		desc     string
+		args     []string
+		want     string
+		wantArgs []interface{}
 	}{
 		{
 			"no values",
 			[]string{},
 			"",
+			nil,
 		},
 		{
 			"a single value",
 			[]string{"val"},
-			"    col IN ( 'val' )\n",
+			"    col = ANY( $1 )\n",
+			[]interface{}{[]string{"val"}},
 		},
 		{
 			"two single values",
 			[]string{"val1", "val2"},
-			"    col IN ( 'val1', 'val2' )\n",
+			"    col = ANY( $1 )\n",
+			[]interface{}{[]string{"val1", "val2"}},
 		},
 		{
 			"a range",
 			[]string{"lo...hi"},
-			"    col BETWEEN 'lo' AND 'hi'\n",
+			"    col BETWEEN $1 AND $2\n",
+			[]interface{}{"lo", "hi"},
 		},
 		{
 			"two ranges",
 			[]string{"lo1...hi1", "lo2...hi2"},
-			"    col BETWEEN 'lo1' AND 'hi1'\n    OR\n    col BETWEEN 'lo2' AND 'hi2'\n",
+			"    col BETWEEN $1 AND $2\n    OR\n    col BETWEEN $3 AND $4\n",
+			[]interface{}{"lo1", "hi1", "lo2", "hi2"},
 		},
 		{
 			"singles and ranges",
 			[]string{"val1,lo1...hi1", "val2,lo2...hi2"},
-			"    col IN ( 'val1', 'val2' )\n    OR\n    col BETWEEN 'lo1' AND 'hi1'\n    OR\n    col BETWEEN 'lo2' AND 'hi2'\n",
+			"    col = ANY( $1 )\n    OR\n    col BETWEEN $2 AND $3\n    OR\n    col BETWEEN $4 AND $5\n",
+			[]interface{}{[]string{"val1", "val2"}, "lo1", "hi1", "lo2", "hi2"},
+		},
+		{
+			"quotes stay out of the SQL",
+			[]string{"x'); DROP TABLE geo; --"},
+			"    col = ANY( $1 )\n",
+			[]interface{}{[]string{"x'); DROP TABLE geo; --"}},
 		},
 	}
 
@@ -48,9 +62,26 @@ func TestWherePart_OK(t *testing.T) {
 			t.Errorf("%s: %s\n", test.desc, err)
 			continue
 		}
-		got := WherePart("col", set)
+		var args Args
+		got := WherePart("col", set, &args)
 		if got != test.want {
 			t.Errorf("%s: %s, want %s", test.desc, got, test.want)
 		}
+		if !reflect.DeepEqual(args.Values(), test.wantArgs) {
+			t.Errorf("%s: args %#v, want %#v", test.desc, args.Values(), test.wantArgs)
+		}
+	}
+}
+
+func TestArgs(t *testing.T) {
+	var args Args
+	if p := args.Add(2011); p != "$1" {
+		t.Errorf("first placeholder %s, want $1", p)
+	}
+	if p := args.Add("QS101EW"); p != "$2" {
+		t.Errorf("second placeholder %s, want $2", p)
+	}
+	if !reflect.DeepEqual(args.Values(), []interface{}{2011, "QS101EW"}) {
+		t.Errorf("values %#v", args.Values())
 	}
 }
@@ -33,14 +33,21 @@ func (p *Postcode) GetMSOA(s string) (code, name string, err error) {
 
 }
 
+// IsPostcode is true if s looks like a postcode, with or without the space.
+func IsPostcode(s string) bool {
+	_, err := normalisePostcode(strings.TrimSpace(s))
+	return err == nil
+}
+
+// postcodeRE matches the outward and inward codes of a UK postcode, such as RM5 and 2DD.
+var postcodeRE = regexp.MustCompile(`^([A-Z]{1,2}[0-9][A-Z0-9]?)\s*([0-9][A-Z]{2})$`)
+
 func normalisePostcode(s string) (string, error) {
 	s = strings.ToUpper(s)
-	re := regexp.MustCompile(`(\S*)\s*(\d\D\D)`)
-	match := re.FindStringSubmatch(s)
-
-	if len(match) != 3 || match[0] != s {
+	match := postcodeRE.FindStringSubmatch(s)
+	if match == nil {
 		return "", fmt.Errorf("%w: invalid format", sentinel.ErrInvalidParams)
 	}
 
-	return re.ReplaceAllString(s, "$1 $2"), nil
+	return match[1] + " " + match[2], nil
 }
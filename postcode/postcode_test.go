@@ -114,3 +114,25 @@ func TestNormalisePostcode(t *testing.T) {
 	}
 
 }
+
+func TestIsPostcode(t *testing.T) {
+	var tests = map[string]bool{
+		"RM5 2DD":        true,
+		" rm52dd ":       true,
+		"EX39 5AA":       true,
+		"Newcastle":      false,
+		"Middlesborough": false,
+		"2nd":            false,
+		"3rd":            false,
+		"St Ives 1AB":    false,
+		"W1A 1AA":        true,
+		"EC1A1BB":        true,
+		"":               false,
+	}
+
+	for s, want := range tests {
+		if got := IsPostcode(s); got != want {
+			t.Errorf("%q: got %t, want %t", s, got, want)
+		}
+	}
+}
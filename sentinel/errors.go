@@ -8,9 +8,14 @@ const (
 	ErrTooManyMetrics    = Sentinel("too many metrics")
 	ErrPartialContent    = Sentinel("insufficient data found")
 	ErrNotSupported      = Sentinel("not supported")
+	ErrNotAcceptable     = Sentinel("no acceptable content type")
+	ErrNotFound          = Sentinel("not found")
+	ErrNotReady          = Sentinel("not ready")
 	ErrTableName         = Sentinel("empty table name")
 	ErrInconsistentTypes = Sentinel("inconsistent property types")
 	ErrUnusableType      = Sentinel("unusable property type")
+	ErrRateLimited       = Sentinel("rate limited")
+	ErrQueryTimeout      = Sentinel("query timed out")
 )
 
 func (e Sentinel) Error() string {
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ONSdigital/dp-geodata-api/cache"
+	"github.com/ONSdigital/dp-geodata-api/config"
+	"github.com/eko/gocache/v2/store"
+	"github.com/go-redis/redis/v8"
+)
+
+// newCacheManager sets up the response cache from the stores named in cfg.CacheStores,
+// fastest first.
+// The stores are:
+//	memory	bigcache in this process, CACHE_SIZE MB
+//	redis	the Redis server at REDIS_ADDR, shared by all replicas
+//	disk	files in CACHE_DIR, kept across restarts, with expired files swept
+//		until ctx is done
+// With REDIS_LOCK, replicas sharing Redis also share the per-key locks that stop
+// them all generating the same response.
+//
+func newCacheManager(ctx context.Context, cfg *config.Config) (*cache.Manager, error) {
+	if len(cfg.CacheStores) == 0 {
+		return nil, fmt.Errorf("CACHE_STORES: no cache stores")
+	}
+
+	var client *redis.Client
+	redisClient := func() *redis.Client {
+		if client == nil {
+			client = redis.NewClient(&redis.Options{
+				Addr:     cfg.RedisAddr,
+				Password: cfg.RedisPassword,
+			})
+		}
+		return client
+	}
+
+	var stores []store.StoreInterface
+	seen := map[string]bool{}
+	for _, name := range cfg.CacheStores {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if seen[name] {
+			return nil, fmt.Errorf("CACHE_STORES: %s listed twice", name)
+		}
+		seen[name] = true
+
+		switch name {
+		case "memory":
+			s, err := cache.NewMemoryStore(cfg.CacheTTL, cfg.CacheSize)
+			if err != nil {
+				return nil, err
+			}
+			stores = append(stores, s)
+		case "redis":
+			if cfg.RedisAddr == "" {
+				return nil, fmt.Errorf("CACHE_STORES: redis needs REDIS_ADDR")
+			}
+			stores = append(stores, cache.NewRedisStore(redisClient(), cfg.CacheTTL))
+		case "disk":
+			if cfg.CacheDir == "" {
+				return nil, fmt.Errorf("CACHE_STORES: disk needs CACHE_DIR")
+			}
+			s, err := cache.NewDiskStore(cfg.CacheDir, cfg.CacheTTL)
+			if err != nil {
+				return nil, err
+			}
+			go s.RunSweeper(ctx)
+			stores = append(stores, s)
+		default:
+			return nil, fmt.Errorf("CACHE_STORES: unknown cache store %q", name)
+		}
+	}
+
+	var locker cache.Locker
+	if cfg.RedisLock {
+		if !seen["redis"] {
+			return nil, fmt.Errorf("REDIS_LOCK needs redis in CACHE_STORES")
+		}
+		locker = cache.NewRedisLocker(redisClient())
+	}
+
+	return cache.NewChain(cfg.CacheTTL, locker, stores...), nil
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-geodata-api/config"
+	"github.com/alicebob/miniredis/v2"
+)
+
+func Test_newCacheManager(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // stops disk cache sweepers
+	mr := miniredis.RunT(t)
+
+	var tests = map[string]struct {
+		cfg     config.Config
+		wantErr bool
+	}{
+		"memory":             {cfg: config.Config{CacheStores: []string{"memory"}}},
+		"memory and redis":   {cfg: config.Config{CacheStores: []string{"memory", "redis"}, RedisAddr: mr.Addr(), RedisLock: true}},
+		"memory and disk":    {cfg: config.Config{CacheStores: []string{"memory", "disk"}, CacheDir: t.TempDir()}},
+		"no stores":          {cfg: config.Config{}, wantErr: true},
+		"unknown store":      {cfg: config.Config{CacheStores: []string{"memcache"}}, wantErr: true},
+		"store twice":        {cfg: config.Config{CacheStores: []string{"memory", "memory"}}, wantErr: true},
+		"redis without addr": {cfg: config.Config{CacheStores: []string{"redis"}}, wantErr: true},
+		"disk without dir":   {cfg: config.Config{CacheStores: []string{"disk"}}, wantErr: true},
+		"lock without redis": {cfg: config.Config{CacheStores: []string{"memory"}, RedisLock: true}, wantErr: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := test.cfg
+			cfg.CacheTTL = time.Hour
+			cfg.CacheSize = 10
+			_, err := newCacheManager(ctx, &cfg)
+			if test.wantErr && err == nil {
+				t.Error("expected error")
+			}
+			if !test.wantErr && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
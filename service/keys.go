@@ -0,0 +1,42 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/ONSdigital/dp-geodata-api/config"
+	"github.com/ONSdigital/dp-geodata-api/pkg/apikey"
+	"github.com/ONSdigital/dp-geodata-api/pkg/database"
+)
+
+// newKeyStore sets up the API key stores named in cfg:
+//	API_KEYS_FILE	a JSON file of keys, reread when it changes
+//	API_KEYS_DB	the api_key table
+// API_TOKEN, if set, is also accepted, as a key named API_TOKEN with every scope, for
+// clients which don't yet have keys of their own.
+// It may be configured with or without the "Bearer " scheme.
+//
+func newKeyStore(cfg *config.Config, db *database.Database) (apikey.Store, error) {
+	stores := apikey.Multi{}
+	if cfg.APIToken != "" {
+		stores = append(stores, apikey.NewStatic(apikey.Key{
+			Name:    "API_TOKEN",
+			Hash:    apikey.Hash(apikey.Secret(cfg.APIToken)),
+			Scopes:  []string{"*"},
+			Enabled: true,
+		}))
+	}
+	if cfg.APIKeysFile != "" {
+		fs, err := apikey.NewFileStore(cfg.APIKeysFile)
+		if err != nil {
+			return nil, fmt.Errorf("API_KEYS_FILE: %w", err)
+		}
+		stores = append(stores, fs)
+	}
+	if cfg.APIKeysDB {
+		if db == nil {
+			return nil, fmt.Errorf("API_KEYS_DB needs ENABLE_DATABASE")
+		}
+		stores = append(stores, apikey.NewDBStore(db.DB()))
+	}
+	return stores, nil
+}
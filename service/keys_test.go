@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ONSdigital/dp-geodata-api/config"
+	"github.com/ONSdigital/dp-geodata-api/pkg/apikey"
+)
+
+func Test_newKeyStore(t *testing.T) {
+	keysFile := filepath.Join(t.TempDir(), "keys.json")
+	content := `[{"name": "atlas", "hash": "` + apikey.Hash("atlas-key") + `", "scopes": ["query"], "enabled": true}]`
+	if err := os.WriteFile(keysFile, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = map[string]struct {
+		cfg      config.Config
+		wantKeys []string
+		wantErr  bool
+	}{
+		"no keys":               {cfg: config.Config{}},
+		"API_TOKEN":             {cfg: config.Config{APIToken: "token"}, wantKeys: []string{"token"}},
+		"API_TOKEN with scheme": {cfg: config.Config{APIToken: "Bearer token"}, wantKeys: []string{"token"}},
+		"file and token":        {cfg: config.Config{APIToken: "token", APIKeysFile: keysFile}, wantKeys: []string{"token", "atlas-key"}},
+		"missing file":          {cfg: config.Config{APIKeysFile: keysFile + ".missing"}, wantErr: true},
+		"db without db":         {cfg: config.Config{APIKeysDB: true}, wantErr: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			keys, err := newKeyStore(&test.cfg, nil)
+			if test.wantErr {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, secret := range test.wantKeys {
+				if _, err := keys.Lookup(context.Background(), apikey.Hash(secret)); err != nil {
+					t.Errorf("%s: %s", secret, err)
+				}
+			}
+		})
+	}
+}
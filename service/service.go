@@ -11,13 +11,17 @@ import (
 
 	"github.com/ONSdigital/dp-api-clients-go/middleware"
 	"github.com/ONSdigital/dp-geodata-api/api"
-	"github.com/ONSdigital/dp-geodata-api/cache"
 	"github.com/ONSdigital/dp-geodata-api/cantabular"
 	"github.com/ONSdigital/dp-geodata-api/config"
 	"github.com/ONSdigital/dp-geodata-api/handlers"
 	"github.com/ONSdigital/dp-geodata-api/metadata"
 	"github.com/ONSdigital/dp-geodata-api/pkg/database"
+	"github.com/ONSdigital/dp-geodata-api/pkg/exports"
 	"github.com/ONSdigital/dp-geodata-api/pkg/geodata"
+	"github.com/ONSdigital/dp-geodata-api/pkg/metrics"
+	"github.com/ONSdigital/dp-geodata-api/pkg/ratelimit"
+	"github.com/ONSdigital/dp-geodata-api/pkg/tracing"
+	"github.com/ONSdigital/dp-geodata-api/pkg/warm"
 	"github.com/ONSdigital/dp-geodata-api/postcode"
 	"github.com/ONSdigital/log.go/v2/log"
 	"github.com/justinas/alice"
@@ -32,6 +36,9 @@ type Service struct {
 	Server      HTTPServer
 	ServiceList *ExternalServiceList
 	HealthCheck HealthChecker
+	Exports     *exports.Manager
+
+	shutdownTracing func(context.Context) error
 }
 
 // Run the service
@@ -40,6 +47,11 @@ func Run(ctx context.Context, cfg *config.Config, serviceList *ExternalServiceLi
 	log.Info(ctx, "config on startup", log.Data{"config": cfg, "build_time": buildTime, "git-commit": gitCommit})
 	log.Info(ctx, "running service")
 
+	shutdownTracing, err := tracing.Setup(ctx, cfg.TracingExporter, cfg.TracingEndpoint, "dp-geodata-api")
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to set up tracing")
+	}
+
 	var cant *cantabular.Client
 	if cfg.EnableCantabular {
 		cant = cantabular.New(cfg.CantabularURL, cfg.CantabularUser, os.Getenv("CANT_PW"))
@@ -49,7 +61,6 @@ func Run(ctx context.Context, cfg *config.Config, serviceList *ExternalServiceLi
 	var queryGeodata *geodata.Geodata
 	var md *metadata.Metadata
 	var pc *postcode.Postcode
-	var err error
 	if cfg.EnableDatabase {
 		// figure out postgres password
 		pgpwd := os.Getenv("PGPASSWORD")
@@ -71,8 +82,18 @@ func Run(ctx context.Context, cfg *config.Config, serviceList *ExternalServiceLi
 			return nil, err
 		}
 
-		// set up our query functionality if we have a db
-		queryGeodata, err = geodata.New(db, cant, cfg.MaxMetrics)
+		// export connection pool stats
+		if err := metrics.RegisterDB("geodata", db.DB()); err != nil {
+			return nil, err
+		}
+
+		// set up our query functionality if we have a db;
+		// rate limiting replaces MAX_METRICS, so large queries wait rather than fail
+		maxMetrics := cfg.MaxMetrics
+		if cfg.RateLimitRate > 0 {
+			maxMetrics = 0
+		}
+		queryGeodata, err = geodata.New(db, cant, maxMetrics)
 		if err != nil {
 			return nil, err
 		}
@@ -106,7 +127,25 @@ func Run(ctx context.Context, cfg *config.Config, serviceList *ExternalServiceLi
 
 	}
 
-	cm, err := cache.New(cfg.CacheTTL, cfg.CacheSize)
+	// set up background exports if we have somewhere to put them
+	var ex *exports.Manager
+	if cfg.ExportDir != "" && queryGeodata != nil {
+		store, err := exports.NewFSStore(cfg.ExportDir)
+		if err != nil {
+			return nil, err
+		}
+		ex = exports.New(store, queryGeodata.Export, cfg.ExportWorkers, cfg.ExportTTL)
+		if err := ex.Start(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
+	cm, err := newCacheManager(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := newKeyStore(cfg, db)
 	if err != nil {
 		return nil, err
 	}
@@ -120,9 +159,19 @@ func Run(ctx context.Context, cfg *config.Config, serviceList *ExternalServiceLi
 		prefix = "/v1/geodata" // for backward compatibility
 	}
 
+	// rate limit queries by their estimated cost, if configured
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimitRate > 0 {
+		// without it, every client behind a load balancer would share one budget
+		if cfg.TrustedProxies < 0 {
+			return nil, errors.New("RATE_LIMIT_RATE needs TRUSTED_PROXIES: the number of proxies in front of the service, 0 if none")
+		}
+		limiter = ratelimit.New(cfg.RateLimitRate, cfg.RateLimitBurst)
+	}
+
 	// Setup the API
 	a := handlers.New(
-		cfg.APIToken,
+		keys,
 		cfg.BindAddr,
 		baseurl+prefix,
 		cfg.EnableHeaderAuth,
@@ -131,7 +180,13 @@ func Run(ctx context.Context, cfg *config.Config, serviceList *ExternalServiceLi
 		queryGeodata,
 		md,
 		cm,
+		cfg.StreamCacheLimit*1024*1024,
+		cfg.CacheMaxAge,
+		cfg.CacheMaxAges,
+		limiter,
+		cfg.TrustedProxies,
 		pc,
+		ex,
 	)
 
 	// Setup health checks
@@ -152,8 +207,20 @@ func Run(ctx context.Context, cfg *config.Config, serviceList *ExternalServiceLi
 		})
 	}
 
+	// http.TimeoutHandler buffers the whole response, so streamed responses
+	// get a context deadline instead.
+	// This must come after stripOptionalPrefix, since IsStreamRequest matches on the path.
 	timeoutHandler := func(h http.Handler) http.Handler {
-		return http.TimeoutHandler(h, cfg.WriteTimeout, "operation timed out\n")
+		th := http.TimeoutHandler(h, cfg.WriteTimeout, "operation timed out\n")
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !handlers.IsStreamRequest(r) {
+				th.ServeHTTP(w, r)
+				return
+			}
+			ctx, cancel := context.WithTimeout(r.Context(), cfg.StreamTimeout)
+			defer cancel()
+			h.ServeHTTP(w, r.WithContext(ctx))
+		})
 	}
 
 	stripOptionalPrefix := func(h http.Handler) http.Handler {
@@ -175,14 +242,44 @@ func Run(ctx context.Context, cfg *config.Config, serviceList *ExternalServiceLi
 		return http.HandlerFunc(strip)
 	}
 
+	// serve Prometheus metrics alongside the healthcheck
+	metricsFilter := func(h http.Handler) http.Handler {
+		mh := metrics.Handler()
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet && r.URL.Path == "/metrics" {
+				mh.ServeHTTP(w, r)
+				return
+			}
+			h.ServeHTTP(w, r)
+		})
+	}
+
 	// build handler chain
 	chain := alice.New(
 		clientInfo,
 		middleware.Whitelist(middleware.HealthcheckFilter(hc.Handler)),
-		timeoutHandler,
+		metricsFilter,
 		stripOptionalPrefix,
+		timeoutHandler,
+		tracing.Middleware,
+		handlers.Metrics,
+		handlers.QueryTimeout(cfg.QueryTimeout, cfg.QueryTimeouts),
 	).Then(api.Handler(a))
 
+	// replay popular requests to fill the cache; the healthcheck warns until done
+	if cfg.WarmFile != "" {
+		uris, err := readWarmFile(cfg.WarmFile)
+		if err != nil {
+			return nil, err
+		}
+		warmer := warm.New(chain, cfg.WarmWorkers, nil, "br", "gzip")
+		if err := hc.AddCheck("cache warming", warmer.Checker); err != nil {
+			return nil, errors.Wrap(err, "unable to register cache warming checker")
+		}
+		// warm requests use the internal API key, so need no Authorization header
+		go warmer.Run(handlers.Internal(ctx), uris)
+	}
+
 	// bind router handler to http server
 	s := serviceList.GetHTTPServer(cfg.BindAddr, chain)
 
@@ -198,6 +295,9 @@ func Run(ctx context.Context, cfg *config.Config, serviceList *ExternalServiceLi
 		HealthCheck: hc,
 		ServiceList: serviceList,
 		Server:      s,
+		Exports:     ex,
+
+		shutdownTracing: shutdownTracing,
 	}, nil
 }
 
@@ -224,6 +324,19 @@ func (svc *Service) Close(ctx context.Context) error {
 			hasShutdownError = true
 		}
 
+		// stop background exports; interrupted jobs are rerun on next startup
+		if svc.Exports != nil {
+			svc.Exports.Close()
+		}
+
+		// send any spans not yet exported
+		if svc.shutdownTracing != nil {
+			if err := svc.shutdownTracing(ctx); err != nil {
+				log.Error(ctx, "failed to shut down tracing", err)
+				hasShutdownError = true
+			}
+		}
+
 		// TODO: Close other dependencies, in the expected order
 	}()
 
@@ -247,6 +360,20 @@ func (svc *Service) Close(ctx context.Context) error {
 	return nil
 }
 
+// readWarmFile reads the request URIs to warm the cache with.
+func readWarmFile(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot open WARM_FILE")
+	}
+	defer f.Close()
+	uris, err := warm.ReadURIs(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read WARM_FILE %s", name)
+	}
+	return uris, nil
+}
+
 func registerCheckers(ctx context.Context,
 	hc HealthChecker,
 	db *database.Database,
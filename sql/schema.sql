@@ -16,6 +16,20 @@ SET xmloption = content;
 SET client_min_messages = warning;
 SET row_security = off;
 
+--
+-- Name: pg_trgm; Type: EXTENSION; Schema: -; Owner: -
+--
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm WITH SCHEMA public;
+
+
+--
+-- Name: EXTENSION pg_trgm; Type: COMMENT; Schema: -; Owner: 
+--
+
+COMMENT ON EXTENSION pg_trgm IS 'text similarity measurement and index searching based on trigrams';
+
+
 --
 -- Name: postgis; Type: EXTENSION; Schema: -; Owner: -
 --
@@ -34,6 +48,46 @@ SET default_tablespace = '';
 
 SET default_table_access_method = heap;
 
+--
+-- Name: api_key; Type: TABLE; Schema: public; Owner: insights
+--
+
+CREATE TABLE public.api_key (
+    id bigint NOT NULL,
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    name text,
+    key_hash text,
+    scopes text,
+    enabled boolean,
+    expires_at timestamp with time zone
+);
+
+
+ALTER TABLE public.api_key OWNER TO insights;
+
+--
+-- Name: api_key_id_seq; Type: SEQUENCE; Schema: public; Owner: insights
+--
+
+CREATE SEQUENCE public.api_key_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+ALTER TABLE public.api_key_id_seq OWNER TO insights;
+
+--
+-- Name: api_key_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: insights
+--
+
+ALTER SEQUENCE public.api_key_id_seq OWNED BY public.api_key.id;
+
+
 --
 -- Name: data_ver; Type: TABLE; Schema: public; Owner: insights
 --
@@ -95,6 +149,82 @@ ALTER TABLE public.geo_id_seq OWNER TO insights;
 ALTER SEQUENCE public.geo_id_seq OWNED BY public.geo.id;
 
 
+--
+-- Name: geo_breaks; Type: TABLE; Schema: public; Owner: insights
+--
+
+CREATE TABLE public.geo_breaks (
+    id integer NOT NULL,
+    data_ver_id integer,
+    category text,
+    geotype text,
+    divide_by text,
+    k integer,
+    method text,
+    stats jsonb,
+    data_updated_at timestamp with time zone
+);
+
+
+ALTER TABLE public.geo_breaks OWNER TO insights;
+
+--
+-- Name: geo_breaks_id_seq; Type: SEQUENCE; Schema: public; Owner: insights
+--
+
+CREATE SEQUENCE public.geo_breaks_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+ALTER TABLE public.geo_breaks_id_seq OWNER TO insights;
+
+--
+-- Name: geo_breaks_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: insights
+--
+
+ALTER SEQUENCE public.geo_breaks_id_seq OWNED BY public.geo_breaks.id;
+
+
+--
+-- Name: geo_hierarchy; Type: TABLE; Schema: public; Owner: insights
+--
+
+CREATE TABLE public.geo_hierarchy (
+    id integer NOT NULL,
+    child_id integer,
+    parent_id integer
+);
+
+
+ALTER TABLE public.geo_hierarchy OWNER TO insights;
+
+--
+-- Name: geo_hierarchy_id_seq; Type: SEQUENCE; Schema: public; Owner: insights
+--
+
+CREATE SEQUENCE public.geo_hierarchy_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+ALTER TABLE public.geo_hierarchy_id_seq OWNER TO insights;
+
+--
+-- Name: geo_hierarchy_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: insights
+--
+
+ALTER SEQUENCE public.geo_hierarchy_id_seq OWNED BY public.geo_hierarchy.id;
+
+
 --
 -- Name: geo_metric; Type: TABLE; Schema: public; Owner: insights
 --
@@ -144,6 +274,43 @@ CREATE TABLE public.geo_type (
 
 ALTER TABLE public.geo_type OWNER TO insights;
 
+--
+-- Name: geo_vintage_lookup; Type: TABLE; Schema: public; Owner: insights
+--
+
+CREATE TABLE public.geo_vintage_lookup (
+    id integer NOT NULL,
+    from_year integer,
+    from_code text,
+    to_year integer,
+    to_code text
+);
+
+
+ALTER TABLE public.geo_vintage_lookup OWNER TO insights;
+
+--
+-- Name: geo_vintage_lookup_id_seq; Type: SEQUENCE; Schema: public; Owner: insights
+--
+
+CREATE SEQUENCE public.geo_vintage_lookup_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+ALTER TABLE public.geo_vintage_lookup_id_seq OWNER TO insights;
+
+--
+-- Name: geo_vintage_lookup_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: insights
+--
+
+ALTER SEQUENCE public.geo_vintage_lookup_id_seq OWNED BY public.geo_vintage_lookup.id;
+
+
 --
 -- Name: lsoa2011_lad2020_lookup; Type: TABLE; Schema: public; Owner: insights
 --
@@ -187,6 +354,7 @@ CREATE TABLE public.nomis_category (
     id integer NOT NULL,
     nomis_desc_id integer NOT NULL,
     category_name text,
+    welsh_category_name text,
     measurement_unit text,
     stat_unit text,
     long_nomis_code text,
@@ -226,6 +394,7 @@ CREATE TABLE public.nomis_desc (
     id integer NOT NULL,
     nomis_topic_id integer NOT NULL,
     name text,
+    welsh_name text,
     pop_stat text,
     short_nomis_code text,
     year integer
@@ -263,7 +432,8 @@ ALTER SEQUENCE public.nomis_desc_id_seq OWNED BY public.nomis_desc.id;
 CREATE TABLE public.nomis_topic (
     id integer NOT NULL,
     top_nomis_code text,
-    name text
+    name text,
+    welsh_name text
 );
 
 
@@ -364,6 +534,13 @@ ALTER TABLE public.schema_ver_id_seq OWNER TO insights;
 ALTER SEQUENCE public.schema_ver_id_seq OWNED BY public.schema_ver.id;
 
 
+--
+-- Name: api_key id; Type: DEFAULT; Schema: public; Owner: insights
+--
+
+ALTER TABLE ONLY public.api_key ALTER COLUMN id SET DEFAULT nextval('public.api_key_id_seq'::regclass);
+
+
 --
 -- Name: geo id; Type: DEFAULT; Schema: public; Owner: insights
 --
@@ -371,6 +548,20 @@ ALTER SEQUENCE public.schema_ver_id_seq OWNED BY public.schema_ver.id;
 ALTER TABLE ONLY public.geo ALTER COLUMN id SET DEFAULT nextval('public.geo_id_seq'::regclass);
 
 
+--
+-- Name: geo_breaks id; Type: DEFAULT; Schema: public; Owner: insights
+--
+
+ALTER TABLE ONLY public.geo_breaks ALTER COLUMN id SET DEFAULT nextval('public.geo_breaks_id_seq'::regclass);
+
+
+--
+-- Name: geo_hierarchy id; Type: DEFAULT; Schema: public; Owner: insights
+--
+
+ALTER TABLE ONLY public.geo_hierarchy ALTER COLUMN id SET DEFAULT nextval('public.geo_hierarchy_id_seq'::regclass);
+
+
 --
 -- Name: geo_metric id; Type: DEFAULT; Schema: public; Owner: insights
 --
@@ -378,6 +569,13 @@ ALTER TABLE ONLY public.geo ALTER COLUMN id SET DEFAULT nextval('public.geo_id_s
 ALTER TABLE ONLY public.geo_metric ALTER COLUMN id SET DEFAULT nextval('public.geo_metric_id_seq'::regclass);
 
 
+--
+-- Name: geo_vintage_lookup id; Type: DEFAULT; Schema: public; Owner: insights
+--
+
+ALTER TABLE ONLY public.geo_vintage_lookup ALTER COLUMN id SET DEFAULT nextval('public.geo_vintage_lookup_id_seq'::regclass);
+
+
 --
 -- Name: lsoa2011_lad2020_lookup id; Type: DEFAULT; Schema: public; Owner: insights
 --
@@ -420,6 +618,14 @@ ALTER TABLE ONLY public.postcode ALTER COLUMN id SET DEFAULT nextval('public.pos
 ALTER TABLE ONLY public.schema_ver ALTER COLUMN id SET DEFAULT nextval('public.schema_ver_id_seq'::regclass);
 
 
+--
+-- Name: api_key api_key_pkey; Type: CONSTRAINT; Schema: public; Owner: insights
+--
+
+ALTER TABLE ONLY public.api_key
+    ADD CONSTRAINT api_key_pkey PRIMARY KEY (id);
+
+
 --
 -- Name: data_ver data_ver_pkey; Type: CONSTRAINT; Schema: public; Owner: insights
 --
@@ -428,6 +634,22 @@ ALTER TABLE ONLY public.data_ver
     ADD CONSTRAINT data_ver_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: geo_breaks geo_breaks_pkey; Type: CONSTRAINT; Schema: public; Owner: insights
+--
+
+ALTER TABLE ONLY public.geo_breaks
+    ADD CONSTRAINT geo_breaks_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: geo_hierarchy geo_hierarchy_pkey; Type: CONSTRAINT; Schema: public; Owner: insights
+--
+
+ALTER TABLE ONLY public.geo_hierarchy
+    ADD CONSTRAINT geo_hierarchy_pkey PRIMARY KEY (id);
+
+
 --
 -- Name: geo_metric geo_metric_pkey; Type: CONSTRAINT; Schema: public; Owner: insights
 --
@@ -452,6 +674,14 @@ ALTER TABLE ONLY public.geo_type
     ADD CONSTRAINT geo_type_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: geo_vintage_lookup geo_vintage_lookup_pkey; Type: CONSTRAINT; Schema: public; Owner: insights
+--
+
+ALTER TABLE ONLY public.geo_vintage_lookup
+    ADD CONSTRAINT geo_vintage_lookup_pkey PRIMARY KEY (id);
+
+
 --
 -- Name: lsoa2011_lad2020_lookup lsoa2011_lad2020_lookup_pkey; Type: CONSTRAINT; Schema: public; Owner: insights
 --
@@ -507,6 +737,13 @@ ALTER TABLE ONLY public.schema_ver
 CREATE INDEX geo_long_lat_geom_idx ON public.geo USING gist (wkb_long_lat_geom);
 
 
+--
+-- Name: geo_name_trgm_idx; Type: INDEX; Schema: public; Owner: insights
+--
+
+CREATE INDEX geo_name_trgm_idx ON public.geo USING gin (name public.gin_trgm_ops);
+
+
 --
 -- Name: geo_wkb_geometry_geom_idx; Type: INDEX; Schema: public; Owner: insights
 --
@@ -514,6 +751,34 @@ CREATE INDEX geo_long_lat_geom_idx ON public.geo USING gist (wkb_long_lat_geom);
 CREATE INDEX geo_wkb_geometry_geom_idx ON public.geo USING gist (wkb_geometry);
 
 
+--
+-- Name: geo_welsh_name_trgm_idx; Type: INDEX; Schema: public; Owner: insights
+--
+
+CREATE INDEX geo_welsh_name_trgm_idx ON public.geo USING gin (welsh_name public.gin_trgm_ops);
+
+
+--
+-- Name: idx_api_key_deleted_at; Type: INDEX; Schema: public; Owner: insights
+--
+
+CREATE INDEX idx_api_key_deleted_at ON public.api_key USING btree (deleted_at);
+
+
+--
+-- Name: idx_api_key_key_hash; Type: INDEX; Schema: public; Owner: insights
+--
+
+CREATE UNIQUE INDEX idx_api_key_key_hash ON public.api_key USING btree (key_hash);
+
+
+--
+-- Name: idx_api_key_name; Type: INDEX; Schema: public; Owner: insights
+--
+
+CREATE UNIQUE INDEX idx_api_key_name ON public.api_key USING btree (name);
+
+
 --
 -- Name: idx_data_ver_deleted_at; Type: INDEX; Schema: public; Owner: insights
 --
@@ -521,6 +786,27 @@ CREATE INDEX geo_wkb_geometry_geom_idx ON public.geo USING gist (wkb_geometry);
 CREATE INDEX idx_data_ver_deleted_at ON public.data_ver USING btree (deleted_at);
 
 
+--
+-- Name: idx_geo_breaks_key; Type: INDEX; Schema: public; Owner: insights
+--
+
+CREATE UNIQUE INDEX idx_geo_breaks_key ON public.geo_breaks USING btree (data_ver_id, category, geotype, divide_by, k, method);
+
+
+--
+-- Name: idx_geo_hierarchy_child_id; Type: INDEX; Schema: public; Owner: insights
+--
+
+CREATE INDEX idx_geo_hierarchy_child_id ON public.geo_hierarchy USING btree (child_id);
+
+
+--
+-- Name: idx_geo_hierarchy_parent_id; Type: INDEX; Schema: public; Owner: insights
+--
+
+CREATE INDEX idx_geo_hierarchy_parent_id ON public.geo_hierarchy USING btree (parent_id);
+
+
 --
 -- Name: idx_geo_metric_category_id; Type: INDEX; Schema: public; Owner: insights
 --
@@ -535,6 +821,13 @@ CREATE INDEX idx_geo_metric_category_id ON public.geo_metric USING btree (catego
 CREATE INDEX idx_geo_metric_geo_id ON public.geo_metric USING btree (geo_id);
 
 
+--
+-- Name: idx_geo_vintage_lookup_from; Type: INDEX; Schema: public; Owner: insights
+--
+
+CREATE INDEX idx_geo_vintage_lookup_from ON public.geo_vintage_lookup USING btree (from_year, from_code);
+
+
 --
 -- Name: idx_nomis_category_id; Type: INDEX; Schema: public; Owner: insights
 --
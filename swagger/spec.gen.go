@@ -15,73 +15,156 @@ import (
 // Base64 encoded, gzipped, json marshaled Swagger object
 var swaggerSpec = []string{
 
-	"H4sIAAAAAAAC/+x863Lbtrbwq6zh983E7qEpkuJNnskPN83Jzmkap3H26cyuMh6IhCTskAALgHY0Gb/7",
-	"mQVeREmUbKXObtom/VGKANf9Cqzkk5WKohSccq2s80+WSpe0IObxGdF0ISSj5hfTtDAP/1/SuXVu/b/R",
-	"+sNR89XonWRlTrV1Z1t6VVLr3CJSkhX+fi6lkPh9KUVJpW7A0vZ1RlUqWamZ4NZ5/RoKqhRZUMu26EdS",
-	"lDkCTEWVZ8CFBkVWsKR5LqwOm9KS8YV1d2dbkv5WMUkz6/zXBsn7bpuY/Zumhsp/UJLr5S5Z6ZKmHx7O",
-	"dw3mGX5E5RD3ShOprzUr6C6v75YUzDpkRFMgPAPcCGIOF29egqw4R6b6QvBd3z1zozPPe+d558Hk3Pec",
-	"0Hcnvv+vXWEY7LpSezHrSiEyvaSIEBHxqkC5Xf5o2dYvF29fv3z9wrKtZ29fvnv57OJVT5JrHFW5n7t6",
-	"rWFog5FxEHrREMk3VCoDYFszs4rl2QFJmvVdSfaYG5Sib6To/pfrnbvuEEELpq9TURRMD+NdMA31OiyJ",
-	"Wu7DGaf+nM5mc3+WeIkXh57nB3GSBfP5jGQzSr1ZFAbzaDxEQk74okJ/GCSglGIhSVEwvoB2J1SKZqAF",
-	"MERfUK53CFqIQ6iue3rYRdkstrx+NgWe4wXO+B4zOIh+G6bnuI47GBe2QsDd3qDQevOOBeZE6WsTIDC6",
-	"DBGGO2BpoIDZOGyP9KOmkpMcFJU3LKWHXTx0nfHYdZPJv4YVpvT1nLC8kvQAUbiDZr+fNm9y5k7OfN/Q",
-	"lpyHnuOaP95+4lSVplSpA8Q1O+ZV/h8WXptnBklrFrcC5UH0heALkc2AKTAhdAchJ/vCF64gjm34tR/N",
-	"VlsRusE0GJEfHvWHmDk6BQx50k9Uk4xoMpBgRWYksFc0u+zk1WJwQZNZTu8vTepdB8l8S1UpuKIPzvsd",
-	"fwMp3yAcYHyjqjoEvFd/3dmPKjChSf7gUm5IYO86kT+sLDSiGJJRg+TLmcdAxL+zLcbnYtcx/pvxDF5y",
-	"xRZLreAFFahaU4MxBaTzxLmQ8FtF5QrzXEq5qhSGKDIqGmtAN11QzIXlcgUnSHLWvWBUgZBwQyQTlYJU",
-	"CJkxTjQ9mxF0cYTMqDp1LNvKGYI3/NZ8W5cl5fBC3FDJTS59hTtSCjdjk+4qmVvn1lLr8nw0ur29dThB",
-	"3khOZLpkN1Q5C3HjVB9GmUhHoqT8bNHBOstrWKMmrY7GI6Mypk1My8qzRS2RM1Iyq5eam2R7Z1sIERfP",
-	"rXGTf0uil0aho/RDQQlXo08rSuQdvlrQgVLqn4oqaPYCydH+9bLAAoIqzQrMBJzoSpIcZpKSD6VgXCtg",
-	"3ASyBbuhvK8TaNxtBSffpUR/ByWRpKCaylMbpnzOck1lXaAInq9AiltlFIzQVElTNmc95a0AjQlOvltQ",
-	"gU99eA68pbqSXMH/XF2+hluml5AzpdsgO+UF+ciKqoAbkldUwQlzqGOWqrKkssfPKfJDSbpsJAFpXilN",
-	"pQ0f6Kqmdib0EhoqTG7sGJ3yE0UxpptspE4duCxrI8hXkBLeCbJmtDZYRG2kKNFgTGIQnK6BagGEC71E",
-	"GljNz5OM3bCMXs9WT6a8EwO6iqrKMkexGUJmNBe3p86UT/lFrgTIRkoECsavC/IRTDgwxNQ8t0hHHYPY",
-	"zFGlaQZnoJdM1Q6pb8WZkWUDwYgcSSsYn3IUCkJvpN0qdW0QktwiLx0bpqksRVnlRNPMRigjBNCIhM2B",
-	"aWAKWTGW3XCsrPNft634WW2BaOoOPKukpFznKyA3hOUYC8+n/Ax81/MMKIafoKdYbXyz8Eur38RqWVG7",
-	"6c57YY5xTRfYct7ZQ2l+0BO0gJTkqWFzQ/1zIR00n9ffwxnWG9AFNMozY5j4Leq0tet0l7U+ThTVM8Jh",
-	"hgwDnIFifJHTzgGos3Dg5yvf9Z//4rquf1rvwjaKnCmKIkad19oV8/53vc/G9vBzcIo291OVa0wyyL9a",
-	"u6syrjCjnbHaYKjBXU97JE0r1/WjrbfjKe9kJOYgCV8MsDN2HKdPDRrt68t3BqOQyFhnk028a8XsrM3C",
-	"JJq1XaREW30z6NLvbpLfSLXD5mF8S8z74e2QbUz5ha79S5jko5cUBWAgKiCSrllr9P3q4ofm4eryArXR",
-	"WAPsN4dXFz8cYwavLn6wEfimrtu4ca+6m41PkVCj6e6FIXiPFppNj6gJXhUzKpG5vtB7Wc/ZQ8oH67io",
-	"cHL55t3Ly9cXr05RwnvCA9o1UZBRLgqsTYSEk5ToURcqT3FXExfRiFubwYrIQJvymgUbGFeakqz2k9t6",
-	"FSMMm0PRc83OfBrlNIkAblmeo95q1KYDWlPhwCXPV1O+aUcmq3VBfcMsHVj/2avd7tsh0XZ15XuMznW7",
-	"YNTuu25duHJNuSlsCDKTmgJs9G9VH2Ks4a27xYb6xqjs1itahZxP+Sd0iKn185Xnek1Yss7BvMX3xlat",
-	"c/i1fgHgOmEwHod+5HpeGLnRZGyvl+LInYReEoVJPA6C0OstTdzY96JgEiRBOI7cpL8UJ+OJP4nj2Ivj",
-	"MPG7Ja9+eG/3qbluUvsWVa7r+0HkJV4w8YIoCD037KFIkiSYBGMvqf/zG8D4v7spv0MHL7Yc3N6woYeK",
-	"6+KHLbomXhQmSeRF/tiP3agvrUnkjf3EC/w49Dx3Em2IJPajSeDHfhBHQbwhyCSahJ6XoIB9z/X7S5No",
-	"HEfxOHCjeBJ7kx3xXfzw2NL7m9iIva328T1qdz0/mbheEAZhmEwS35v0MLm+H0ZeHPtJjHIKNzh1x9HY",
-	"Czwv9ryx68fRxodREPleMJmEQTL2k6QvPG88Hieh63pRGLquO/G/sPbtA+p3fS9y/dAbx0HshoHv9g3A",
-	"nfiBG/m+F7jJJIq8Pi5/HI1jP5kkkR+EYeDHvbUgHIeu78eeO4n9SRL215IoHk/8MPYDPwmDcfSfCxwY",
-	"6Nv0PBeyIBrDvKhmOV2fztUpeCBh39lbObQt19bnlaazasqmDEH4brDV2ypEgd2PqnKNSariZmdwZN44",
-	"dM5S37ENUFwwhVkFhIQZyXLTbBU0A8bLSjd50zJfzUmV6y9PEFYo3YkjlUCbjbalqqIgcmVq3bYQbQUu",
-	"cCtpu/y2YMFGb7NBR6WSBTZmVlnNcpZa7xF0ewZhCpcvexBhUMCM6ltKOTaqh44mptwcTnjNaQJ20SPA",
-	"N/7mecXjnlZM+duuE++fU/zuU4q/QH/cFMC8Kqjsyl9vhCo5hdsl5VBKkVUp+tRa3Yeap0fvq/c3iN7B",
-	"uvVz5LDdCPxZJOEfL4kjW+IjGsQHof9SfeDjdSsmK+8pHvcUjnuKxj0Fozfl77+l7L9Kym6z096E2F1y",
-	"1ZEFRlDHFvywTfUPzO45JfIsJemS9rL6PWav6Uc9KnPCtkS07a074qBFqVfQQq9DocENhg6abdja0Zoq",
-	"6JfVlKSFuKFA8hwo10b+cymK9qS9ZqUvacluiKaNqBd0oH4SJTVa5i8z69x6QfULKo4oBOw/qhB40VlX",
-	"KjJqA13Ac3dixhmCQ0eA5o7yqAi/xoRQDKbv6cecruAQHvP02EdRvMrzu68tfLygen1PmgLjcwFkJioN",
-	"hAORlDjwM4rHVAHmtocyvaQSGm1gsGwEBiezSpsbnZnQy9N9MWPZzf8NNgFthdyMo+zMytU3b2ZWEASH",
-	"jJaUZ5Tr9q5YWZ+pGvuB4m7mF3fFfdVPdO2V2+WPLQtGeC3h8yHCMc/5k0ezi47QXUobjHBLpBkdq0rU",
-	"Y0YXkmQ0gxOiIacEy0FOm4kgxqGZScGt7VBKw9zpV5cWWzO6ePPyyZYx9QwzE61VtlXxfT1qCxdZgXpp",
-	"tjWU0CubTXxvDffMhCBlQwfxhgImQ9vYtNKySnUlKZwwrrGgL1mqbKhnbYDq1AwofPVN3j8VbdpmM/Si",
-	"niIIYHNYiQpuSd1vLMkNhSf1hif9imR992RkZ+6/++tts98WAea++3l38b4npPfpGYrrMyFySvgjVO0P",
-	"GV3qZp4GbPvyR+trTBHd1NWeoF4oQUafSqE0JoW++xy01zfNB4B2CVe/eBfgXVzsM84W/EMM9IhM/dn1",
-	"6LOr/8VI/tPV5YWpY4wbG1q/vtITo9YWpUwraAqdQZ0aH9qNh199APr18vWV4VK9P1lqXarz0Yhy55Z9",
-	"YCXNGHGEXIzw1+jy9dV1KjLGF9dqpTQtTrvmqT87ppdEY/SachO+TIw3gxvrq/Xhi/XnrleP6J7ijgdd",
-	"rnef2O2T3z2NDRia56xUTPUgoYGxRSUqVQ9GbAHtEeI4TvPsuZv39+Zg897Le9z1tINW399vvutj2Fti",
-	"4yePeJXfT76tsupU85jHXtDN1eybqmlvwY7Qd+8ju3d72nv+fK33YJu5mObXtuZTkT9kSkfk6mkPYk/3",
-	"e/HsPygU+aPp/1ZALvjChpzo3mwnlIRJBZKWkirKdXtaKspSKKaRbcmRXTEHAjNRcYwDMBMfG+nNZuLj",
-	"U3N/mNih5wTROLRdx3O9uP4ZxOZE/92SqXpkQtGcptr09zvRI2d192TKF6Y28DnwPWJtxG6GztcTHDZW",
-	"24yjMc1YPVq6Hrqb8k2vBaKAwG1tZTU5iKM7y9l/covMHt1Ym4PburRS9d+bao7ttADSXo6kW0dJrRtt",
-	"uZCQw/6CIj5mBKoZgFqPP/05B5TekoxVyqTpXNT1ApyYAVRkuM2OpwcNz5iJrAE1/bNxFPQTdI4Osg1T",
-	"bjhvX2yZfePqBtJTDO0ODNH3GQb8WObb0nCcCX8T8lFCrmk7cvjtAhT9rTID82L+4EBNIM0FsliKfLVA",
-	"tZg8/qR++wTqg25kbs6k0rWIiNoBC1NeVEqjxMx9LSnoaePlDeSnruMmQWC0kExijPC+V/+ceK59MP7b",
-	"m986sJMLpvxB2aChpSPqsbSMBevxem6IuM+XBvO6qZfMicVxrvhIM5LOlP+ypLw3i8hUfaFhN8cG2AfR",
-	"rM46zYVHh6IdfMSd9YbGo7sdvZHI7U1Tvkba7ne++MCj6V1TdfOVni4brht9Hmwz/QfcbfzciPBbA7rd",
-	"gH7rP7/1n9/6z79h//mHtp9/h+7zW/P5xfuib73nNxn/BVvPP7bz/GMbz6+27/zTN1SvsFhsRm8UFESn",
-	"S5SuokSmS6zIMlaPUe5pttQtWSzqf2FncLwB0+bnDtDsMLQedjd/PX8uZFHHKaYwcdMt3hrcLd3m5wbV",
-	"FfuddBvlLnWR30Nwhw/+8e6nV4bwB9P6CdvJu147K9rB1u2W9o2k85wtlnq3px26/K5/3e8ag+3s8XPK",
-	"m5KR65GFvhieXb69grLlA+pDnKt2onHQCO/u/i8AAP//M8Tl1wBPAAA=",
+	"H4sIAAAAAAAC/+y9/XPbuPEw/q9g9P0+E7sfmiKpd3fyg5tLr3nqi9Mk7c18qkwKkZCEmgJ0BGhHzfh/",
+	"f2YXAAlKlGwndl6uucuMJYEEFruLxb5h8bGTytVaCia06px+7Kh0yVYUPz6jmi1kwRl+45qt8MP/X7B5",
+	"57Tz/3XrF7v2re7bgq9zpjs3QUdv1qxz2qFFQTfw/XlRyALeXxdyzQptu2Xu54yptOBrzaXonJqfyYop",
+	"RResE3TYB7pa59BhKss8I0JqouiGLFmey041mtIFF4vOzU3QKdhvJS9Y1jn9px3kXfWYnP2bpQjl8w9r",
+	"WehdsNKCUQ0vf+zMZbGiunPayahmJ5qv2O54QSeT1yKXNNudyt9fnxM5J3rJyLM3/yCy1OtSB0SKlBFA",
+	"Yc50a497MHO93GBfDCEnc8pzlrW9zxGWnZ8BL0zp2yhpEPPaPnwTdBT/D9uFBn7dnR3hgsw2mqndaVbY",
+	"5EIP+zXcXGi2YAWOpKkuDXOIcgX0+61kJU6yKIWAeQQdv0uDgnctOCjX2X3IuMU2POtU0AQVS9S91sjc",
+	"z1qva3Q3OWw2kx9aCZQyoUql6SxHfNec/7c3cRQ//7WN1qnMVePhf3YWTC4Kul5u3qcyg7kumMT33gX1",
+	"Wt7paHvVZvyKZ+z9bNP6tOuyOfLF2f3GyGVKDTu1PLyW+Waxp62gGS/9MTwmKuT1NkbOzs/vB9iG0ZYF",
+	"aOhDsNETTEkUx7vcvMVR+FIbs/yF0VwvW+TQkqWXd5e/pptn8JJBw/aUlKaFfo/cvzOxt0tGsJ0AexMq",
+	"MgIPwvI+e/WC1GuvZskkSqKTaHgSx2/j+LQ/OU3icJBEkyT53zY2rVd268i6VE6WnL160Qmq9X/x107Q",
+	"+fXs9csXL3/uBJ1nr1+8ffHs7HzPkt8/O9NmJ9SYSK8/iIdtIF+xQlne3Fq/Jc+zA5jE9l1MepNrxWKC",
+	"WIz+J4pPo6gNoAXX71O5WnHdPu6Ca2LayZKq5b4xR2kyZ7PZPJmN43E8GsRx0h+Ns/58PqPZjLF4Nhz0",
+	"58NeGwg5FYsS9uVWANYFCJ7ViosFcU+SUrGMaEk4DL9iQu8AtJCHhnrv0WF3SNvo5vrJEMRh3A97t7DB",
+	"weG3+4zDKIxaN5otEXCzVyi41bzDgTlV+j0KCJa1AwZPkCX2QvDBdn5kHzQrBM2JYsUVT9nhJT6Iwl4v",
+	"isaT/20nmNLvYUcuC3YAKLNnfz5s8eQkmpwkCcI2Ph3EYYT/xfuBU2WaMqUOAGefmJf5F0ae03dbQbON",
+	"W4Ly4PArKRYymxGuyMVf2wYUdJ/4ghYYY7t/s45mmy0JbUdqlch3l/ptk7n3FtC2kn5hmmZU05YNFpSj",
+	"NmXAoWZ3Onm5aG1Ale12E8k8dRDM10ytpVDszvt+Nb+WLf+t0yS3Jt6w7g517tmBN8GDIkxqmt/ZpGxD",
+	"2NsK5XczT+HxVhzZQR6PPVokPthoYi53F8afucjIC6H4YqkV+ZlJIC3qYFwRWq3EuSzIbyUrNrDPWa0U",
+	"nuyuLDfAMq3MAHIEIGfVD5wpIgtyRQsuS0VSKYuMC7CNZhSWOPTMmToOO0En59A9ztfMu3OxZoL8LK9Y",
+	"IXAvPYcnUkauerjdlUXeOe0stV6fdrvX19ehQA2f5rRIl/yKqXAhr8LyspvJtCvXTJwsqr5OctNX126r",
+	"3V4XScY1yrRsfbIwGDmha97xtma72d4EHegRGk87Pbv/rqleIkG7dLEo2IJq1v0I2vgN/LhgLcrUm3Kl",
+	"UCpZI49lPpIJwEtonuMjagWfaMGoIit6CQQp14SCsCxOMjbngmXYHEwFbCAF02UhkJhcLHJGCnkNQvDZ",
+	"m3+EUwGCER4Gci/4FQNjmrAPNNX5hkiBchnsm4BY6ygASjozCncoYxqFU2F6g+6XMs/MhKzZRlS5Ao6A",
+	"mQTYIMrVjBXQvZkKF2leZizbbtVLqdhUmIeulzxdougWGZmxjRSZEebZotokLZwAZsqLNGfkKM35es2y",
+	"Y9O3KlfwLKPpkljJtLG4omTNipQJTReG5xsPkYLBQi+BPPNCrlxvK9T2NM1V9Wg4FcgJBV0xzQrVOf3n",
+	"Ns2f1bZdSJ6VRcEE4JxeUZ6D7DidihMClh52xeEV4KyOkwcdaxXWNp8uShZYr1qbpXoTbMPwz4uXbwjI",
+	"HfXuCFaQOu12mQiv+SVfs4zTUBaLLnzrXrx8A8Y9F4v3aqM0Wx0jfiyJq1VeM6S2jBVOxd8VM8ygYKNX",
+	"G6HpB0LNT8BbpMIT9tlFQRMQ9iFla03Ozs+BOYXUsATkNcvCqTjL8+a4pdJkxupRLN+FNfKw1xp7MHDH",
+	"x9ZdbfVdLALX+8tVL6kmG1mSayq0ZZCQHMIC+FRasWBX6BZ31azpyQvXBnI7y0AGlFquqOYpzfPNfkTA",
+	"2AfZ6NMRc3Tx6u2Li5dn58fkpCEN5LziD+WLBkL95WsFC/mJzWmZa0W0JBdnQH1RySwnNgifE64V0EEX",
+	"kmckB7a45nrJRbtY2I8RC2WnZS3VW+z2XF8jsCgQK+l4NJN6CaASh91joljOUu1N/xoEXA03r6A20ycr",
+	"pgumpsLiLJdiEeRUkzXltSAOCAsXYfX1aRTGvd44GMRhf9gbTMsoSoamv6dxFEX75+56+K+cvHmocz8Z",
+	"ekYULEKRIlsjhARArLUchFaRgq0LppjQICEpSXMJuo/jy6OXfyIn5In59QlZMSqMbJjzQmmDWqp2up2K",
+	"bdF3bNFhO34ahdG430dsjCejIArjJDZfJ3EUNHEFX6N4ZL72R0Hz3ZC8XSKF8txScir2krK5/rhy8OzH",
+	"vn3gIOe9A0FlDBYUSUkUGdVZaCZQsdLsg+6m6qpzKso8B8U3M+Jj6zm6XufcMEz338q4W+pxD4YrMFhy",
+	"Y7r2OYELz6RkBWH2waADIo4WG5hJuWpR7XaVt07Q0XQBekNnXc5ynnbeQUfdlKZL1uXiiuY8o9oYW1K1",
+	"6JSv2UpeMUWAII4WhIqNE75G24NBUKWz5IPuySXbKOuexbfWBZvzD+FUOGNR4brWdLFgmXlE13sg6CXk",
+	"6F/w5ynoL/86Ru7F/QrtVnh4Q7Qs0yU5+hf+9PRvbwYQcPjXcTgVr3A41CZ0uvT1YvL31y8C8JksCVXk",
+	"X2aP7OIgdqcsWMaNIMq4urTzUVoWFmbFwCxAhU0GREnonRcVkoy4AsRlRtlkQB8ulKYitR2hyrmyGiPq",
+	"gTM2lwUjlBQMsRaQWamJFPmm6ti+OduYxVD1CAOmshQaVIQLvfRGg/lvyEISKaYCDTGxqPpz+ieHyOUK",
+	"9n07yVJonpNnZ8/+8vz927fnd1BENV2gs7LiqRrDFQ2BQxqE2rOGNV08kFLlUdzyXxPIfUIEH/1MGXJY",
+	"NrTY1k3Qa+PFUcux1Db5ARH9KHp80SQYR94CWgtZOIyiDOh8azLSYMtuMSxr8rzjC1zZvpQs+BXVrCEm",
+	"laZa7TW6X1vDeMl1QFZcqYCwK55Wdm3Fa/ADrlEVYAPutBCMxi90oSpzEiDd7Fvr4VQ835EGcmvJqyUt",
+	"jH7wGiWZLHxBBtJCSE1yDjq/WduPy8tOgFLNleap+gaZxeMHK0oLswNYvtnPJJeoZd3mnEHDzT5LaA7u",
+	"Sb1cgTRiSvMV1YwIqsuC5mRWMHq5llxoILu3zfpbfmWpHf0hpfoPtd13HJCpmPNcs8LEj3AHQQvZ2dpq",
+	"zVI+555vbUPQojr6g7Va/P5C4nj8/765eGk2amAdpwJMxYp+4KtyRa5oXjJFjnjIQmwq12tWePM5hvkY",
+	"bwhigqR5qTQrAlAWDLSo71soEP/VRKfiSDFGbLBAHYfkYm18dPmGpFRUiDQTNf5EGBqxWABP4RqTgtWd",
+	"akmowOUTgPEHQD+pUgieTEVtTnOwMYE7WUYQkBnL5TVoGn8yg+AmTPO0zKl2lihs/L5L0y1xO8GAlCJn",
+	"SlnnGGRYBJUF0rXWCwhao9ROhZFmqCMs0Gahqmnng1NDSc9dt+Li/Qp8BLBFtnijuhWyay/AiRU58L6+",
+	"lidIV9tDpaetuDDKC/RuKe8YrGbOgl4DXiuUouBZy7VBEkhM0YUOLHnQ/iZcHX8nrq9tp43PVxUvNFhx",
+	"LosQWBkNtVIxUvm+mchwkcC7wF9ujaW7U/PHBFQ9o4LMYMKEnDgHrVuMaMb97U0SJc9/jaIoOTZPQcSd",
+	"nigGKNYss9SVc/8977Ve0P65fww890uZa4hHwPw9F5TCZTlj1cKxRiU89dQDyRjXW7/2pqLCEbiPqVi0",
+	"TKcXhqEPDTDty4u3OKIs0MfqeNLKXofmA74sqh/Qp+dcVZ6oPcQbU3FmXBsriXEKvWSAAOzRGh9uapbe",
+	"52c/2Q9vLs6mouIGsp8dzs9+ug8bnJ/9FEDnTVo7uXErue2DTwFQpHT1AwJ8D//Z51Gi1qh9pHs7cLgH",
+	"lMt7OnMaHst94gH4miqSMSFXXFAtC3KUUt2tROUxPGXlIjCx4xlQ7LC3qTBTCFDzYzQz6+TatIKE4XOy",
+	"8pZmxT6WOHYjML6YGbNSGjXKGoqQXIh8MxVNPsId1j3TZMuQ1P/tpW717v1chA3MLuU1rqWllMp4rjy6",
+	"Nh2+dvGHlunNt9OmxqVwN+IrDjPF7iDUiPa19UAZhOVUKdPPv5m4VCcK9ZFT16t1xgjY+0HzUmuasoyY",
+	"p4BC4D2MLB4DRGROi0W1nlRlN1+cmWF+KymY4+y0dvzvxr084P5IVMm1IuqSXTPM6KIBuV6yohaCsFDX",
+	"pYb+CRGMFvnG6irGJS6MllRPlf1W0vwEVekrmp+aJmZsFmgi1zzTSzJj+pox4RQEYvUD04fSVGS0yE4y",
+	"dsVRxfH6EaxqJ1U79MoC4w4Elcp2zKgwPS4ZzU405fkpfuzCx4qYBVtj/ivSIOdaO7IalNEZmIeuP/DT",
+	"OJ8SLZhBTCkyVpB+9H+qEKLpyVBtyejVhmiTFwRIBun4HJG4Ynop66BpmypcBw8BA8bXo+SKobvGvEjm",
+	"7JrB5kUFuXTTmgoY2yrBFWvYVqCyICsqNnaSS3rlhbOQ5/bvewbqxoJ0ySyWbzpBx+f5TtBxAHSCTpNF",
+	"TCLyFr07QaciWWsizMH1Dvo5aGiE1iougR2ll9q1g1/Ye2dmt7VZA0xke1ozDu1OT56KKraL4WjHQgfX",
+	"X2XeI2PBF9OtNZh4US1+Y91NBVJHFsBtNpbnhNjb9m6oIGy11hs3YMHIgYhExhDhLZJ2JmXOqLgV9Z8V",
+	"4nWuYi/SqqWBnM8xaO4bMdaQRrfqbL9l5Uwg2CGXbpW4pA2MVB6yqQCbLmYCC26GKf8zcJwb74wztIRv",
+	"vaEnGVjApjSABzecihdzIuAXWlhDHV3hMPzTs/Pz4DYjkHDlkMFZtp+KDxhrbtBWX8s7B5yAjnK9lopr",
+	"wFghWIF7ACUzWQpcHohvQ9GpaJDUaBINPvBCq34HhuJ3wAlQ9zM0CNqMBf4R4bFRw4DIPdA2ooomXOcS",
+	"WRpgB1PxVeOpjalaoLlwcNMCEO6FP9HmtN/Ch4xtbqHchizvFu/c8nDcxkkmlOhW9l256GFihoddl3WO",
+	"q1WkKxeQ/e42mtOp+Ajqx9Qdn0ELuXNKPhqlZNpBs6lzSv5pfiAkCgf9Xm+QDKM4Hgyj4aQX1E2jYTQZ",
+	"xOPhYDzq9fuD2GuaRKMkHvYn/XF/0BtGY79pNO5NksloNIpHo8E4qZpi8+Fd4EPz3nqZtqCKoiTpD+Nx",
+	"3J/E/WF/EEcDb4jxeNyf9Hvx2Pyf2I7hz81U3IA2tdqyNYOGOXNXdJ39tAXXJB4OxuNhPEx6ySga+tia",
+	"DONeMo77CRwwiCbDBkpGyXDST0ZJfzTsjxqIHA8ngzgeA4KTOEr8psmwNxqOev1oOJqM4skO+s5+emjs",
+	"/ZfwSLBN9t4tZI/iZDyJ4v6gPxiMJ+MknngjRUkyGMajUTIeAZ4GjZlGvWEv7sfxKI57UTIaNl4c9odJ",
+	"3J9MBv1xLxmPfeTFvV5vPIiieDgYRFE0SR6Z+sEB8kdJPIySQdwb9UfRoJ9EPgNEk6QfDZMk7kfjyXAY",
+	"+2MlvWFvlIwn42HSHwz6ychr6w96gyhJRnE0GSWT8cBvGw9HvUkyGCX9ZDzo94ZfUnBMBe7kRvF9aqyG",
+	"hs6FJsSCaXV3+RGG4X7C7TQaGwTb4iQg/Tggk1FA4l4vIMPBVkeMCvNoFI5HvTiAxdXvm7+jyPwdJwP8",
+	"O4mT7bcz7r0/jvrm/UFi3x/Y94fm/WjkoarjHS+sD5zKcpZ7p02NtdOiWO4G+qx/oT6Qkm881R26SKL+",
+	"VnRMwRBg0Cl018xBL/liMW0I2aJKX5AZBV8BYIFB3HZdamuofXPhygqjW04nFyd0bkawZJohvr0ZQY0o",
+	"Zte6ZfZGM9/SSz8P1XMBU0W2OjMGVR2RglPXC37lzAkcyixO9D3NaZ4rwkVgLAhjPG3PqBmwggiIAdjE",
+	"tpFdXXp1FHjDVNErSAFWjr5/tK3cSzk3LZiDjOk9hOvlVHj+GzMh4y3dmvE9g1dfOjq1bYBXKvbWLL5Y",
+	"eETtxkc+EbbHDBrYNfGVgwT3xcjjONqdj3UPLK2+9+/U67jPbXVPOjyW96bhNrnF03ZPkD/TuWK89g4m",
+	"e4pGzh8KusfwiTwWrJ/vNnEuDSbAfeL2zgcC76Fzpn1vR+U/dyZ8s8xHYGNMnvIdQLj5eTQ0x6KD3v7G",
+	"JIinh6HeVVCtnnBAQf2hdu5VO1FLkvNaWwtIWTGjS2IApatbi+5D6iZGtx83cw6HqOOS1/JQLt1UYDZd",
+	"bNPfNCtIl8AvSTPB7mHT6yAj3inHfmLdZ6fV/Q6SqKwCJMoVK6ocibgLJDk20c51IbMydUxoyH0ow+bB",
+	"k6/2q8nx/famO+BhO1vke8FEcn9M3DNv6iFP4T1mstDDxRHQX7bHrbvHpbvHnbvHlRtPxbsfHqLfi4fI",
+	"7U57N8TaOYCShXSJkS1zWXuW7uhMyhktTszZinpXv4v6uM4pbz9csF+lM4kQrncjCm0eP8DBsgav3ZtS",
+	"K/ZFzqnQPP+kIypytaYF636Ed266H7W8ufWoCjjg0BEmhSm0ANpETVk/6T7wPXXI6pWqARu/Pb4GjXSm",
+	"ZF7ackNe/YN0ScWC+YlhK0xT/9kzXWjBCM+Y0EZrmm1smgwmnDhFTkujpUzFr5jKhoYvRXxhlpMZKAsM",
+	"9uBRpzhB9yu6XmMGmZaup4b1ZNRYSmZM6ZM51ySX8rJc2wnaw+Umh85/DUdesWKBTsh/mPGcwABMmQNT",
+	"OByAkbO5Npk7aKNx42vcRRfO4T+skGZXlWuGy1a8yDqnnZ+ZfmbIfpt295wWOWeFf6YyIGxxUKGDoT9T",
+	"oTununXQZO+gWn5jNSgMeRqcF1QlGaYCazKgYoD5/J9Ym8KUHQBWOTs/vzXx5zFqKxwsOvHp1SbqAkx2",
+	"8Wk8LeuEAGBTwTIHgn2VmhI/2xCCMeSUKRdptQstCXU2XLq144XkmU1xb2RDy6I9kT58nOz27/78ut21",
+	"GqznayTIIPu0C1NHWB04s16iKW07R8S7pTyj6eUCE59MDm9Nc2mzsL31ORUuE9sEoS7OrC5ke8ajzNWJ",
+	"cbNbz2S2IfpgdMwtklfS1mKqIklmZvbwNWZwm2JzXFUFiTGYJYgr3kw4nGP5MxdcLasOzKLLGDyfETrX",
+	"GBo0Cc5HlFwzdokp/oYjjjHT0nWICVQrMCVXa51v2rafV1Lp55YGVUHhP8ls83C81SzlfHOzLQRudhZA",
+	"8sCDtyqa2EKq2s4QVMB992Pn3KsIvLeYtmDXlkKHfZZfyrCxR5PtCqm59JszZt5gEQlKZmV+6RaJnLtl",
+	"CNLjFlnR/cgzXy/e0aeeO7IcVKfs0DxrBH9fXbx5S9xI7eoNz+6i3tw3Dd4AsV3JHLNxnbhw2PIOCNVy",
+	"ZX++uBUGhzPGP9eTcadFGLRsZf2o/3AD7eNLIc05HINAzOg2BYrhFyQjQjJ5fEgqWV8f0LWlQryq+t4W",
+	"8c0t35+ZOdFYl06lwsert5ntW8cL1hIm2FnFPzN5D3938LX83T9XKiVGwMA2eh5NMKLVP6Qw2rL495Ab",
+	"9UjQC470J/YhZxtyaBz89OnyqSpg7dVnw/GrkIyVGiH5FbwQKXgZzKjuLAY89Vwscq6WwCC/slwtsYuQ",
+	"XFyxouCZ1a/O0pSt9cm5G9JsyU5LR2IyQY5sXyYTyOk90JhuyBH2fhySl3Rl88hlqQm1o7pS2dXhDpiF",
+	"7e9Awj4Vi9aUBgZvpJu2NIRPkaffpHYP670ypVMCJWPhtB0gVZhqjuRvgC4MIQC+nZvE8jiQ3DHEEZyL",
+	"E1JjXYjj28VD9yP0cNNNlzzPCiZuERfP3GPfq9hITJR837BWZDyU6rG3+qKWGCdCoCCvN5wKPwXIaL8f",
+	"dPUyiPy6+in08UQReS2qDoylJcgvprPPiO58zrL6oppG5Wn45jbwc678couoYTpKAnQUC87hcd+DZed2",
+	"l+maFu4epwOr9JV96ntdpPEDL9IfLP3QLG25GGMAhocD4w9S2pTO3MfSy+oanMPVwfCx3StjTLAFr8wh",
+	"UpCMrZnImNCuZLrqfCKpgzsi0V7js4vEN3581iVvX/zVTQG3bQf4vA1w4LXk4cyjCtBdSO2I5JoWK1s3",
+	"GqwKtihoBsWaqCY5o0qbmgYAM8gqezUDPOruZrCTO/7muNWx0dmrF0+2mMljzEw6rnTJHLelVrl+YSrE",
+	"NM22avPX4rPer4FxT1AfVwGperxiBGx1e35aF2Wqy4KRI25ibmueqsD4TBVhOj0O7yHPv1pu0t+VCxKY",
+	"6tl4lghcMFVlbi1NFPCJeeCJH0ivwwGIO1Omz2vfMohMXbHnVYGzPVqPD8/nHOpvGGpAH0ueZtk1JPMP",
+	"u+vTVv3O/SgtAuDir9+kx8aBvm/nWzFd8PT2wpguMpKVRq9TAVlfLrqar1hBNAeJbeoxYKBUpVRgEYac",
+	"zlieGw9rlZLmnf6ByDwcG7c7EeZ7LLk2XUEEHmQTzg0jjaaAAcuNdIJ5zahC7VUwU6xzLWWOMlUFU2EX",
+	"5qtCwvEEVioUbcTkP92pYqafzoK61zYdvL4dKtuFfsuDrTJfSdr9uJZKo2rt0eWgkH1lXyCwLMmbX+Mz",
+	"Ep+d7ZOorvsHVlc/OfcH/d5ztBJR4UbqIqzfXpoPUHMLUuBX621rXWN+zYO7EvR3eSPI3syLutZde6W7",
+	"yvgy285dqt1VrwTuU1J96mE3LM/5WnHl9QQMxhelLJWpVLjVqQdIGIb2cxw1C+o1M0X2VdPDujK1TWmK",
+	"iDR+80f4+jeXPGSKsa9ftJe5dAdF7kFv76XAP2lSf/50qnt9Y6FK+22b8s1cmn2Uh6eeej16tN87zm3J",
+	"NQ9C/wcvIGSxBwfQnh68WwJPT2zfJ4G5lDvSI+escZOEP15I/gSjWrTjhYF1xNTE/oQtEOXq0tlzxKbM",
+	"VE07QhXkWhguM+DAGJUj5CFLGX2pJCZyv5qktiJpXY/0+6wYetfbaA4xnldOitxy+cx9a0WRNvg+gYEf",
+	"in0f98qfH0j+Xq8WIl/3biEyFdv80robWFgqoB6KylPxKXS+y4Hc4GPrq/5l/J91dvsTixab/HzhFQd2",
+	"d0IGzWRHc5rAJlS6IVwl4vq4gTuS7Z7wahRvPzQV9aDNKxQfqzCCTbmyboFWH1mbayxVV+TI5TXtOsfQ",
+	"G3u0baceByY0YTVYc9kGVilkzQMc0MWCyd1eFkz+j+uJ/JlRcM8+k3nO0pqPXZEk09UT5Y5ZbAjVGpws",
+	"GXS/psVvJdPN7q9EFtI1PBPa9uO9yDcYay/6oK46QQfg7AQdOw/jKYYu71+/YTsdpT5/DrCAUl2uRGAv",
+	"BfXvAoS61govopyb5C9c9/nv2hd6x8xDcLugtDOXYQmNSdIN1gFUFoxaz7crzg1Ot2Aq/JqtJj8ZHrhe",
+	"ypxZFzQo6CXPzRkEI6KBCpgB6vLF6krZrtZqOBVvdMHw2uCiccuYkJqoEteMS0jI+YprV1O6Pu5pfWyV",
+	"oAqmAo94m2Ls9jUuFuFUYBHK5k0DsLSNVDCFYakw3iAi07QscHvQuPXrZSHLxZJQorYhDuz5iso1yRXJ",
+	"CgkHmCAnXdoYVhNsJEfBUsavDpW6NIM9ZGKnkyou7rgvHnlYVLin2rI9h18iYF1V9LXSvArONMR409j5",
+	"5vz2SGy7eR/0KSZ3yKb8m+WcH97GbW/jD2fjD2fjD2fjf6Gz8av6Gv8bXI0/PI2P7gT74Wj8gePfoZ/x",
+	"a19h/jW9jI/qZGwS+SVWshAZubx1+QCwl80F1Ljc3RrmjBZM1UZ5Y0EJU2MEyQifWxfS5dM4CsmZn+Tp",
+	"xL9jCet/BLh3LvWornayLyl7m7ZQPGMF2tLepD+BYCbrr3kI/NCyhAl/QJvTc2vsoaEw1tMn17baKiBp",
+	"KYegI+6N9yaGtsM3lFzeX1gYjaAy3u5vu+0uwx2RXV+E7PyYtjI8Ls8a5UZS0OzfFLjzaXWuxrifHDtp",
+	"LEEF7iHt8jb9Ey542N8+C5qM7e0r8Qw5E6QUlwJO2gB+cRFAL/2ov5+ODgWPGXr47stX4OEBe1hN1bdC",
+	"KUaLdAmiI+PQ214XjHnwrsnZ1Ny7XJmTKRUZXl7frFczY0obWMxOaS88MOmReBbFnAG9Bpfye/O5zqWc",
+	"oSG04sJu3PiJ6gBv97U/wSeqj1ESUqJSWdic8AjlAwhKurLy05V8mZf/+Q/PN1hj4je3EtAJinaYMBef",
+	"YcLmmuXmANlLdp1SpXNs+YVnWc7UTKK71DhVfzNXE7tkxKCxGKk9GG0z7apzHVxX97LtnPlAaQAvBESt",
+	"aJ5Xxz7A36pBvrh1b+IC/v3U6A/eU6bpDRL6ezi39CqnKbMcUgcXqsO3KKwcvvcKj98e8KihKauKS2B3",
+	"u2bedo0s45lxwS9fznJrQOzKtXo+ecOSQbWBNgujx4du/AKuevhKkt+kODWrBD2xPqVnm0o8eGnH7QJV",
+	"U63uKk/rW+y1u+sf5R1KuAANjsBdUc6lOLlmfLGEhW9azHUrgSvaxnNgwd2rS03hOEqWXGmY08qZjM4F",
+	"Mt+6iH3nHv9bb5D3/CxQuLiltrB3r37bDRp764RPxd8Vm5c5NppiTd7RuBp57r7FCine3aRY5Q+0zQb+",
+	"7LedaKEN5+Ohlu2Y/xNbaokcsYYDFUervyfHtlofV6bOHoRQuT2raodZUkWENMNs1Smr0WItfSHtAShX",
+	"t0+W+vuuqGy1fPPs7BD5H+Lm9S95wfruDSK3TvLLbxAPcMkH3gerNNVcaZ5Wyo6YCrtmTHVXT7ggLlyq",
+	"y+Nmw3gS0ZhKIG8DX0WMIueTqR99as14/6fJYHunDAhcfzUa4DqdwMb5yhvNrx3nFf3EzWctza2xOTf3",
+	"XB9wiNQ9tpP//uWPDyOs1hTqXWLGhQrueK32lnneol08By6YcbF7sRG+ybXCm5AKwrIFs5cdwY+mwjz8",
+	"+Ed8CT1u0I/NjlGuQP2BIAMX6jFKYdcFsO92b5v9Dr/gXg+/9fr17X7TzooL+DGe9Hr+r+Zmtzga9Ifx",
+	"0G9g1Dw/6CWTKBx5TW6re18/E/eTKEwar2fcNia9uD8IB16jx4IN2KEtjuCnwaQ37If9wG9JBtAySaLR",
+	"IEwGjaYRNsWjeNLrb7dNsMMErvWLw7FrufHAUTp7n7Erg4dxv5+EPiIqpt0BFRjH3n0HSCVxNEqiOOwF",
+	"JImT/nASDgPSi0ej3iicBKSf9KJoGCYBGSTjZNQPBwEZ9nqDfhKOAzLqjcdxHMYBGff70WgS9gMy6U96",
+	"/VE4Chx53jUm5t+9N4gCEvfHAUE44oDAH/wX4b/4XTXxqaj+VJfj7bCvybtrU3U9sfzjdpFPMAP8g+Qe",
+	"Lk09u5atc68xcE0XIGD22QFqzdJPLatwE+wxKqyTBvBs1FdIWOM525qjHdvBjV8bUJf8M+FG19lSr/Jb",
+	"AK7GI395+8s5An5nWD+Cwnrj2VvSVenfTiN6VbB5DjJxV2tuO11qvt3ueGxVmO+/0zQxU9RntH00PLt4",
+	"/Yas3TyI2b/fuGPNrUx4c/P/BgBUH/bHpbAAAA==",
 }
 
 // GetOpenAPISpec returns the Swagger specification corresponding to the generated code